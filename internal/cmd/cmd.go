@@ -3,15 +3,21 @@ package cmd
 import (
 	"binance_data_gf/internal/service"
 	"context"
+	"fmt"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
 	"github.com/gogf/gf/v2/os/gcmd"
+	"github.com/gogf/gf/v2/os/gproc"
 	"github.com/gogf/gf/v2/os/gtimer"
 	"log"
+	"os"
 	"strconv"
 	"time"
 )
 
+// 退出时等待下单任务的最长时间
+const shutdownTimeout = 30 * time.Second
+
 var (
 	Main = &gcmd.Command{
 		Name: "main",
@@ -24,6 +30,14 @@ var (
 		Func: func(ctx context.Context, parser *gcmd.Parser) (err error) {
 			serviceBinanceTrader := service.BinanceTraderHistory()
 
+			// 退出信号，停止同步订单和定时任务
+			loopCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			gproc.AddSigHandlerShutdown(func(sig os.Signal) {
+				log.Println("收到退出信号：", sig)
+				cancel()
+			})
+
 			// 初始化根据数据库现有人
 			if !serviceBinanceTrader.UpdateCoinInfo(ctx) {
 				log.Println("初始化币种失败，fail")
@@ -31,40 +45,75 @@ var (
 			}
 			log.Println("初始化币种成功，ok")
 
+			// 恢复上次退出时的系统仓位
+			if err = serviceBinanceTrader.LoadSystemPositions(ctx); nil != err {
+				log.Println("恢复系统仓位失败：", err)
+			}
+
 			// 拉龟兔的保证金
-			serviceBinanceTrader.PullAndSetBaseMoneyNewGuiTuAndUser(ctx)
+			serviceBinanceTrader.PullAndSetBaseMoneyNewGuiTuAndUser(loopCtx)
+
+			// 标记价格，风控使用
+			serviceBinanceTrader.UpdateMarkPrice(loopCtx)
+
+			timerEntries := make([]*gtimer.Entry, 0)
+
+			// 3秒/次，标记价格
+			handle5 := func(ctx context.Context) {
+				serviceBinanceTrader.UpdateMarkPrice(ctx)
+			}
+			timerEntries = append(timerEntries, gtimer.AddSingleton(loopCtx, time.Second*3, handle5))
 
 			// 10秒/次，拉取保证金
 			handle := func(ctx context.Context) {
 				serviceBinanceTrader.PullAndSetBaseMoneyNewGuiTuAndUser(ctx)
 			}
-			gtimer.AddSingleton(ctx, time.Second*10, handle)
+			timerEntries = append(timerEntries, gtimer.AddSingleton(loopCtx, time.Second*10, handle))
 
 			// 30秒/次，加新人
 			handle2 := func(ctx context.Context) {
 				serviceBinanceTrader.InsertGlobalUsers(ctx)
 			}
-			gtimer.AddSingleton(ctx, time.Second*30, handle2)
+			timerEntries = append(timerEntries, gtimer.AddSingleton(loopCtx, time.Second*30, handle2))
 
 			// 300秒/次，币种信息
 			handle3 := func(ctx context.Context) {
 				serviceBinanceTrader.UpdateCoinInfo(ctx)
 			}
-			gtimer.AddSingleton(ctx, time.Second*300, handle3)
+			timerEntries = append(timerEntries, gtimer.AddSingleton(loopCtx, time.Second*300, handle3))
 
 			// 60秒/次，cookie
 			handle4 := func(ctx context.Context) {
 				serviceBinanceTrader.CookieErrEmail(ctx)
 			}
-			gtimer.AddSingleton(ctx, time.Second*60, handle4)
+			timerEntries = append(timerEntries, gtimer.AddSingleton(loopCtx, time.Second*60, handle4))
+
+			// 300秒/次，碎仓清理
+			handle6 := func(ctx context.Context) {
+				serviceBinanceTrader.SweepDust(ctx)
+			}
+			timerEntries = append(timerEntries, gtimer.AddSingleton(loopCtx, time.Second*300, handle6))
+
+			// 600秒/次，同步用户收入流水，之后结算分成
+			handle7 := func(ctx context.Context) {
+				serviceBinanceTrader.SyncUserIncome(ctx)
+				serviceBinanceTrader.SettleUserFees(ctx)
+			}
+			timerEntries = append(timerEntries, gtimer.AddSingleton(loopCtx, time.Second*600, handle7))
+
+			// 300秒/次，检查用户订阅到期
+			handle8 := func(ctx context.Context) {
+				serviceBinanceTrader.CheckSubscriptions(ctx)
+			}
+			timerEntries = append(timerEntries, gtimer.AddSingleton(loopCtx, time.Second*300, handle8))
 
-			// 任务1 同步订单，死循环
-			go serviceBinanceTrader.PullAndOrderNewGuiTu(ctx)
+			// 任务1 同步订单，收到退出信号后结束
+			serviceBinanceTrader.PullAndOrderNewGuiTu(loopCtx)
 
 			// 开启http管理服务
 			s := g.Server()
 			s.Group("/api", func(group *ghttp.RouterGroup) {
-				// 探测ip
+				// 探测ip，设置双向持仓，code为2时账户保持单向持仓
 				group.POST("/set_position_side", func(r *ghttp.Request) {
 					var (
 						setCode   uint64
@@ -337,21 +386,980 @@ var (
 					return
 				})
 
-				// cookie设置
-				group.POST("/cookie", func(r *ghttp.Request) {
+				// 查询用户风控配置
+				group.GET("/user/risk_limit", func(r *ghttp.Request) {
+					r.Response.WriteJson(serviceBinanceTrader.GetUserRiskLimit(ctx, r.Get("apiKey").String()))
+					return
+				})
+
+				// 更新用户风控配置，0不限制
+				group.POST("/user/update/risk_limit", func(r *ghttp.Request) {
+					var (
+						parseErr          error
+						setErr            error
+						maxSymbolNotional float64
+						maxTotalNotional  float64
+						maxPositions      int64
+						maxLeverage       float64
+						minFreeMargin     float64
+						leverageCap       int64
+					)
+					parseFloat := func(key string) float64 {
+						if nil != parseErr || 0 >= len(r.PostFormValue(key)) {
+							return 0
+						}
+
+						var tmp float64
+						tmp, parseErr = strconv.ParseFloat(r.PostFormValue(key), 64)
+						if nil == parseErr && 0 > tmp {
+							parseErr = fmt.Errorf("%s不能为负数", key)
+						}
+						return tmp
+					}
+
+					maxSymbolNotional = parseFloat("max_symbol_notional")
+					maxTotalNotional = parseFloat("max_total_notional")
+					maxLeverage = parseFloat("max_leverage")
+					minFreeMargin = parseFloat("min_free_margin")
+					if nil == parseErr && 0 < len(r.PostFormValue("max_positions")) {
+						maxPositions, parseErr = strconv.ParseInt(r.PostFormValue("max_positions"), 10, 64)
+						if nil == parseErr && 0 > maxPositions {
+							parseErr = fmt.Errorf("max_positions不能为负数")
+						}
+					}
+					if nil == parseErr && 0 < len(r.PostFormValue("leverage_cap")) {
+						leverageCap, parseErr = strconv.ParseInt(r.PostFormValue("leverage_cap"), 10, 64)
+						if nil == parseErr && 0 > leverageCap {
+							parseErr = fmt.Errorf("leverage_cap不能为负数")
+						}
+					}
+					if nil != parseErr {
+						r.Response.WriteJson(g.Map{
+							"code": -1,
+						})
+
+						return
+					}
+
+					setErr = serviceBinanceTrader.SetUserRiskLimit(
+						ctx,
+						r.PostFormValue("apiKey"),
+						maxSymbolNotional,
+						maxTotalNotional,
+						int(maxPositions),
+						maxLeverage,
+						minFreeMargin,
+						int(leverageCap),
+					)
+					if nil != setErr {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+						})
+
+						return
+					}
+
 					r.Response.WriteJson(g.Map{
-						"code": serviceBinanceTrader.SetCookie(ctx, r.PostFormValue("cookie"), r.PostFormValue("token")),
+						"code": 1,
 					})
 
 					return
 				})
-			})
 
-			s.SetPort(80)
-			s.Run()
+				// 查询用户风控记录
+				group.GET("/user/risk_logs", func(r *ghttp.Request) {
+					limit := r.Get("limit", 100).Int()
+					if 0 >= limit || 1000 < limit {
+						limit = 100
+					}
+
+					r.Response.WriteJson(serviceBinanceTrader.GetUserRiskLogs(ctx, r.Get("apiKey").String(), limit))
+					return
+				})
+
+				// 查询币种名单，apiKey为空时是全局名单
+				group.GET("/symbol_filters", func(r *ghttp.Request) {
+					r.Response.WriteJson(serviceBinanceTrader.GetSymbolFilters(ctx, r.Get("apiKey").String()))
+					return
+				})
+
+				// 更新币种名单，type：allow，deny，remove为1时删除
+				group.POST("/update/symbol_filter", func(r *ghttp.Request) {
+					var (
+						setErr error
+					)
+					setErr = serviceBinanceTrader.SetSymbolFilter(
+						ctx,
+						r.PostFormValue("apiKey"),
+						r.PostFormValue("symbol"),
+						r.PostFormValue("type"),
+						"1" == r.PostFormValue("remove"),
+					)
+					if nil != setErr {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  setErr.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+
+					return
+				})
+
+				// 按orderLinkId查询bybit订单
+				group.GET("/bybit/order", func(r *ghttp.Request) {
+					res, getErr := serviceBinanceTrader.GetBybitOrderByLinkId(ctx, r.Get("apiKey").String(), r.Get("orderLinkId").String())
+					if nil != getErr {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  getErr.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+						"data": res,
+					})
+
+					return
+				})
+
+				// 查询闪烁隔离配置，隔离中的币种方向和最近的隔离记录
+				group.GET("/symbol_quarantines", func(r *ghttp.Request) {
+					limit := r.Get("limit", 100).Int()
+					if 0 >= limit || 1000 < limit {
+						limit = 100
+					}
+
+					r.Response.WriteJson(serviceBinanceTrader.GetSymbolQuarantines(ctx, limit))
+					return
+				})
+
+				// 更新闪烁隔离配置，单位秒
+				group.POST("/update/symbol_quarantine_config", func(r *ghttp.Request) {
+					var (
+						parseErr error
+						setErr   error
+					)
+					parseInt := func(key string) int {
+						if nil != parseErr {
+							return 0
+						}
+
+						var tmp int64
+						tmp, parseErr = strconv.ParseInt(r.PostFormValue(key), 10, 64)
+						return int(tmp)
+					}
+
+					flickerWindow := parseInt("flicker_window")
+					countWindow := parseInt("count_window")
+					threshold := parseInt("threshold")
+					cooldown := parseInt("cooldown")
+					if nil != parseErr {
+						r.Response.WriteJson(g.Map{
+							"code": -1,
+						})
+
+						return
+					}
+
+					setErr = serviceBinanceTrader.SetSymbolQuarantineConfig(ctx, flickerWindow, countWindow, threshold, cooldown)
+					if nil != setErr {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  setErr.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+
+					return
+				})
+
+				// 手动解除闪烁隔离
+				group.POST("/symbol_quarantine/resume", func(r *ghttp.Request) {
+					setErr := serviceBinanceTrader.ResumeSymbolQuarantine(ctx, r.PostFormValue("symbol"), r.PostFormValue("positionSide"))
+					if nil != setErr {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  setErr.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+
+					return
+				})
+
+				// 查询快照确认配置，待确认的变化和增加的延迟
+				group.GET("/snapshot_confirm", func(r *ghttp.Request) {
+					r.Response.WriteJson(serviceBinanceTrader.GetSnapshotConfirm(ctx))
+					return
+				})
+
+				// 更新快照确认配置，type：close，reduce，jump，polls不大于1且duration_ms为0时不确认
+				group.POST("/update/snapshot_confirm", func(r *ghttp.Request) {
+					var (
+						parseErr   error
+						setErr     error
+						polls      int64
+						durationMs int64
+						ratio      float64
+					)
+					polls, parseErr = strconv.ParseInt(r.PostFormValue("polls"), 10, 64)
+					if nil == parseErr {
+						durationMs, parseErr = strconv.ParseInt(r.PostFormValue("duration_ms"), 10, 64)
+					}
+					if nil == parseErr && 0 < len(r.PostFormValue("ratio")) {
+						ratio, parseErr = strconv.ParseFloat(r.PostFormValue("ratio"), 64)
+					}
+					if nil != parseErr {
+						r.Response.WriteJson(g.Map{
+							"code": -1,
+						})
+
+						return
+					}
+
+					setErr = serviceBinanceTrader.SetSnapshotConfirmConfig(ctx, r.PostFormValue("type"), int(polls), int(durationMs), ratio)
+					if nil != setErr {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  setErr.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+
+					return
+				})
+
+				// 查询碎仓清理配置
+				group.GET("/dust_sweep_config", func(r *ghttp.Request) {
+					r.Response.WriteJson(serviceBinanceTrader.GetDustSweepConfig(ctx))
+					return
+				})
+
+				// 更新碎仓清理配置，enabled为1开启，max_notional名义价值低于该值视为碎仓
+				group.POST("/update/dust_sweep_config", func(r *ghttp.Request) {
+					var (
+						parseErr    error
+						setErr      error
+						enabled     int64
+						maxNotional float64
+					)
+					enabled, parseErr = strconv.ParseInt(r.PostFormValue("enabled"), 10, 64)
+					if nil == parseErr {
+						maxNotional, parseErr = strconv.ParseFloat(r.PostFormValue("max_notional"), 64)
+					}
+					if nil != parseErr {
+						r.Response.WriteJson(g.Map{
+							"code": -1,
+						})
+
+						return
+					}
+
+					setErr = serviceBinanceTrader.SetDustSweepConfig(ctx, int(enabled), maxNotional)
+					if nil != setErr {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  setErr.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+
+					return
+				})
+
+				// 查询下单方式配置
+				group.GET("/execution_policies", func(r *ghttp.Request) {
+					r.Response.WriteJson(serviceBinanceTrader.GetExecutionPolicies(ctx))
+					return
+				})
+
+				// 更新下单方式，user_id为0和symbol为空表示所有，mode：market，limit，limit时开仓加仓挂买一卖一加offset_bps，等待wait_ms（最多1000）后剩余市价，平仓始终市价
+				group.POST("/update/execution_policy", func(r *ghttp.Request) {
+					var (
+						parseErr  error
+						setErr    error
+						userId    uint64
+						offsetBps float64
+						waitMs    int64
+					)
+					if 0 < len(r.PostFormValue("user_id")) {
+						userId, parseErr = strconv.ParseUint(r.PostFormValue("user_id"), 10, 64)
+					}
+					if nil == parseErr && 0 < len(r.PostFormValue("offset_bps")) {
+						offsetBps, parseErr = strconv.ParseFloat(r.PostFormValue("offset_bps"), 64)
+					}
+					if nil == parseErr && 0 < len(r.PostFormValue("wait_ms")) {
+						waitMs, parseErr = strconv.ParseInt(r.PostFormValue("wait_ms"), 10, 64)
+					}
+					if nil != parseErr {
+						r.Response.WriteJson(g.Map{
+							"code": -1,
+						})
+
+						return
+					}
+
+					setErr = serviceBinanceTrader.SetExecutionPolicy(ctx, uint(userId), r.PostFormValue("symbol"), r.PostFormValue("mode"), offsetBps, int(waitMs))
+					if nil != setErr {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  setErr.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+
+					return
+				})
+
+				// 删除下单方式
+				group.POST("/execution_policy/delete", func(r *ghttp.Request) {
+					var (
+						parseErr error
+						setErr   error
+						userId   uint64
+					)
+					if 0 < len(r.PostFormValue("user_id")) {
+						userId, parseErr = strconv.ParseUint(r.PostFormValue("user_id"), 10, 64)
+					}
+					if nil != parseErr {
+						r.Response.WriteJson(g.Map{
+							"code": -1,
+						})
+
+						return
+					}
+
+					setErr = serviceBinanceTrader.DeleteExecutionPolicy(ctx, uint(userId), r.PostFormValue("symbol"))
+					if nil != setErr {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  setErr.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+
+					return
+				})
+
+				// 查询滑点保护配置，带单员最近的成交价和当前偏离
+				group.GET("/slippage_guard", func(r *ghttp.Request) {
+					r.Response.WriteJson(serviceBinanceTrader.GetSlippageGuard(ctx))
+					return
+				})
+
+				// 更新滑点保护配置，enabled为1开启，偏离超过threshold_bps后按比例减少开仓，达到max_bps跳过，单位万分之一
+				group.POST("/update/slippage_guard", func(r *ghttp.Request) {
+					var (
+						parseErr     error
+						setErr       error
+						enabled      int64
+						thresholdBps float64
+						maxBps       float64
+					)
+					enabled, parseErr = strconv.ParseInt(r.PostFormValue("enabled"), 10, 64)
+					if nil == parseErr {
+						thresholdBps, parseErr = strconv.ParseFloat(r.PostFormValue("threshold_bps"), 64)
+					}
+					if nil == parseErr && 0 < len(r.PostFormValue("max_bps")) {
+						maxBps, parseErr = strconv.ParseFloat(r.PostFormValue("max_bps"), 64)
+					}
+					if nil != parseErr {
+						r.Response.WriteJson(g.Map{
+							"code": -1,
+						})
+
+						return
+					}
+
+					setErr = serviceBinanceTrader.SetSlippageGuardConfig(ctx, int(enabled), thresholdBps, maxBps)
+					if nil != setErr {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  setErr.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+
+					return
+				})
+
+				// 查询带单员仓位变化记录，symbol为空时查所有币种
+				group.GET("/trader/position_history", func(r *ghttp.Request) {
+					limit := r.Get("limit", 100).Int()
+					if 0 >= limit || 1000 < limit {
+						limit = 100
+					}
+
+					r.Response.WriteJson(serviceBinanceTrader.GetTraderPositionHistory(ctx, r.Get("symbol", "").String(), limit))
+					return
+				})
+
+				// 带单员统计，最近days天的交易，胜率，持仓时长，最大回撤，按小时的活跃度
+				group.GET("/trader/analytics", func(r *ghttp.Request) {
+					days := r.Get("days", 30).Int()
+					if 0 >= days || 365 < days {
+						days = 30
+					}
+
+					r.Response.WriteJson(serviceBinanceTrader.GetTraderAnalytics(ctx, days))
+					return
+				})
+
+				// 收入报表，period：day，week，month，apiKey为空时汇总所有用户
+				group.GET("/income_report", func(r *ghttp.Request) {
+					days := r.Get("days", 30).Int()
+					if 0 >= days || 366 < days {
+						days = 30
+					}
+
+					res, err := serviceBinanceTrader.GetIncomeReport(ctx, r.Get("apiKey").String(), r.Get("period", "day").String(), days)
+					if nil != err {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  err.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(res)
+					return
+				})
+
+				// 收入报表导出csv，参数同上
+				group.GET("/income_report/csv", func(r *ghttp.Request) {
+					days := r.Get("days", 30).Int()
+					if 0 >= days || 366 < days {
+						days = 30
+					}
+
+					res, err := serviceBinanceTrader.ExportIncomeReportCsv(ctx, r.Get("apiKey").String(), r.Get("period", "day").String(), days)
+					if nil != err {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  err.Error(),
+						})
+
+						return
+					}
+
+					r.Response.Header().Set("Content-Type", "text/csv; charset=utf-8")
+					r.Response.Header().Set("Content-Disposition", "attachment; filename=income_report.csv")
+					r.Response.Write(res)
+					return
+				})
+
+				// 分成配置
+				group.GET("/fee_configs", func(r *ghttp.Request) {
+					r.Response.WriteJson(serviceBinanceTrader.GetUserFeeConfigs(ctx))
+					return
+				})
+
+				// 设置用户分成，feeRate：0.2为20%，period：day，week，month，basis：equity，realized
+				group.POST("/update/fee_config", func(r *ghttp.Request) {
+					var (
+						parseErr error
+						setErr   error
+						feeRate  float64
+					)
+					feeRate, parseErr = strconv.ParseFloat(r.PostFormValue("feeRate"), 64)
+					if nil != parseErr {
+						r.Response.WriteJson(g.Map{
+							"code": -1,
+						})
+
+						return
+					}
+
+					setErr = serviceBinanceTrader.SetUserFeeConfig(
+						ctx,
+						r.PostFormValue("apiKey"),
+						feeRate,
+						r.Get("period", "month").String(),
+						r.Get("basis", "equity").String(),
+						r.Get("dueDays", 7).Int(),
+						r.Get("pauseOverdue", 0).Int(),
+					)
+					if nil != setErr {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  setErr.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+					return
+				})
+
+				// 删除用户分成配置
+				group.POST("/fee_config/delete", func(r *ghttp.Request) {
+					err := serviceBinanceTrader.DeleteUserFeeConfig(ctx, r.PostFormValue("apiKey"))
+					if nil != err {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  err.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+					return
+				})
+
+				// 分成账单，apiKey和status为空时查询全部
+				group.GET("/fee_settlements", func(r *ghttp.Request) {
+					limit := r.Get("limit", 100).Int()
+					if 0 >= limit || 1000 < limit {
+						limit = 100
+					}
+
+					res, err := serviceBinanceTrader.GetFeeSettlements(ctx, r.Get("apiKey").String(), r.Get("status").String(), limit)
+					if nil != err {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  err.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(res)
+					return
+				})
+
+				// 账单结清，status：paid已支付，waived免除
+				group.POST("/fee_settlement/status", func(r *ghttp.Request) {
+					var (
+						parseErr error
+						id       uint64
+					)
+					id, parseErr = strconv.ParseUint(r.PostFormValue("id"), 10, 64)
+					if nil != parseErr || 0 >= id {
+						r.Response.WriteJson(g.Map{
+							"code": -1,
+						})
+
+						return
+					}
+
+					err := serviceBinanceTrader.SetFeeSettlementStatus(ctx, uint(id), r.PostFormValue("status"))
+					if nil != err {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  err.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+					return
+				})
+
+				// 代理列表
+				group.GET("/agents", func(r *ghttp.Request) {
+					r.Response.WriteJson(serviceBinanceTrader.GetAgents(ctx))
+					return
+				})
+
+				// 新增或修改代理，id为0时新增并返回token，parentId为0时为顶级代理，status：1可用，2停用
+				group.POST("/update/agent", func(r *ghttp.Request) {
+					var (
+						parseErr       error
+						commissionRate float64
+					)
+					commissionRate, parseErr = strconv.ParseFloat(r.PostFormValue("commissionRate"), 64)
+					if nil != parseErr {
+						r.Response.WriteJson(g.Map{
+							"code": -1,
+						})
+
+						return
+					}
+
+					token, err := serviceBinanceTrader.SetAgent(
+						ctx,
+						r.Get("id", 0).Uint(),
+						r.Get("parentId", 0).Uint(),
+						r.PostFormValue("name"),
+						commissionRate,
+						r.Get("status", 1).Int(),
+					)
+					if nil != err {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  err.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code":  1,
+						"token": token,
+					})
+					return
+				})
+
+				// 重置代理token
+				group.POST("/agent/token/reset", func(r *ghttp.Request) {
+					token, err := serviceBinanceTrader.ResetAgentToken(ctx, r.Get("id", 0).Uint())
+					if nil != err {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  err.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code":  1,
+						"token": token,
+					})
+					return
+				})
+
+				// 设置用户的直属代理，agentId为0时取消
+				group.POST("/update/user/agent", func(r *ghttp.Request) {
+					err := serviceBinanceTrader.SetUserAgent(ctx, r.PostFormValue("apiKey"), r.Get("agentId", 0).Uint())
+					if nil != err {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  err.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+					return
+				})
+
+				// 通知列表，userId为0时查询全部
+				group.GET("/notifications", func(r *ghttp.Request) {
+					limit := r.Get("limit", 100).Int()
+					if 0 >= limit || 1000 < limit {
+						limit = 100
+					}
+
+					r.Response.WriteJson(serviceBinanceTrader.GetNotifications(ctx, r.Get("userId", 0).Uint(), limit))
+					return
+				})
+
+				// 设置通知webhook，enabled：1推送
+				group.POST("/update/notify_config", func(r *ghttp.Request) {
+					err := serviceBinanceTrader.SetNotifyConfig(ctx, r.Get("enabled", 0).Int(), r.PostFormValue("webhookUrl"))
+					if nil != err {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  err.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+					return
+				})
+
+				// 用户订阅列表
+				group.GET("/subscriptions", func(r *ghttp.Request) {
+					r.Response.WriteJson(serviceBinanceTrader.GetUserSubscriptions(ctx))
+					return
+				})
+
+				// 设置用户订阅，expireAt：2006-01-02 15:04:05，deactivate：1宽限期后停用
+				group.POST("/update/subscription", func(r *ghttp.Request) {
+					err := serviceBinanceTrader.SetUserSubscription(
+						ctx,
+						r.PostFormValue("apiKey"),
+						r.PostFormValue("expireAt"),
+						r.Get("warnDays", 3).Int(),
+						r.Get("graceDays", 3).Int(),
+						r.Get("deactivate", 0).Int(),
+					)
+					if nil != err {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  err.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+					return
+				})
+
+				// 续费，从到期时间或现在起延长天数
+				group.POST("/subscription/extend", func(r *ghttp.Request) {
+					err := serviceBinanceTrader.ExtendUserSubscription(ctx, r.PostFormValue("apiKey"), r.Get("days", 0).Int())
+					if nil != err {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  err.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+					return
+				})
+
+				// 删除用户订阅，不再限制
+				group.POST("/subscription/delete", func(r *ghttp.Request) {
+					err := serviceBinanceTrader.DeleteUserSubscription(ctx, r.PostFormValue("apiKey"))
+					if nil != err {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  err.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+					return
+				})
+
+				// 下单数量方式
+				group.GET("/user_sizings", func(r *ghttp.Request) {
+					r.Response.WriteJson(serviceBinanceTrader.GetUserSizings(ctx))
+					return
+				})
+
+				// 设置下单数量方式，user_id为0时所有用户，mode：ratio，fixed_notional，equity_fraction，trader_multiple，capped_ratio
+				group.POST("/update/user_sizing", func(r *ghttp.Request) {
+					var (
+						parseErr    error
+						setErr      error
+						userId      uint64
+						value       float64
+						minNotional float64
+						maxNotional float64
+					)
+					if 0 < len(r.PostFormValue("user_id")) {
+						userId, parseErr = strconv.ParseUint(r.PostFormValue("user_id"), 10, 64)
+					}
+					if nil == parseErr && 0 < len(r.PostFormValue("value")) {
+						value, parseErr = strconv.ParseFloat(r.PostFormValue("value"), 64)
+					}
+					if nil == parseErr && 0 < len(r.PostFormValue("min_notional")) {
+						minNotional, parseErr = strconv.ParseFloat(r.PostFormValue("min_notional"), 64)
+					}
+					if nil == parseErr && 0 < len(r.PostFormValue("max_notional")) {
+						maxNotional, parseErr = strconv.ParseFloat(r.PostFormValue("max_notional"), 64)
+					}
+					if nil != parseErr {
+						r.Response.WriteJson(g.Map{
+							"code": -1,
+						})
+
+						return
+					}
+
+					setErr = serviceBinanceTrader.SetUserSizing(ctx, uint(userId), r.PostFormValue("mode"), value, minNotional, maxNotional)
+					if nil != setErr {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  setErr.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+
+					return
+				})
+
+				// 删除下单数量方式
+				group.POST("/user_sizing/delete", func(r *ghttp.Request) {
+					var (
+						parseErr error
+						setErr   error
+						userId   uint64
+					)
+					if 0 < len(r.PostFormValue("user_id")) {
+						userId, parseErr = strconv.ParseUint(r.PostFormValue("user_id"), 10, 64)
+					}
+					if nil != parseErr {
+						r.Response.WriteJson(g.Map{
+							"code": -1,
+						})
+
+						return
+					}
+
+					setErr = serviceBinanceTrader.DeleteUserSizing(ctx, uint(userId))
+					if nil != setErr {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  setErr.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(g.Map{
+						"code": 1,
+					})
+
+					return
+				})
+
+				// 保证金和最近一次刷新时间，过期的用户不按保证金开仓加仓
+				group.GET("/money_freshness", func(r *ghttp.Request) {
+					r.Response.WriteJson(serviceBinanceTrader.GetMoneyFreshness(ctx))
+					return
+				})
+
+				// cookie设置
+				group.POST("/cookie", func(r *ghttp.Request) {
+					r.Response.WriteJson(g.Map{
+						"code": serviceBinanceTrader.SetCookie(ctx, r.PostFormValue("cookie"), r.PostFormValue("token")),
+					})
+
+					return
+				})
+			})
+
+			// 代理接口单独的服务和端口，只对代理开放，不暴露管理接口
+			agentServer := g.Server("agent")
+			agentServer.Group("/api", func(group *ghttp.RouterGroup) {
+				// 代理查看自己和下级代理的用户，token放在X-Agent-Token请求头或token参数
+				group.GET("/agent/users", func(r *ghttp.Request) {
+					token := r.Header.Get("X-Agent-Token")
+					if 0 >= len(token) {
+						token = r.Get("token").String()
+					}
+
+					res, err := serviceBinanceTrader.GetAgentUsers(ctx, token)
+					if nil != err {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  err.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(res)
+					return
+				})
+
+				// 代理查看自己的佣金
+				group.GET("/agent/commissions", func(r *ghttp.Request) {
+					token := r.Header.Get("X-Agent-Token")
+					if 0 >= len(token) {
+						token = r.Get("token").String()
+					}
+
+					limit := r.Get("limit", 100).Int()
+					if 0 >= limit || 1000 < limit {
+						limit = 100
+					}
+
+					res, err := serviceBinanceTrader.GetAgentCommissions(ctx, token, limit)
+					if nil != err {
+						r.Response.WriteJson(g.Map{
+							"code": -2,
+							"msg":  err.Error(),
+						})
+
+						return
+					}
+
+					r.Response.WriteJson(res)
+					return
+				})
+			})
+
+			agentServer.SetPort(8081)
+			if startErr := agentServer.Start(); nil != startErr {
+				log.Println("代理服务启动失败：", startErr)
+			}
+
+			s.SetPort(80)
+			s.Run()
+
+			// 阻塞，等待退出信号
+			<-loopCtx.Done()
+
+			// 停止定时任务，等待下单任务完成，系统仓位落库
+			for _, entry := range timerEntries {
+				entry.Close()
+			}
+			serviceBinanceTrader.Shutdown(ctx, shutdownTimeout)
 
-			// 阻塞
-			select {}
+			return nil
 		},
 	}
 )
@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalAgentDao is internal type for wrapping internal DAO implements.
+type internalAgentDao = *internal.AgentDao
+
+// agentDao is the data access object for table agent.
+// You can define custom methods on it to extend its functionality as you wish.
+type agentDao struct {
+	internalAgentDao
+}
+
+var (
+	// Agent is globally public accessible object for table agent operations.
+	Agent = agentDao{
+		internal.NewAgentDao(),
+	}
+)
+
+// Fill with you ideas below.
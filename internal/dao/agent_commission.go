@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalAgentCommissionDao is internal type for wrapping internal DAO implements.
+type internalAgentCommissionDao = *internal.AgentCommissionDao
+
+// agentCommissionDao is the data access object for table agent_commission.
+// You can define custom methods on it to extend its functionality as you wish.
+type agentCommissionDao struct {
+	internalAgentCommissionDao
+}
+
+var (
+	// AgentCommission is globally public accessible object for table agent_commission operations.
+	AgentCommission = agentCommissionDao{
+		internal.NewAgentCommissionDao(),
+	}
+)
+
+// Fill with you ideas below.
@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalDustSweepConfigDao is internal type for wrapping internal DAO implements.
+type internalDustSweepConfigDao = *internal.DustSweepConfigDao
+
+// dustSweepConfigDao is the data access object for table dust_sweep_config.
+// You can define custom methods on it to extend its functionality as you wish.
+type dustSweepConfigDao struct {
+	internalDustSweepConfigDao
+}
+
+var (
+	// DustSweepConfig is globally public accessible object for table dust_sweep_config operations.
+	DustSweepConfig = dustSweepConfigDao{
+		internal.NewDustSweepConfigDao(),
+	}
+)
+
+// Fill with you ideas below.
@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalExecutionPolicyDao is internal type for wrapping internal DAO implements.
+type internalExecutionPolicyDao = *internal.ExecutionPolicyDao
+
+// executionPolicyDao is the data access object for table execution_policy.
+// You can define custom methods on it to extend its functionality as you wish.
+type executionPolicyDao struct {
+	internalExecutionPolicyDao
+}
+
+var (
+	// ExecutionPolicy is globally public accessible object for table execution_policy operations.
+	ExecutionPolicy = executionPolicyDao{
+		internal.NewExecutionPolicyDao(),
+	}
+)
+
+// Fill with you ideas below.
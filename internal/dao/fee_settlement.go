@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalFeeSettlementDao is internal type for wrapping internal DAO implements.
+type internalFeeSettlementDao = *internal.FeeSettlementDao
+
+// feeSettlementDao is the data access object for table fee_settlement.
+// You can define custom methods on it to extend its functionality as you wish.
+type feeSettlementDao struct {
+	internalFeeSettlementDao
+}
+
+var (
+	// FeeSettlement is globally public accessible object for table fee_settlement operations.
+	FeeSettlement = feeSettlementDao{
+		internal.NewFeeSettlementDao(),
+	}
+)
+
+// Fill with you ideas below.
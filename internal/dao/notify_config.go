@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalNotifyConfigDao is internal type for wrapping internal DAO implements.
+type internalNotifyConfigDao = *internal.NotifyConfigDao
+
+// notifyConfigDao is the data access object for table notify_config.
+// You can define custom methods on it to extend its functionality as you wish.
+type notifyConfigDao struct {
+	internalNotifyConfigDao
+}
+
+var (
+	// NotifyConfig is globally public accessible object for table notify_config operations.
+	NotifyConfig = notifyConfigDao{
+		internal.NewNotifyConfigDao(),
+	}
+)
+
+// Fill with you ideas below.
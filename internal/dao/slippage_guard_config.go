@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalSlippageGuardConfigDao is internal type for wrapping internal DAO implements.
+type internalSlippageGuardConfigDao = *internal.SlippageGuardConfigDao
+
+// slippageGuardConfigDao is the data access object for table slippage_guard_config.
+// You can define custom methods on it to extend its functionality as you wish.
+type slippageGuardConfigDao struct {
+	internalSlippageGuardConfigDao
+}
+
+var (
+	// SlippageGuardConfig is globally public accessible object for table slippage_guard_config operations.
+	SlippageGuardConfig = slippageGuardConfigDao{
+		internal.NewSlippageGuardConfigDao(),
+	}
+)
+
+// Fill with you ideas below.
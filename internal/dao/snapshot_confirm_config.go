@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalSnapshotConfirmConfigDao is internal type for wrapping internal DAO implements.
+type internalSnapshotConfirmConfigDao = *internal.SnapshotConfirmConfigDao
+
+// snapshotConfirmConfigDao is the data access object for table snapshot_confirm_config.
+// You can define custom methods on it to extend its functionality as you wish.
+type snapshotConfirmConfigDao struct {
+	internalSnapshotConfirmConfigDao
+}
+
+var (
+	// SnapshotConfirmConfig is globally public accessible object for table snapshot_confirm_config operations.
+	SnapshotConfirmConfig = snapshotConfirmConfigDao{
+		internal.NewSnapshotConfirmConfigDao(),
+	}
+)
+
+// Fill with you ideas below.
@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalSymbolFilterDao is internal type for wrapping internal DAO implements.
+type internalSymbolFilterDao = *internal.SymbolFilterDao
+
+// symbolFilterDao is the data access object for table symbol_filter.
+// You can define custom methods on it to extend its functionality as you wish.
+type symbolFilterDao struct {
+	internalSymbolFilterDao
+}
+
+var (
+	// SymbolFilter is globally public accessible object for table symbol_filter operations.
+	SymbolFilter = symbolFilterDao{
+		internal.NewSymbolFilterDao(),
+	}
+)
+
+// Fill with you ideas below.
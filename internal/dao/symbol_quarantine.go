@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalSymbolQuarantineDao is internal type for wrapping internal DAO implements.
+type internalSymbolQuarantineDao = *internal.SymbolQuarantineDao
+
+// symbolQuarantineDao is the data access object for table symbol_quarantine.
+// You can define custom methods on it to extend its functionality as you wish.
+type symbolQuarantineDao struct {
+	internalSymbolQuarantineDao
+}
+
+var (
+	// SymbolQuarantine is globally public accessible object for table symbol_quarantine operations.
+	SymbolQuarantine = symbolQuarantineDao{
+		internal.NewSymbolQuarantineDao(),
+	}
+)
+
+// Fill with you ideas below.
@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalSymbolQuarantineConfigDao is internal type for wrapping internal DAO implements.
+type internalSymbolQuarantineConfigDao = *internal.SymbolQuarantineConfigDao
+
+// symbolQuarantineConfigDao is the data access object for table symbol_quarantine_config.
+// You can define custom methods on it to extend its functionality as you wish.
+type symbolQuarantineConfigDao struct {
+	internalSymbolQuarantineConfigDao
+}
+
+var (
+	// SymbolQuarantineConfig is globally public accessible object for table symbol_quarantine_config operations.
+	SymbolQuarantineConfig = symbolQuarantineConfigDao{
+		internal.NewSymbolQuarantineConfigDao(),
+	}
+)
+
+// Fill with you ideas below.
@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalTraderMarginHistoryDao is internal type for wrapping internal DAO implements.
+type internalTraderMarginHistoryDao = *internal.TraderMarginHistoryDao
+
+// traderMarginHistoryDao is the data access object for table trader_margin_history.
+// You can define custom methods on it to extend its functionality as you wish.
+type traderMarginHistoryDao struct {
+	internalTraderMarginHistoryDao
+}
+
+var (
+	// TraderMarginHistory is globally public accessible object for table trader_margin_history operations.
+	TraderMarginHistory = traderMarginHistoryDao{
+		internal.NewTraderMarginHistoryDao(),
+	}
+)
+
+// Fill with you ideas below.
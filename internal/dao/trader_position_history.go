@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalTraderPositionHistoryDao is internal type for wrapping internal DAO implements.
+type internalTraderPositionHistoryDao = *internal.TraderPositionHistoryDao
+
+// traderPositionHistoryDao is the data access object for table trader_position_history.
+// You can define custom methods on it to extend its functionality as you wish.
+type traderPositionHistoryDao struct {
+	internalTraderPositionHistoryDao
+}
+
+var (
+	// TraderPositionHistory is globally public accessible object for table trader_position_history operations.
+	TraderPositionHistory = traderPositionHistoryDao{
+		internal.NewTraderPositionHistoryDao(),
+	}
+)
+
+// Fill with you ideas below.
@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalUserFeeConfigDao is internal type for wrapping internal DAO implements.
+type internalUserFeeConfigDao = *internal.UserFeeConfigDao
+
+// userFeeConfigDao is the data access object for table user_fee_config.
+// You can define custom methods on it to extend its functionality as you wish.
+type userFeeConfigDao struct {
+	internalUserFeeConfigDao
+}
+
+var (
+	// UserFeeConfig is globally public accessible object for table user_fee_config operations.
+	UserFeeConfig = userFeeConfigDao{
+		internal.NewUserFeeConfigDao(),
+	}
+)
+
+// Fill with you ideas below.
@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalUserIncomeDao is internal type for wrapping internal DAO implements.
+type internalUserIncomeDao = *internal.UserIncomeDao
+
+// userIncomeDao is the data access object for table user_income.
+// You can define custom methods on it to extend its functionality as you wish.
+type userIncomeDao struct {
+	internalUserIncomeDao
+}
+
+var (
+	// UserIncome is globally public accessible object for table user_income operations.
+	UserIncome = userIncomeDao{
+		internal.NewUserIncomeDao(),
+	}
+)
+
+// Fill with you ideas below.
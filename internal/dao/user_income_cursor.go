@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalUserIncomeCursorDao is internal type for wrapping internal DAO implements.
+type internalUserIncomeCursorDao = *internal.UserIncomeCursorDao
+
+// userIncomeCursorDao is the data access object for table user_income_cursor.
+// You can define custom methods on it to extend its functionality as you wish.
+type userIncomeCursorDao struct {
+	internalUserIncomeCursorDao
+}
+
+var (
+	// UserIncomeCursor is globally public accessible object for table user_income_cursor operations.
+	UserIncomeCursor = userIncomeCursorDao{
+		internal.NewUserIncomeCursorDao(),
+	}
+)
+
+// Fill with you ideas below.
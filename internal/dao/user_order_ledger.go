@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalUserOrderLedgerDao is internal type for wrapping internal DAO implements.
+type internalUserOrderLedgerDao = *internal.UserOrderLedgerDao
+
+// userOrderLedgerDao is the data access object for table user_order_ledger.
+// You can define custom methods on it to extend its functionality as you wish.
+type userOrderLedgerDao struct {
+	internalUserOrderLedgerDao
+}
+
+var (
+	// UserOrderLedger is globally public accessible object for table user_order_ledger operations.
+	UserOrderLedger = userOrderLedgerDao{
+		internal.NewUserOrderLedgerDao(),
+	}
+)
+
+// Fill with you ideas below.
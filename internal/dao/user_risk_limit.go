@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalUserRiskLimitDao is internal type for wrapping internal DAO implements.
+type internalUserRiskLimitDao = *internal.UserRiskLimitDao
+
+// userRiskLimitDao is the data access object for table user_risk_limit.
+// You can define custom methods on it to extend its functionality as you wish.
+type userRiskLimitDao struct {
+	internalUserRiskLimitDao
+}
+
+var (
+	// UserRiskLimit is globally public accessible object for table user_risk_limit operations.
+	UserRiskLimit = userRiskLimitDao{
+		internal.NewUserRiskLimitDao(),
+	}
+)
+
+// Fill with you ideas below.
@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalUserRiskLogDao is internal type for wrapping internal DAO implements.
+type internalUserRiskLogDao = *internal.UserRiskLogDao
+
+// userRiskLogDao is the data access object for table user_risk_log.
+// You can define custom methods on it to extend its functionality as you wish.
+type userRiskLogDao struct {
+	internalUserRiskLogDao
+}
+
+var (
+	// UserRiskLog is globally public accessible object for table user_risk_log operations.
+	UserRiskLog = userRiskLogDao{
+		internal.NewUserRiskLogDao(),
+	}
+)
+
+// Fill with you ideas below.
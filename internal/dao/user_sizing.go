@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalUserSizingDao is internal type for wrapping internal DAO implements.
+type internalUserSizingDao = *internal.UserSizingDao
+
+// userSizingDao is the data access object for table user_sizing.
+// You can define custom methods on it to extend its functionality as you wish.
+type userSizingDao struct {
+	internalUserSizingDao
+}
+
+var (
+	// UserSizing is globally public accessible object for table user_sizing operations.
+	UserSizing = userSizingDao{
+		internal.NewUserSizingDao(),
+	}
+)
+
+// Fill with you ideas below.
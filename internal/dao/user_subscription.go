@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalUserSubscriptionDao is internal type for wrapping internal DAO implements.
+type internalUserSubscriptionDao = *internal.UserSubscriptionDao
+
+// userSubscriptionDao is the data access object for table user_subscription.
+// You can define custom methods on it to extend its functionality as you wish.
+type userSubscriptionDao struct {
+	internalUserSubscriptionDao
+}
+
+var (
+	// UserSubscription is globally public accessible object for table user_subscription operations.
+	UserSubscription = userSubscriptionDao{
+		internal.NewUserSubscriptionDao(),
+	}
+)
+
+// Fill with you ideas below.
@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"binance_data_gf/internal/dao/internal"
+)
+
+// internalUserSystemPositionDao is internal type for wrapping internal DAO implements.
+type internalUserSystemPositionDao = *internal.UserSystemPositionDao
+
+// userSystemPositionDao is the data access object for table user_system_position.
+// You can define custom methods on it to extend its functionality as you wish.
+type userSystemPositionDao struct {
+	internalUserSystemPositionDao
+}
+
+var (
+	// UserSystemPosition is globally public accessible object for table user_system_position operations.
+	UserSystemPosition = userSystemPositionDao{
+		internal.NewUserSystemPositionDao(),
+	}
+)
+
+// Fill with you ideas below.
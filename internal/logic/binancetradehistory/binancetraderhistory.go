@@ -36,6 +36,11 @@ type (
 	sBinanceTraderHistory struct {
 		// 全局存储
 		pool *grpool.Pool
+		// 运行中的同步、加人任务，退出时等待
+		running sync.WaitGroup
+		// 登记任务和开始退出互斥，退出开始后不再登记
+		runningMu sync.Mutex
+		closing   bool
 	}
 )
 
@@ -45,41 +50,31 @@ func init() {
 
 func New() *sBinanceTraderHistory {
 	return &sBinanceTraderHistory{
-		grpool.New(),
+		pool: grpool.New(),
 	}
 }
 
-func IsEqual(f1, f2 float64) bool {
-	if f1 > f2 {
-		return f1-f2 < 0.000000001
-	} else {
-		return f2-f1 < 0.000000001
-	}
-}
+// sleepWithCtx 可中断的等待，ctx取消时返回false
+func sleepWithCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
 
-// floatEqual 判断两个浮点数是否在精度范围内相等
-func floatEqual(a, b, epsilon float64) bool {
-	return math.Abs(a-b) <= epsilon
-}
-
-func lessThanOrEqualZero(a, b float64, epsilon float64) bool {
-	return a-b < epsilon || math.Abs(a-b) < epsilon
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
 }
 
-// 获取步长的小数精度
-func getStringFromStepSizePrecision(value, stepSize float64) string {
-	stepStr := strconv.FormatFloat(stepSize, 'f', -1, 64) // 转换为字符串
-	parts := strings.Split(stepStr, ".")
-	if len(parts) == 2 { // 小数部分的长度即为精度
-		return strconv.FormatFloat(value, 'f', len(parts[1]), 64) // 保留相同的小数位数
-	} else {
-		return fmt.Sprintf("%d", int64(value))
-	}
+// IsEqual 按十进制比较，数量都来自接口的字符串，转回十进制后是精确的
+func IsEqual(f1, f2 float64) bool {
+	return decimal.NewFromFloat(f1).Equal(decimal.NewFromFloat(f2))
 }
 
-// 调整数值并返回符合步长精度的字符串
-func adjustToStepSize(value, stepSize float64) float64 {
-	return math.Ceil(value/stepSize) * stepSize
+// lessThanOrEqual 按十进制比较 a<=b
+func lessThanOrEqual(a, b float64) bool {
+	return decimal.NewFromFloat(a).LessThanOrEqual(decimal.NewFromFloat(b))
 }
 
 type LhCoinSymbol struct {
@@ -97,10 +92,23 @@ type LhCoinSymbol struct {
 	VolumePlace       int     `json:"volumePlace"       ` //
 	SizeMultiplier    float64 `json:"sizeMultiplier"    ` //
 	QuantoMultiplier  float64 `json:"quantoMultiplier"  ` //
+	MinQty            float64 `json:"minQty"            ` // LOT_SIZE
+	MaxQty            float64 `json:"maxQty"            ` // LOT_SIZE
+	StepSize          float64 `json:"stepSize"          ` // LOT_SIZE
+	MarketMinQty      float64 `json:"marketMinQty"      ` // MARKET_LOT_SIZE
+	MarketMaxQty      float64 `json:"marketMaxQty"      ` // MARKET_LOT_SIZE
+	MarketStepSize    float64 `json:"marketStepSize"    ` // MARKET_LOT_SIZE
+	MinNotional       float64 `json:"minNotional"       ` // MIN_NOTIONAL
+	TickSize          float64 `json:"tickSize"          ` // PRICE_FILTER
 }
 
 type BybitSymbol struct {
-	QtyStep float64
+	QtyStep        float64
+	MinOrderQty    float64
+	MaxOrderQty    float64
+	MaxMktOrderQty float64
+	MinNotional    float64
+	TickSize       float64
 }
 
 type TraderPosition struct {
@@ -114,7 +122,7 @@ var (
 	orderMap        = gmap.New(true)              // 初始化下单记录
 	orderMapTmp     = gmap.New(true)              // 初始化下单记录
 
-	baseMoneyGuiTu      = gtype.NewFloat64()
+	baseMoneyGuiTu      = gtype.NewAny(decimal.Zero)
 	baseMoneyUserAllMap = gmap.NewIntAnyMap(true)
 
 	globalUsers        = gmap.New(true)
@@ -144,9 +152,28 @@ func (s *sBinanceTraderHistory) UpdateCoinInfo(ctx context.Context) bool {
 	}
 
 	for _, v := range binanceSymbolInfo {
-		symbolsMap.Set(v.Symbol, &LhCoinSymbol{
+		tmpSymbol := &LhCoinSymbol{
 			QuantityPrecision: v.QuantityPrecision,
-		})
+		}
+
+		for _, vFilter := range v.Filters {
+			switch vFilter.FilterType {
+			case "LOT_SIZE":
+				tmpSymbol.MinQty = parseFilterFloat(vFilter.MinQty)
+				tmpSymbol.MaxQty = parseFilterFloat(vFilter.MaxQty)
+				tmpSymbol.StepSize = parseFilterFloat(vFilter.StepSize)
+			case "MARKET_LOT_SIZE":
+				tmpSymbol.MarketMinQty = parseFilterFloat(vFilter.MinQty)
+				tmpSymbol.MarketMaxQty = parseFilterFloat(vFilter.MaxQty)
+				tmpSymbol.MarketStepSize = parseFilterFloat(vFilter.StepSize)
+			case "MIN_NOTIONAL":
+				tmpSymbol.MinNotional = parseFilterFloat(vFilter.Notional)
+			case "PRICE_FILTER":
+				tmpSymbol.TickSize = parseFilterFloat(vFilter.TickSize)
+			}
+		}
+
+		symbolsMap.Set(v.Symbol, tmpSymbol)
 	}
 
 	bybitSymbolInfo, err = getByBitCoinInfo(ctx)
@@ -168,20 +195,25 @@ func (s *sBinanceTraderHistory) UpdateCoinInfo(ctx context.Context) bool {
 			continue
 		}
 
-		if lessThanOrEqualZero(tmp, 0, 1e-7) {
+		if 0 >= tmp {
 			log.Println("更新币种，bybit", tmp, err)
 			continue
 		}
 
 		symbolsBybitMap.Set(v.Symbol, &BybitSymbol{
-			QtyStep: tmp,
+			QtyStep:        tmp,
+			MinOrderQty:    parseFilterFloat(v.LotSizeFilter.MinOrderQty),
+			MaxOrderQty:    parseFilterFloat(v.LotSizeFilter.MaxOrderQty),
+			MaxMktOrderQty: parseFilterFloat(v.LotSizeFilter.MaxMktOrderQty),
+			MinNotional:    parseFilterFloat(v.LotSizeFilter.MinNotionalValue),
+			TickSize:       parseFilterFloat(v.PriceFilter.TickSize),
 		})
 	}
 
 	return true
 }
 
-// PullAndSetBaseMoneyNewGuiTuAndUser 拉取binance保证金数据
+// PullAndSetBaseMoneyNewGuiTuAndUser 拉取binance保证金数据，用户按平台并发刷新
 func (s *sBinanceTraderHistory) PullAndSetBaseMoneyNewGuiTuAndUser(ctx context.Context) {
 	var (
 		err error
@@ -193,18 +225,19 @@ func (s *sBinanceTraderHistory) PullAndSetBaseMoneyNewGuiTuAndUser(ctx context.C
 		log.Println("拉取保证金失败：", err, globalTraderNum)
 	}
 	if 0 < len(one) {
-		var tmp float64
-		tmp, err = strconv.ParseFloat(one, 64)
+		var tmp decimal.Decimal
+		tmp, err = decimal.NewFromString(one)
 		if nil != err {
 			log.Println("拉取保证金，转化失败：", err, globalTraderNum)
-		}
-
-		if !floatEqual(tmp, baseMoneyGuiTu.Val(), 100) {
-			log.Println("带单员保证金变更成功", tmp, baseMoneyGuiTu.Val())
-			baseMoneyGuiTu.Set(tmp)
+		} else {
+			if moneyChanged(tmp, traderBaseMoney(), moneyChangeRatio) {
+				log.Println("带单员保证金变更成功", tmp, traderBaseMoney())
+				baseMoneyGuiTu.Set(tmp)
+				recordTraderMargin(ctx, tmp)
+			}
+			traderMoneyRefreshedAt.Set(time.Now())
 		}
 	}
-	time.Sleep(300 * time.Millisecond)
 
 	var (
 		users []*entity.User
@@ -217,88 +250,42 @@ func (s *sBinanceTraderHistory) PullAndSetBaseMoneyNewGuiTuAndUser(ctx context.C
 		return
 	}
 
-	tmpUserMap := make(map[uint]*entity.User, 0)
+	nums := make(map[uint]float64, 0)
 	for _, vUsers := range users {
-		tmpUserMap[vUsers.Id] = vUsers
+		nums[vUsers.Id] = vUsers.Num
 	}
 
+	tmpUsers := make([]*entity.User, 0)
 	globalUsers.Iterator(func(k interface{}, v interface{}) bool {
 		vGlobalUsers := v.(*entity.User)
-
-		if _, ok := tmpUserMap[vGlobalUsers.Id]; !ok {
+		if _, ok := nums[vGlobalUsers.Id]; !ok {
 			log.Println("变更保证金，用户数据错误，数据库不存在：", vGlobalUsers)
 			return true
 		}
 
-		if "binance" == tmpUserMap[vGlobalUsers.Id].Plat {
-			var (
-				detail string
-			)
-			detail = getBinanceInfo(vGlobalUsers.ApiKey, vGlobalUsers.ApiSecret)
-			if 0 < len(detail) {
-				var tmp float64
-				tmp, err = strconv.ParseFloat(detail, 64)
-				if nil != err {
-					log.Println("拉取保证金，转化失败：", err, vGlobalUsers)
-					return true
-				}
-
-				originTmp := tmp
-				tmp *= tmpUserMap[vGlobalUsers.Id].Num
-				if !baseMoneyUserAllMap.Contains(int(vGlobalUsers.Id)) {
-					log.Println("初始化成功保证金", vGlobalUsers, tmp, originTmp, tmpUserMap[vGlobalUsers.Id].Num)
-					baseMoneyUserAllMap.Set(int(vGlobalUsers.Id), tmp)
-				} else {
-					if !floatEqual(tmp, baseMoneyUserAllMap.Get(int(vGlobalUsers.Id)).(float64), 100) {
-						log.Println("保证金变更成功", int(vGlobalUsers.Id), tmp, originTmp, tmpUserMap[vGlobalUsers.Id].Num)
-						baseMoneyUserAllMap.Set(int(vGlobalUsers.Id), tmp)
-					}
-				}
-			}
-
-		} else if "bybit" == tmpUserMap[vGlobalUsers.Id].Plat {
-			var (
-				list []*BybitBalanceAccount
-			)
-			list, err = getBybitAccountBalance(ctx, vGlobalUsers.ApiKey, vGlobalUsers.ApiSecret)
-			if 0 < len(list) {
-				detail := list[0].TotalMarginBalance
-				if 0 < len(detail) {
-					var tmp float64
-					tmp, err = strconv.ParseFloat(detail, 64)
-					if nil != err {
-						log.Println("拉取保证金，转化失败，bybit：", err, vGlobalUsers)
-						return true
-					}
-
-					originTmp := tmp
-					tmp *= tmpUserMap[vGlobalUsers.Id].Num
-					if !baseMoneyUserAllMap.Contains(int(vGlobalUsers.Id)) {
-						log.Println("初始化成功保证金，bybit", vGlobalUsers, tmp, originTmp, tmpUserMap[vGlobalUsers.Id].Num)
-						baseMoneyUserAllMap.Set(int(vGlobalUsers.Id), tmp)
-					} else {
-						if !floatEqual(tmp, baseMoneyUserAllMap.Get(int(vGlobalUsers.Id)).(float64), 100) {
-							log.Println("保证金变更成功，bybit", int(vGlobalUsers.Id), tmp, originTmp, tmpUserMap[vGlobalUsers.Id].Num)
-							baseMoneyUserAllMap.Set(int(vGlobalUsers.Id), tmp)
-						}
-					}
-				}
-			} else {
-				log.Println("保证金bybit平台，拉取失败，", vGlobalUsers)
-			}
-		}
-
-		time.Sleep(300 * time.Millisecond)
+		tmpUsers = append(tmpUsers, vGlobalUsers)
 		return true
 	})
+
+	refreshUserMoneys(ctx, tmpUsers, nums)
 }
 
 // InsertGlobalUsers  新增用户
 func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
+	// 退出中，不再加人
+	if !s.beginTask(ctx) {
+		return
+	}
+	defer s.running.Done()
+
 	var (
 		err   error
 		users []*entity.User
 	)
+	// 初始化仓位的下单不随退出信号中断
+	orderCtx := context.WithoutCancel(ctx)
+	cycle := orderCycle(time.Now())
+
 	err = g.Model("user").Ctx(ctx).
 		Where("api_status=?", 1).
 		Scan(&users)
@@ -312,8 +299,24 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 		tmpUserMap[vUsers.Id] = vUsers
 	}
 
+	// 风控配置，币种名单
+	loadUserRiskLimits(ctx)
+	_ = loadSymbolFilters(ctx)
+	loadQuarantineConfig(ctx)
+	loadSnapshotConfirmConfigs(ctx)
+	loadDustSweepConfig(ctx)
+	loadExecutionPolicies(ctx)
+	loadSlippageGuardConfig(ctx)
+	loadSubscriptions(ctx)
+	loadUserSizings(ctx)
+
 	// 第一遍比较，新增
 	for _, vTmpUserMap := range users {
+		if nil != ctx.Err() {
+			log.Println("新增用户，退出中")
+			return
+		}
+
 		if globalUsers.Contains(vTmpUserMap.Id) {
 			// 变更可否开新仓
 			if 2 != vTmpUserMap.OpenStatus && 2 == globalUsers.Get(vTmpUserMap.Id).(*entity.User).OpenStatus {
@@ -325,7 +328,7 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 			}
 
 			// 变更num
-			if !floatEqual(vTmpUserMap.Num, globalUsers.Get(vTmpUserMap.Id).(*entity.User).Num, 1e-7) {
+			if !IsEqual(vTmpUserMap.Num, globalUsers.Get(vTmpUserMap.Id).(*entity.User).Num) {
 				log.Println("用户变更num:", vTmpUserMap)
 				globalUsers.Set(vTmpUserMap.Id, vTmpUserMap)
 			}
@@ -345,6 +348,9 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 
 		globalUsersOrderId.Set(vTmpUserMap.Id, uint64(0))
 
+		// 持仓模式，单向持仓时按净仓位下单
+		refreshPositionMode(ctx, vTmpUserMap)
+
 		// 初始化仓位
 		if 1 == vTmpUserMap.NeedInit {
 			_, err = g.Model("user").Ctx(ctx).Data("need_init", 0).Where("id=?", vTmpUserMap.Id).Update()
@@ -354,20 +360,20 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 
 			strUserId := strconv.FormatUint(uint64(vTmpUserMap.Id), 10)
 
-			if lessThanOrEqualZero(vTmpUserMap.Num, 0, 1e-7) {
+			if 0 >= vTmpUserMap.Num {
 				log.Println("新增用户，保证金系数错误：", vTmpUserMap)
 				continue
 			}
 
 			// 新增仓位
-			tmpTraderBaseMoney := baseMoneyGuiTu.Val()
-			if lessThanOrEqualZero(tmpTraderBaseMoney, 0, 1e-7) {
+			tmpTraderBaseMoney := traderBaseMoney()
+			if !tmpTraderBaseMoney.IsPositive() {
 				log.Println("新增用户，交易员信息无效了，信息", vTmpUserMap)
 				continue
 			}
 
 			// 获取保证金
-			var tmpUserBindTradersAmount float64
+			var tmpUserBindTradersAmount decimal.Decimal
 
 			if "binance" == vTmpUserMap.Plat {
 				var (
@@ -378,20 +384,20 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 					log.Println("新增用户，拉取保证金失败：", err, vTmpUserMap)
 				}
 				if 0 < len(detail) {
-					var tmp float64
-					tmp, err = strconv.ParseFloat(detail, 64)
+					var originTmp decimal.Decimal
+					originTmp, err = decimal.NewFromString(detail)
 					if nil != err {
 						log.Println("新增用户，拉取保证金，转化失败：", err, vTmpUserMap)
 					}
 
-					originTmp := tmp
-					tmp *= vTmpUserMap.Num
+					tmp := originTmp.Mul(decimal.NewFromFloat(vTmpUserMap.Num))
 					tmpUserBindTradersAmount = tmp
-					if !baseMoneyUserAllMap.Contains(int(vTmpUserMap.Id)) {
+					markUserMoneyFresh(vTmpUserMap.Id)
+					if oldMoney, ok := userBaseMoney(vTmpUserMap.Id); !ok {
 						log.Println("新增用户，初始化成功保证金", vTmpUserMap, originTmp, tmp, vTmpUserMap.Num)
 						baseMoneyUserAllMap.Set(int(vTmpUserMap.Id), tmp)
 					} else {
-						if !IsEqual(tmp, baseMoneyUserAllMap.Get(int(vTmpUserMap.Id)).(float64)) {
+						if !tmp.Equal(oldMoney) {
 							log.Println("新增用户，变更成功", int(vTmpUserMap.Id), originTmp, tmp, vTmpUserMap.Num)
 							baseMoneyUserAllMap.Set(int(vTmpUserMap.Id), tmp)
 						}
@@ -408,21 +414,21 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 				if 0 < len(list) {
 					detail := list[0].TotalMarginBalance
 					if 0 < len(detail) {
-						var tmp float64
-						tmp, err = strconv.ParseFloat(detail, 64)
+						var originTmp decimal.Decimal
+						originTmp, err = decimal.NewFromString(detail)
 						if nil != err {
 							log.Println("新增用户，拉取保证金，转化失败，bybit：", err, vTmpUserMap)
 							continue
 						}
 
-						originTmp := tmp
-						tmp *= vTmpUserMap.Num
+						tmp := originTmp.Mul(decimal.NewFromFloat(vTmpUserMap.Num))
 						tmpUserBindTradersAmount = tmp
-						if !baseMoneyUserAllMap.Contains(int(vTmpUserMap.Id)) {
+						markUserMoneyFresh(vTmpUserMap.Id)
+						if oldMoney, ok := userBaseMoney(vTmpUserMap.Id); !ok {
 							log.Println("新增用户，初始化成功保证金", vTmpUserMap, originTmp, tmp, vTmpUserMap.Num)
 							baseMoneyUserAllMap.Set(int(vTmpUserMap.Id), tmp)
 						} else {
-							if !IsEqual(tmp, baseMoneyUserAllMap.Get(int(vTmpUserMap.Id)).(float64)) {
+							if !tmp.Equal(oldMoney) {
 								log.Println("新增用户，变更成功", int(vTmpUserMap.Id), originTmp, tmp, vTmpUserMap.Num)
 								baseMoneyUserAllMap.Set(int(vTmpUserMap.Id), tmp)
 							}
@@ -434,12 +440,13 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 				continue
 			}
 
-			if lessThanOrEqualZero(tmpUserBindTradersAmount, 0, 1e-7) {
+			if !tmpUserBindTradersAmount.IsPositive() {
 				log.Println("新增用户，保证金不足为0：", tmpUserBindTradersAmount, vTmpUserMap.Id)
 				continue
 			}
 
 			// 仓位
+			initPending := newRiskPending()
 			for _, vInsertData := range binancePositionMap {
 				// 一个新symbol通常3个开仓方向short，long，both，屏蔽一下未真实开仓的
 				tmpInsertData := vInsertData
@@ -447,6 +454,24 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 					continue
 				}
 
+				// 订阅到期
+				if subscriptionOpenBlocked(vTmpUserMap.Id) {
+					log.Println("新增用户，订阅已到期，禁止开仓：", tmpInsertData, vTmpUserMap)
+					continue
+				}
+
+				// 币种名单
+				if symbolOpenBlocked(vTmpUserMap.Id, tmpInsertData.Symbol) {
+					log.Println("新增用户，币种禁止开仓：", tmpInsertData, vTmpUserMap)
+					continue
+				}
+
+				// 闪烁隔离
+				if symbolSideQuarantined(ctx, tmpInsertData.Symbol, tmpInsertData.PositionSide) {
+					log.Println("新增用户，闪烁隔离，禁止开仓：", tmpInsertData, vTmpUserMap)
+					continue
+				}
+
 				if "binance" == vTmpUserMap.Plat {
 					if !symbolsMap.Contains(tmpInsertData.Symbol) {
 						log.Println("新增用户，代币信息无效，信息", tmpInsertData, vTmpUserMap)
@@ -454,12 +479,11 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 					}
 
 					var (
-						tmpQty        float64
-						quantity      string
-						quantityFloat float64
-						side          string
-						positionSide  string
-						orderType     = "MARKET"
+						tmpQty          decimal.Decimal
+						quantity        string
+						quantityDecimal decimal.Decimal
+						side            string
+						positionSide    string
 					)
 					if "LONG" == tmpInsertData.PositionSide {
 						positionSide = "LONG"
@@ -479,24 +503,21 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 						log.Println("新增用户，无效信息，信息", vInsertData)
 						continue
 					}
-					tmpPositionAmount := math.Abs(tmpInsertData.PositionAmount)
-					// 本次 代单员币的数量 * (用户保证金/代单员保证金)
-					tmpQty = tmpPositionAmount * tmpUserBindTradersAmount / tmpTraderBaseMoney // 本次开单数量
+					// 本次 按用户的下单数量方式，默认代单员币的数量 * (用户保证金/代单员保证金)
+					tmpQty = userSizingQty(vTmpUserMap.Id, tmpInsertData.Symbol, positionSide, decimal.NewFromFloat(tmpInsertData.PositionAmount), decimal.Zero, decimal.Zero, tmpUserBindTradersAmount, tmpTraderBaseMoney, decimal.NewFromInt(1)) // 本次开单数量
 
-					// 精度调整
-					if 0 >= symbolsMap.Get(tmpInsertData.Symbol).(*LhCoinSymbol).QuantityPrecision {
-						quantity = fmt.Sprintf("%d", int64(tmpQty))
-					} else {
-						quantity = strconv.FormatFloat(tmpQty, 'f', symbolsMap.Get(tmpInsertData.Symbol).(*LhCoinSymbol).QuantityPrecision, 64)
-					}
-
-					quantityFloat, err = strconv.ParseFloat(quantity, 64)
-					if nil != err {
-						log.Println(err)
+					// 风控
+					tmpQty = checkOpenRisk(ctx, vTmpUserMap, tmpInsertData.Symbol, positionSide, tmpQty, initPending)
+					if !tmpQty.IsPositive() {
 						continue
 					}
 
-					if lessThanOrEqualZero(quantityFloat, 0, 1e-7) {
+					// 精度调整，开仓向下取整
+					quantityDecimal = roundOpenQty("binance", tmpInsertData.Symbol, tmpQty)
+					quantity = formatQty("binance", tmpInsertData.Symbol, quantityDecimal)
+
+					// 不满足最小下单量或最小名义价值，暂存累加
+					if stageIfNotOrderable("binance", tmpInsertData.Symbol, tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, tmpQty, quantityDecimal) {
 						continue
 					}
 
@@ -505,8 +526,11 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 						binanceOrderRes *binanceOrder
 						orderInfoRes    *orderInfo
 					)
+					// 杠杆
+					ensureUserLeverage(orderCtx, vTmpUserMap, tmpInsertData.Symbol)
+
 					// 请求下单
-					binanceOrderRes, orderInfoRes, err = requestBinanceOrder(tmpInsertData.Symbol, side, orderType, positionSide, quantity, vTmpUserMap.ApiKey, vTmpUserMap.ApiSecret)
+					binanceOrderRes, orderInfoRes, err = placeBinanceOrder(vTmpUserMap, tmpInsertData.Symbol, side, positionSide, quantity, binanceClientOrderId(cycle, vTmpUserMap.Id, tmpInsertData.Symbol, positionSide, side))
 					if nil != err {
 						log.Println(err)
 					}
@@ -525,8 +549,8 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 					//	Status:        "",
 					//}
 
-					var tmpExecutedQty float64
-					tmpExecutedQty = quantityFloat
+					var tmpExecutedQty decimal.Decimal
+					tmpExecutedQty = quantityDecimal
 
 					// 下单异常
 					if 0 >= binanceOrderRes.OrderId {
@@ -546,29 +570,17 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 						continue
 					}
 
-					// 不存在新增，这里只能是开仓
-					if !orderMap.Contains(tmpInsertData.Symbol + "&" + positionSide + "&" + strUserId) {
-						orderMap.Set(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, tmpExecutedQty)
-					} else {
-
-						d1 := decimal.NewFromFloat(orderMap.Get(tmpInsertData.Symbol + "&" + positionSide + "&" + strUserId).(float64))
-						d2 := decimal.NewFromFloat(tmpExecutedQty)
-						result := d1.Add(d2)
-
-						var (
-							newRes float64
-							exact  bool
-						)
-						newRes, exact = result.Float64()
-						if !exact {
-							fmt.Println("转换过程中可能发生了精度损失", d1, d2, tmpExecutedQty, orderMap.Get(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId).(float64), newRes)
-						}
-
-						orderMap.Set(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, newRes)
+					// 拆单时只有部分下单成功
+					if binanceOrderRes.placedQty.IsPositive() {
+						tmpExecutedQty = binanceOrderRes.placedQty
 					}
 
+					// 不存在新增，这里只能是开仓
+					// 开仓，累加系统仓位
+					addOrderQty(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, tmpExecutedQty)
+
 					//  这里只能是，跟单人开仓，用户的预备仓位清空
-					orderMapTmp.Set(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, float64(0))
+					orderMapTmp.Set(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, decimal.Zero)
 
 					time.Sleep(50 * time.Millisecond)
 				} else if "bybit" == vTmpUserMap.Plat {
@@ -578,9 +590,9 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 					}
 
 					var (
-						tmpQty            float64
+						tmpQty            decimal.Decimal
 						quantity          string
-						quantityFloat     float64
+						quantityDecimal   decimal.Decimal
 						positionSide      string
 						sideOrder         string
 						positionSideOrder int
@@ -608,38 +620,37 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 						continue
 					}
 
-					tmpPositionAmount := math.Abs(tmpInsertData.PositionAmount)
-					// 本次 代单员币的数量 * (用户保证金/代单员保证金)
-					tmpQty = tmpPositionAmount * tmpUserBindTradersAmount / tmpTraderBaseMoney // 本次开单数量
-
-					// 精度调整
-					tmpQtyStep := symbolsBybitMap.Get(tmpInsertData.Symbol).(*BybitSymbol).QtyStep
-					tmpFloatQuantity := adjustToStepSize(tmpQty, tmpQtyStep)
+					// 本次 按用户的下单数量方式，默认代单员币的数量 * (用户保证金/代单员保证金)
+					tmpQty = userSizingQty(vTmpUserMap.Id, tmpInsertData.Symbol, positionSide, decimal.NewFromFloat(tmpInsertData.PositionAmount), decimal.Zero, decimal.Zero, tmpUserBindTradersAmount, tmpTraderBaseMoney, decimal.NewFromInt(1)) // 本次开单数量
 
-					quantity = getStringFromStepSizePrecision(tmpFloatQuantity, tmpQtyStep)
-					quantityFloat, err = strconv.ParseFloat(quantity, 64)
-					if nil != err {
-						log.Println(err)
+					// 风控
+					tmpQty = checkOpenRisk(ctx, vTmpUserMap, tmpInsertData.Symbol, positionSide, tmpQty, initPending)
+					if !tmpQty.IsPositive() {
 						continue
 					}
 
-					if lessThanOrEqualZero(quantityFloat, 0, 1e-7) {
+					// 精度调整，开仓向下取整
+					quantityDecimal = roundOpenQty("bybit", tmpInsertData.Symbol, tmpQty)
+					quantity = formatQty("bybit", tmpInsertData.Symbol, quantityDecimal)
+
+					// 不满足最小下单量或最小名义价值，暂存累加
+					if stageIfNotOrderable("bybit", tmpInsertData.Symbol, tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, tmpQty, quantityDecimal) {
 						continue
 					}
 
-					if !globalUsersOrderId.Contains(vTmpUserMap.Id) {
+					tmpOrderIdStr, ok := nextBybitOrderLinkId(vTmpUserMap.Id, cycle, orderSourceInit, sideOrder, positionSideOrder)
+					if !ok {
 						log.Println("新增用户，无效信息，不存在自增订单，信息", vInsertData, vTmpUserMap)
 						continue
 					}
 
-					tmpOrderId := globalUsersOrderId.Get(vTmpUserMap.Id).(uint64)
-					globalUsersOrderId.Set(vTmpUserMap.Id, tmpOrderId+1)
-					tmpOrderIdStr := strconv.FormatUint(uint64(vTmpUserMap.Id), 10) + "at" + strconv.FormatUint(uint64(time.Now().Unix()), 10) + "&" + strconv.FormatUint(tmpOrderId, 10)
-
 					var (
 						resOrder *BybitPlaceOrderResponse
 					)
-					resOrder, err = bybitPlaceOrder(ctx, vTmpUserMap.ApiKey, vTmpUserMap.ApiSecret, tmpInsertData.Symbol, quantity, sideOrder, positionSideOrder, tmpOrderIdStr)
+					// 杠杆
+					ensureUserLeverage(orderCtx, vTmpUserMap, tmpInsertData.Symbol)
+
+					resOrder, err = placeBybitOrder(orderCtx, vTmpUserMap, tmpInsertData.Symbol, quantity, sideOrder, positionSideOrder, tmpOrderIdStr)
 					if nil != err {
 						log.Println("bybit 初始化仓位下单错误", err, resOrder)
 					}
@@ -649,8 +660,8 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 						continue
 					}
 
-					var tmpExecutedQty float64
-					tmpExecutedQty = quantityFloat
+					var tmpExecutedQty decimal.Decimal
+					tmpExecutedQty = quantityDecimal
 					if 0 != resOrder.RetCode {
 						if 10010 == resOrder.RetCode {
 							log.Println("api无效，更新用户api_status：", vTmpUserMap)
@@ -673,29 +684,17 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 						continue
 					}
 
-					// 不存在新增，这里只能是开仓
-					if !orderMap.Contains(tmpInsertData.Symbol + "&" + positionSide + "&" + strUserId) {
-						orderMap.Set(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, tmpExecutedQty)
-					} else {
-
-						d1 := decimal.NewFromFloat(orderMap.Get(tmpInsertData.Symbol + "&" + positionSide + "&" + strUserId).(float64))
-						d2 := decimal.NewFromFloat(tmpExecutedQty)
-						result := d1.Add(d2)
-
-						var (
-							newRes float64
-							exact  bool
-						)
-						newRes, exact = result.Float64()
-						if !exact {
-							fmt.Println("转换过程中可能发生了精度损失", d1, d2, tmpExecutedQty, orderMap.Get(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId).(float64), newRes)
-						}
-
-						orderMap.Set(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, newRes)
+					// 拆单时只有部分下单成功
+					if resOrder.placedQty.IsPositive() {
+						tmpExecutedQty = resOrder.placedQty
 					}
 
+					// 不存在新增，这里只能是开仓
+					// 开仓，累加系统仓位
+					addOrderQty(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, tmpExecutedQty)
+
 					//  这里只能是，跟单人开仓，用户的预备仓位清空
-					orderMapTmp.Set(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, float64(0))
+					orderMapTmp.Set(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, decimal.Zero)
 
 					log.Println("初始化，现有仓位：", tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, orderMap.Get(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId))
 					time.Sleep(50 * time.Millisecond)
@@ -708,6 +707,10 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 
 		globalUsers.Set(vTmpUserMap.Id, vTmpUserMap)
 
+		// 用户数据流，跟踪成交和余额
+		startBinanceUserStream(ctx, vTmpUserMap)
+		startBybitUserStream(ctx, vTmpUserMap)
+
 		log.Println("新增用户:", vTmpUserMap)
 	}
 
@@ -725,6 +728,9 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 		log.Println("删除用户:", vTmpIds)
 		globalUsers.Remove(vTmpIds)
 		globalUsersOrderId.Remove(vTmpIds)
+		userMoneyRefreshedAt.Remove(int(vTmpIds))
+		stopBinanceUserStream(vTmpIds)
+		stopBybitUserStream(vTmpIds)
 
 		tmpRemoveUserKey := make([]string, 0)
 		// 遍历map
@@ -788,8 +794,22 @@ func (s *sBinanceTraderHistory) InsertGlobalUsers(ctx context.Context) {
 	}
 }
 
-// PullAndOrderNewGuiTu 拉取binance数据，仓位，根据cookie
+// PullAndOrderNewGuiTu 拉取binance数据，仓位，根据cookie。先登记任务再开协程同步，收到退出信号后结束
 func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
+	// 退出中，不再同步
+	if !s.beginTask(ctx) {
+		log.Println("同步订单，退出中，不再开始")
+		return
+	}
+
+	go func() {
+		defer s.running.Done()
+		s.pullAndOrder(ctx)
+	}()
+}
+
+// pullAndOrder 同步带单员仓位并跟单，直到收到退出信号
+func (s *sBinanceTraderHistory) pullAndOrder(ctx context.Context) {
 	var (
 		traderNum                 = globalTraderNum
 		zyTraderCookie            []*entity.ZyTraderCookie
@@ -800,11 +820,18 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 		err                       error
 	)
 
+	// 已发出的订单不随退出信号中断
+	orderCtx := context.WithoutCancel(ctx)
+
 	// 执行
 	for {
-		time.Sleep(50 * time.Millisecond) // 测试 28 最低值
-		//time.Sleep(5 * time.Second) // 测试 28 最低值
+		// 收到退出信号，不再接收新的带单员变更
+		if !sleepWithCtx(ctx, 50*time.Millisecond) { // 测试 28 最低值
+			log.Println("同步订单，退出")
+			return
+		}
 		start := time.Now()
+		cycle := orderCycle(start)
 
 		// 重新初始化数据
 		if 0 < len(binancePositionMap) {
@@ -819,12 +846,12 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 			err = g.Model("zy_trader_cookie").Ctx(ctx).Where("trader_id=? and is_open=?", 1, 1).
 				OrderDesc("update_time").Limit(1).Scan(&zyTraderCookie)
 			if nil != err {
-				time.Sleep(time.Second * 3)
+				sleepWithCtx(ctx, time.Second*3)
 				continue
 			}
 
 			if 0 >= len(zyTraderCookie) || 0 >= len(zyTraderCookie[0].Cookie) || 0 >= len(zyTraderCookie[0].Token) {
-				time.Sleep(time.Second * 3)
+				sleepWithCtx(ctx, time.Second*3)
 				continue
 			}
 
@@ -847,7 +874,10 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 			// 需要重试
 			if retry {
 				retryTimes++
-				time.Sleep(time.Second * 5)
+				if !sleepWithCtx(ctx, time.Second*5) {
+					log.Println("同步订单，重试中退出")
+					return
+				}
 				log.Println("重试：", retry)
 				continue
 			}
@@ -895,7 +925,13 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 		// 用于下单
 		orderInsertData := make([]*TraderPosition, 0)
 		orderUpdateData := make([]*TraderPosition, 0)
+		beginSnapshotPoll()
 		for _, vReqResData := range reqResData {
+			// 交易员杠杆
+			setTraderLeverage(vReqResData.Symbol, vReqResData.Leverage, vReqResData.Isolated)
+			// 带单员成交价，滑点保护使用
+			recordTraderPrice(vReqResData, start)
+
 			// 新增
 			var (
 				currentAmount    float64
@@ -957,6 +993,15 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 					}
 				}
 			} else {
+				// 可疑的变化，确认后再下单
+				tmpConfirmAmount := currentAmountAbs
+				if "BOTH" == vReqResData.PositionSide {
+					tmpConfirmAmount = currentAmount
+				}
+				if !confirmSnapshot(vReqResData.Symbol+vReqResData.PositionSide, binancePositionMap[vReqResData.Symbol+vReqResData.PositionSide].PositionAmount, tmpConfirmAmount, start) {
+					continue
+				}
+
 				// 数量无变化
 				if "BOTH" != vReqResData.PositionSide {
 					if IsEqual(currentAmountAbs, binancePositionMap[vReqResData.Symbol+vReqResData.PositionSide].PositionAmount) {
@@ -1071,6 +1116,26 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 			continue
 		}
 
+		// 退出中，本次变更不再处理
+		if nil != ctx.Err() {
+			log.Println("同步订单，退出，未处理的变更：", insertData, updateData)
+			return
+		}
+
+		// 带单员仓位变化，初始化仓位不算
+		historyChanges := make([]*do.TraderPositionHistory, 0)
+		for _, vIBinancePosition := range insertData {
+			if 0 < len(binancePositionMapCompare) {
+				historyChanges = append(historyChanges, traderPositionChanges(vIBinancePosition.Symbol, vIBinancePosition.PositionSide, 0, vIBinancePosition.PositionAmount, start)...)
+			}
+		}
+		for _, vUBinancePosition := range updateData {
+			if tmp, ok := binancePositionMap[vUBinancePosition.Symbol+vUBinancePosition.PositionSide]; ok {
+				historyChanges = append(historyChanges, traderPositionChanges(vUBinancePosition.Symbol, vUBinancePosition.PositionSide, tmp.PositionAmount, vUBinancePosition.PositionAmount, start)...)
+			}
+		}
+		recordTraderPositionHistory(orderCtx, historyChanges)
+
 		// 新增数据
 		for _, vIBinancePosition := range insertData {
 			binancePositionMap[vIBinancePosition.Symbol+vIBinancePosition.PositionSide] = &TraderPosition{
@@ -1088,6 +1153,7 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 				PositionAmount: vUBinancePosition.PositionAmount,
 			}
 		}
+		publishTraderPositions(binancePositionMap)
 
 		// 推送订单，数据库已初始化仓位，新仓库
 		if 0 >= len(binancePositionMapCompare) {
@@ -1112,56 +1178,38 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 			lastPositionData := binancePositionMapCompare[vUpdateData.Symbol+vUpdateData.PositionSide]
 
 			// 判断是否闪烁
-			if lessThanOrEqualZero(tmpUpdateData.PositionAmount, 0, 1e-7) {
+			if lessThanOrEqual(tmpUpdateData.PositionAmount, 0) {
 				log.Println("判断闪烁，完全平仓：", tmpUpdateData)
 
 				if locKOrder.Contains(tmpUpdateData.Symbol + "&" + tmpUpdateData.PositionSide) {
 					lastOrderT := locKOrder.Get(tmpUpdateData.Symbol + "&" + tmpUpdateData.PositionSide).(int64)
-					if (tmpNow - 30) < lastOrderT {
+					if (tmpNow - flickerWindow()) < lastOrderT {
 						fmt.Println("可能抖动", tmpUpdateData, lastOrderT, tmpNow)
-						// 修改
-						_, err = g.Model("user").Ctx(ctx).Data("open_status", 4).Where("id>=?", 1).Update()
-						if nil != err {
-							log.Println("可能抖动，暂停：", err)
-						}
-
-						var (
-							users []*entity.User
-						)
-						err = g.Model("user").Ctx(ctx).
-							Where("api_status=?", 1).
-							Scan(&users)
-						if nil != err {
-							log.Println("新增用户，数据库查询错误：", err)
-						}
-
-						for _, vUsers := range users {
-							if globalUsers.Contains(vUsers.Id) {
-								globalUsers.Set(vUsers.Id, vUsers)
-							}
-						}
+						// 只隔离该币种方向
+						recordFlicker(ctx, tmpUpdateData.Symbol, tmpUpdateData.PositionSide, lastOrderT, tmpNow)
 					}
 				}
-			} else if lessThanOrEqualZero(lastPositionData.PositionAmount, tmpUpdateData.PositionAmount, 1e-7) {
+			} else if lessThanOrEqual(lastPositionData.PositionAmount, tmpUpdateData.PositionAmount) {
 				// 追加仓位时候记录时间
 				locKOrder.Set(tmpUpdateData.Symbol+"&"+tmpUpdateData.PositionSide, tmpNow)
 			}
 		}
+		resumeExpiredQuarantines(ctx)
 
 		wg := sync.WaitGroup{}
 		// 遍历跟单者
-		tmpTraderBaseMoney := baseMoneyGuiTu.Val()
+		tmpTraderBaseMoney := traderBaseMoney()
 		globalUsers.Iterator(func(k interface{}, v interface{}) bool {
 			tmpUser := v.(*entity.User)
+			pending := newRiskPending()
 
-			var tmpUserBindTradersAmount float64
-			if !baseMoneyUserAllMap.Contains(int(tmpUser.Id)) {
+			tmpUserBindTradersAmount, ok := userBaseMoney(tmpUser.Id)
+			if !ok {
 				log.Println("保证金不存在：", tmpUser)
 				return true
 			}
-			tmpUserBindTradersAmount = baseMoneyUserAllMap.Get(int(tmpUser.Id)).(float64)
 
-			if lessThanOrEqualZero(tmpUserBindTradersAmount, 0, 1e-7) {
+			if !tmpUserBindTradersAmount.IsPositive() {
 				log.Println("保证金不足为0：", tmpUserBindTradersAmount, tmpUser)
 				return true
 			}
@@ -1172,7 +1220,7 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 				return true
 			}
 
-			if lessThanOrEqualZero(tmpTraderBaseMoney, 0, 1e-7) {
+			if !tmpTraderBaseMoney.IsPositive() {
 				log.Println("交易员信息无效了，信息", tmpUser)
 				return true
 			}
@@ -1187,7 +1235,32 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 
 				// 一个新symbol通常3个开仓方向short，long，both，屏蔽一下未真实开仓的
 				tmpInsertData := vInsertData
-				if lessThanOrEqualZero(tmpInsertData.PositionAmount, 0, 1e-7) {
+				if lessThanOrEqual(tmpInsertData.PositionAmount, 0) {
+					continue
+				}
+
+				// 订阅到期
+				if subscriptionOpenBlocked(tmpUser.Id) {
+					log.Println("订阅已到期，禁止开仓:", tmpUser, tmpInsertData)
+					continue
+				}
+
+				// 币种名单
+				if symbolOpenBlocked(tmpUser.Id, tmpInsertData.Symbol) {
+					log.Println("币种禁止开仓:", tmpUser, tmpInsertData)
+					continue
+				}
+
+				// 闪烁隔离
+				if symbolSideQuarantined(ctx, tmpInsertData.Symbol, tmpInsertData.PositionSide) {
+					log.Println("闪烁隔离，禁止开仓:", tmpUser, tmpInsertData)
+					continue
+				}
+
+				// 滑点保护，价格偏离带单员成交价太多时减少或跳过开仓
+				slippage := slippageFactor(tmpInsertData.Symbol, tmpInsertData.PositionSide)
+				if !slippage.IsPositive() {
+					log.Println("滑点保护，禁止开仓:", tmpUser, tmpInsertData)
 					continue
 				}
 
@@ -1198,12 +1271,12 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 					}
 
 					var (
-						tmpQty        float64
-						quantity      string
-						quantityFloat float64
-						side          string
-						positionSide  string
-						orderType     = "MARKET"
+						tmpQty          decimal.Decimal
+						quantity        string
+						quantityDecimal decimal.Decimal
+						side            string
+						positionSide    string
+						orderType       = "MARKET"
 					)
 					if "LONG" == tmpInsertData.PositionSide {
 						positionSide = "LONG"
@@ -1216,36 +1289,34 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 						continue
 					}
 
-					// 本次 代单员币的数量 * (用户保证金/代单员保证金)
-					tmpQty = tmpInsertData.PositionAmount * tmpUserBindTradersAmount / tmpTraderBaseMoney // 本次开单数量
+					// 本次 按用户的下单数量方式，默认代单员币的数量 * (用户保证金/代单员保证金)
+					tmpQty = userSizingQty(tmpUser.Id, tmpInsertData.Symbol, positionSide, decimal.NewFromFloat(tmpInsertData.PositionAmount), decimal.Zero, decimal.Zero, tmpUserBindTradersAmount, tmpTraderBaseMoney, slippage) // 本次开单数量
 					// 累加预备仓位
 					if orderMapTmp.Contains(tmpInsertData.Symbol + "&" + positionSide + "&" + strUserId) {
-						tmpOldQty := orderMapTmp.Get(tmpInsertData.Symbol + "&" + positionSide + "&" + strUserId).(float64)
-						if !lessThanOrEqualZero(tmpOldQty, 0, 1e-7) {
-							tmpQty += tmpOldQty
+						tmpOldQty := orderTmpQty(tmpInsertData.Symbol + "&" + positionSide + "&" + strUserId)
+						if tmpOldQty.IsPositive() {
+							tmpQty = tmpQty.Add(tmpOldQty)
 							log.Println("新增，暂存的累加开仓：", tmpQty, tmpOldQty, tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId)
 						}
 					}
 
-					// 精度调整
-					if 0 >= symbolsMap.Get(tmpInsertData.Symbol).(*LhCoinSymbol).QuantityPrecision {
-						quantity = fmt.Sprintf("%d", int64(tmpQty))
-					} else {
-						quantity = strconv.FormatFloat(tmpQty, 'f', symbolsMap.Get(tmpInsertData.Symbol).(*LhCoinSymbol).QuantityPrecision, 64)
-					}
-
-					quantityFloat, err = strconv.ParseFloat(quantity, 64)
-					if nil != err {
-						log.Println(err)
+					// 风控
+					tmpQty = checkOpenRisk(ctx, tmpUser, tmpInsertData.Symbol, positionSide, tmpQty, pending)
+					if !tmpQty.IsPositive() {
 						continue
 					}
 
-					if lessThanOrEqualZero(quantityFloat, 0, 1e-7) {
+					// 精度调整，开仓向下取整
+					quantityDecimal = roundOpenQty("binance", tmpInsertData.Symbol, tmpQty)
+					quantity = formatQty("binance", tmpInsertData.Symbol, quantityDecimal)
+
+					// 不满足最小下单量或最小名义价值，暂存累加
+					if stageIfNotOrderable("binance", tmpInsertData.Symbol, tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, tmpQty, quantityDecimal) {
 						continue
 					}
 
 					wg.Add(1)
-					err = s.pool.Add(ctx, func(ctx context.Context) {
+					err = s.pool.Add(orderCtx, func(ctx context.Context) {
 						defer wg.Done()
 
 						// 下单，不用计算数量，新仓位
@@ -1253,8 +1324,11 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 							binanceOrderRes *binanceOrder
 							orderInfoRes    *orderInfo
 						)
+						// 杠杆
+						ensureUserLeverage(ctx, tmpUser, tmpInsertData.Symbol)
+
 						// 请求下单
-						binanceOrderRes, orderInfoRes, err = requestBinanceOrder(tmpInsertData.Symbol, side, orderType, positionSide, quantity, tmpUser.ApiKey, tmpUser.ApiSecret)
+						binanceOrderRes, orderInfoRes, err = placeBinanceOrder(tmpUser, tmpInsertData.Symbol, side, positionSide, quantity, binanceClientOrderId(cycle, tmpUser.Id, tmpInsertData.Symbol, positionSide, side))
 						if nil != err {
 							log.Println("执行下单错误，新增，错误", err, tmpInsertData.Symbol, side, orderType, positionSide, quantity, tmpUser.ApiKey, tmpUser.ApiSecret, orderInfoRes)
 						}
@@ -1272,8 +1346,8 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 						//	Type:          "",
 						//	Status:        "",
 						//}
-						var tmpExecutedQty float64
-						tmpExecutedQty = quantityFloat
+						var tmpExecutedQty decimal.Decimal
+						tmpExecutedQty = quantityDecimal
 
 						// 下单异常
 						if 0 >= binanceOrderRes.OrderId {
@@ -1297,28 +1371,17 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 							return
 						}
 
-						// 不存在新增，这里只能是开仓
-						if !orderMap.Contains(tmpInsertData.Symbol + "&" + positionSide + "&" + strUserId) {
-							orderMap.Set(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, tmpExecutedQty)
-						} else {
-							d1 := decimal.NewFromFloat(orderMap.Get(tmpInsertData.Symbol + "&" + positionSide + "&" + strUserId).(float64))
-							d2 := decimal.NewFromFloat(tmpExecutedQty)
-							result := d1.Add(d2)
-
-							var (
-								newRes float64
-								exact  bool
-							)
-							newRes, exact = result.Float64()
-							if !exact {
-								fmt.Println("转换过程中可能发生了精度损失", d1, d2, tmpExecutedQty, orderMap.Get(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId).(float64), newRes)
-							}
-
-							orderMap.Set(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, newRes)
+						// 拆单时只有部分下单成功
+						if binanceOrderRes.placedQty.IsPositive() {
+							tmpExecutedQty = binanceOrderRes.placedQty
 						}
 
+						// 不存在新增，这里只能是开仓
+						// 开仓，累加系统仓位
+						addOrderQty(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, tmpExecutedQty)
+
 						//  这里只能是，跟单人开仓，用户的预备仓位清空
-						orderMapTmp.Set(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, float64(0))
+						orderMapTmp.Set(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, decimal.Zero)
 						log.Println("现有仓位：", tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, orderMap.Get(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId))
 						return
 					})
@@ -1332,9 +1395,9 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 					}
 
 					var (
-						tmpQty            float64
+						tmpQty            decimal.Decimal
 						quantity          string
-						quantityFloat     float64
+						quantityDecimal   decimal.Decimal
 						positionSide      string
 						sideOrder         string
 						positionSideOrder int
@@ -1366,50 +1429,49 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 						continue
 					}
 
-					tmpPositionAmount := math.Abs(tmpInsertData.PositionAmount)
-					// 本次 代单员币的数量 * (用户保证金/代单员保证金)
-					tmpQty = tmpPositionAmount * tmpUserBindTradersAmount / tmpTraderBaseMoney // 本次开单数量
+					// 本次 按用户的下单数量方式，默认代单员币的数量 * (用户保证金/代单员保证金)
+					tmpQty = userSizingQty(tmpUser.Id, tmpInsertData.Symbol, positionSide, decimal.NewFromFloat(tmpInsertData.PositionAmount), decimal.Zero, decimal.Zero, tmpUserBindTradersAmount, tmpTraderBaseMoney, slippage) // 本次开单数量
 					// 累加预备仓位
 					if orderMapTmp.Contains(tmpInsertData.Symbol + "&" + positionSide + "&" + strUserId) {
-						tmpOldQty := orderMapTmp.Get(tmpInsertData.Symbol + "&" + positionSide + "&" + strUserId).(float64)
-						if !lessThanOrEqualZero(tmpOldQty, 0, 1e-7) {
-							tmpQty += tmpOldQty
+						tmpOldQty := orderTmpQty(tmpInsertData.Symbol + "&" + positionSide + "&" + strUserId)
+						if tmpOldQty.IsPositive() {
+							tmpQty = tmpQty.Add(tmpOldQty)
 							log.Println("新增，暂存的累加开仓：", tmpQty, tmpOldQty, tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId)
 						}
 					}
 
-					// 精度调整
-					tmpQtyStep := symbolsBybitMap.Get(tmpInsertData.Symbol).(*BybitSymbol).QtyStep
-					tmpFloatQuantity := adjustToStepSize(tmpQty, tmpQtyStep)
-
-					quantity = getStringFromStepSizePrecision(tmpFloatQuantity, tmpQtyStep)
-					quantityFloat, err = strconv.ParseFloat(quantity, 64)
-					if nil != err {
-						log.Println(err)
+					// 风控
+					tmpQty = checkOpenRisk(ctx, tmpUser, tmpInsertData.Symbol, positionSide, tmpQty, pending)
+					if !tmpQty.IsPositive() {
 						continue
 					}
 
-					if lessThanOrEqualZero(quantityFloat, 0, 1e-7) {
+					// 精度调整，开仓向下取整
+					quantityDecimal = roundOpenQty("bybit", tmpInsertData.Symbol, tmpQty)
+					quantity = formatQty("bybit", tmpInsertData.Symbol, quantityDecimal)
+
+					// 不满足最小下单量或最小名义价值，暂存累加
+					if stageIfNotOrderable("bybit", tmpInsertData.Symbol, tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, tmpQty, quantityDecimal) {
 						continue
 					}
 
-					if !globalUsersOrderId.Contains(tmpUser.Id) {
+					tmpOrderIdStr, ok := nextBybitOrderLinkId(tmpUser.Id, cycle, orderSourceNew, sideOrder, positionSideOrder)
+					if !ok {
 						log.Println("新增，无效信息，不存在自增订单，信息", vInsertData, tmpUser)
 						continue
 					}
 
-					tmpOrderId := globalUsersOrderId.Get(tmpUser.Id).(uint64)
-					globalUsersOrderId.Set(tmpUser.Id, tmpOrderId+1)
-					tmpOrderIdStr := strconv.FormatUint(uint64(tmpUser.Id), 10) + "at" + strconv.FormatUint(uint64(time.Now().Unix()), 10) + "&" + strconv.FormatUint(tmpOrderId, 10)
-
 					wg.Add(1)
-					err = s.pool.Add(ctx, func(ctx context.Context) {
+					err = s.pool.Add(orderCtx, func(ctx context.Context) {
 						defer wg.Done()
 
 						var (
 							resOrder *BybitPlaceOrderResponse
 						)
-						resOrder, err = bybitPlaceOrder(ctx, tmpUser.ApiKey, tmpUser.ApiSecret, tmpInsertData.Symbol, quantity, sideOrder, positionSideOrder, tmpOrderIdStr)
+						// 杠杆
+						ensureUserLeverage(ctx, tmpUser, tmpInsertData.Symbol)
+
+						resOrder, err = placeBybitOrder(ctx, tmpUser, tmpInsertData.Symbol, quantity, sideOrder, positionSideOrder, tmpOrderIdStr)
 						if nil != err {
 							log.Println("bybit 仓位下单错误", err, resOrder)
 						}
@@ -1419,8 +1481,8 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 							return
 						}
 
-						var tmpExecutedQty float64
-						tmpExecutedQty = quantityFloat
+						var tmpExecutedQty decimal.Decimal
+						tmpExecutedQty = quantityDecimal
 						if 0 != resOrder.RetCode {
 							if 10010 == resOrder.RetCode {
 								log.Println("api无效，更新用户api_status：", tmpUser)
@@ -1443,28 +1505,17 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 							return
 						}
 
-						// 不存在新增，这里只能是开仓
-						if !orderMap.Contains(tmpInsertData.Symbol + "&" + positionSide + "&" + strUserId) {
-							orderMap.Set(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, tmpExecutedQty)
-						} else {
-							d1 := decimal.NewFromFloat(orderMap.Get(tmpInsertData.Symbol + "&" + positionSide + "&" + strUserId).(float64))
-							d2 := decimal.NewFromFloat(tmpExecutedQty)
-							result := d1.Add(d2)
-
-							var (
-								newRes float64
-								exact  bool
-							)
-							newRes, exact = result.Float64()
-							if !exact {
-								fmt.Println("转换过程中可能发生了精度损失", d1, d2, tmpExecutedQty, orderMap.Get(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId).(float64), newRes)
-							}
-
-							orderMap.Set(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, newRes)
+						// 拆单时只有部分下单成功
+						if resOrder.placedQty.IsPositive() {
+							tmpExecutedQty = resOrder.placedQty
 						}
 
+						// 不存在新增，这里只能是开仓
+						// 开仓，累加系统仓位
+						addOrderQty(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, tmpExecutedQty)
+
 						//  这里只能是，跟单人开仓，用户的预备仓位清空
-						orderMapTmp.Set(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, float64(0))
+						orderMapTmp.Set(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, decimal.Zero)
 						log.Println("现有仓位：", tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId, orderMap.Get(tmpInsertData.Symbol+"&"+positionSide+"&"+strUserId))
 						return
 					})
@@ -1499,9 +1550,9 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 				}
 
 				var (
-					tmpQty            float64
+					tmpQty            decimal.Decimal
 					quantity          string
-					quantityFloat     float64
+					quantityDecimal   decimal.Decimal
 					side              string
 					positionSide      string
 					orderType         = "MARKET"
@@ -1509,7 +1560,7 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 					positionSideOrder int
 				)
 
-				if lessThanOrEqualZero(tmpUpdateData.PositionAmount, 0, 1e-7) {
+				if lessThanOrEqual(tmpUpdateData.PositionAmount, 0) {
 					log.Println("完全平仓：", tmpUpdateData)
 					// 全平仓
 					if "LONG" == tmpUpdateData.PositionSide {
@@ -1531,12 +1582,12 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 
 					// 带单人完全平仓，用户可能没开起来过，所以也要把系统数据清空，用户的预备仓位清空
 					if orderMapTmp.Contains(tmpUpdateData.Symbol + "&" + positionSide + "&" + strUserId) {
-						tmpOldQty := orderMapTmp.Get(tmpUpdateData.Symbol + "&" + positionSide + "&" + strUserId).(float64)
-						if !lessThanOrEqualZero(tmpOldQty, 0, 1e-7) {
+						tmpOldQty := orderTmpQty(tmpUpdateData.Symbol + "&" + positionSide + "&" + strUserId)
+						if tmpOldQty.IsPositive() {
 							log.Println("变更，完全平仓，清空暂存：", tmpOldQty, tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId)
 						}
 					}
-					orderMapTmp.Set(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, float64(0))
+					orderMapTmp.Set(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, decimal.Zero)
 
 					// 未开启过仓位
 					if !orderMap.Contains(tmpUpdateData.Symbol + "&" + tmpUpdateData.PositionSide + "&" + strUserId) {
@@ -1544,19 +1595,44 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 					}
 
 					// 认为是0
-					if lessThanOrEqualZero(orderMap.Get(tmpUpdateData.Symbol+"&"+tmpUpdateData.PositionSide+"&"+strUserId).(float64), 0, 1e-7) {
+					if !orderQty(tmpUpdateData.Symbol + "&" + tmpUpdateData.PositionSide + "&" + strUserId).IsPositive() {
 						continue
 					}
 
 					// 剩余仓位
-					tmpQty = orderMap.Get(tmpUpdateData.Symbol + "&" + tmpUpdateData.PositionSide + "&" + strUserId).(float64)
-				} else if lessThanOrEqualZero(lastPositionData.PositionAmount, tmpUpdateData.PositionAmount, 1e-7) {
+					tmpQty = orderQty(tmpUpdateData.Symbol + "&" + tmpUpdateData.PositionSide + "&" + strUserId)
+				} else if lessThanOrEqual(lastPositionData.PositionAmount, tmpUpdateData.PositionAmount) {
 					if 2 != tmpUser.OpenStatus {
 						log.Println("变更，暂停用户:", tmpUser, tmpUpdateData, lastPositionData)
 						// 暂停开新仓
 						continue
 					}
 
+					// 订阅到期
+					if subscriptionOpenBlocked(tmpUser.Id) {
+						log.Println("变更，订阅已到期，禁止加仓:", tmpUser, tmpUpdateData, lastPositionData)
+						continue
+					}
+
+					// 币种名单
+					if symbolOpenBlocked(tmpUser.Id, tmpUpdateData.Symbol) {
+						log.Println("变更，币种禁止加仓:", tmpUser, tmpUpdateData, lastPositionData)
+						continue
+					}
+
+					// 闪烁隔离
+					if symbolSideQuarantined(ctx, tmpUpdateData.Symbol, tmpUpdateData.PositionSide) {
+						log.Println("变更，闪烁隔离，禁止加仓:", tmpUser, tmpUpdateData, lastPositionData)
+						continue
+					}
+
+					// 滑点保护，价格偏离带单员成交价太多时减少或跳过加仓
+					slippage := slippageFactor(tmpUpdateData.Symbol, tmpUpdateData.PositionSide)
+					if !slippage.IsPositive() {
+						log.Println("变更，滑点保护，禁止加仓:", tmpUser, tmpUpdateData, lastPositionData)
+						continue
+					}
+
 					log.Println("追加仓位：", tmpUpdateData, lastPositionData)
 					// 本次加仓 代单员币的数量 * (用户保证金/代单员保证金)
 					if "LONG" == tmpUpdateData.PositionSide {
@@ -1576,18 +1652,34 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 						continue
 					}
 
-					// 本次减去上一次
-					tmpQty = (tmpUpdateData.PositionAmount - lastPositionData.PositionAmount) * tmpUserBindTradersAmount / tmpTraderBaseMoney // 本次开单数量
+					// 本次减去上一次，按用户的下单数量方式
+					tmpQty = userSizingQty(
+						tmpUser.Id,
+						tmpUpdateData.Symbol,
+						positionSide,
+						decimal.NewFromFloat(tmpUpdateData.PositionAmount).Sub(decimal.NewFromFloat(lastPositionData.PositionAmount)),
+						decimal.NewFromFloat(lastPositionData.PositionAmount),
+						orderQty(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId),
+						tmpUserBindTradersAmount,
+						tmpTraderBaseMoney,
+						slippage,
+					) // 本次开单数量
 
 					// 累加预备仓位
 					if orderMapTmp.Contains(tmpUpdateData.Symbol + "&" + positionSide + "&" + strUserId) {
-						tmpOldQty := orderMapTmp.Get(tmpUpdateData.Symbol + "&" + positionSide + "&" + strUserId).(float64)
-						if !lessThanOrEqualZero(tmpOldQty, 0, 1e-7) {
-							tmpQty += tmpOldQty
+						tmpOldQty := orderTmpQty(tmpUpdateData.Symbol + "&" + positionSide + "&" + strUserId)
+						if tmpOldQty.IsPositive() {
+							tmpQty = tmpQty.Add(tmpOldQty)
 							log.Println("变更，暂存的累加开仓：", tmpQty, tmpOldQty, tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId)
 						}
 					}
-				} else if lessThanOrEqualZero(tmpUpdateData.PositionAmount, lastPositionData.PositionAmount, 1e-7) {
+
+					// 风控
+					tmpQty = checkOpenRisk(ctx, tmpUser, tmpUpdateData.Symbol, positionSide, tmpQty, pending)
+					if !tmpQty.IsPositive() {
+						continue
+					}
+				} else if lessThanOrEqual(tmpUpdateData.PositionAmount, lastPositionData.PositionAmount) {
 					log.Println("部分平仓：", tmpUpdateData, lastPositionData)
 					// 部分平仓
 					if "LONG" == tmpUpdateData.PositionSide {
@@ -1613,66 +1705,67 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 					}
 
 					// 认为是0
-					if lessThanOrEqualZero(orderMap.Get(tmpUpdateData.Symbol+"&"+tmpUpdateData.PositionSide+"&"+strUserId).(float64), 0, 1e-7) {
+					if !orderQty(tmpUpdateData.Symbol + "&" + tmpUpdateData.PositionSide + "&" + strUserId).IsPositive() {
 						continue
 					}
 
 					// 上次仓位
-					if lessThanOrEqualZero(lastPositionData.PositionAmount, 0, 1e-7) {
+					if lessThanOrEqual(lastPositionData.PositionAmount, 0) {
 						log.Println("部分平仓，上次仓位信息无效，信息", lastPositionData, tmpUpdateData)
 						continue
 					}
 
 					// 按百分比
-					tmpQty = orderMap.Get(tmpUpdateData.Symbol+"&"+tmpUpdateData.PositionSide+"&"+strUserId).(float64) * (lastPositionData.PositionAmount - tmpUpdateData.PositionAmount) / lastPositionData.PositionAmount
+					tmpQty = orderQty(tmpUpdateData.Symbol + "&" + tmpUpdateData.PositionSide + "&" + strUserId).Mul(decimal.NewFromFloat(lastPositionData.PositionAmount).Sub(decimal.NewFromFloat(tmpUpdateData.PositionAmount))).Div(decimal.NewFromFloat(lastPositionData.PositionAmount))
 				} else {
 					log.Println("分析仓位无效，信息", lastPositionData, tmpUpdateData)
 					continue
 				}
 
+				// 开仓或平仓
+				isOpen := ("LONG" == positionSide && "BUY" == side) || ("SHORT" == positionSide && "SELL" == side)
+
 				var tmpOrderIdStr string
 				if "binance" == tmpUser.Plat {
-					// 精度调整
-					if 0 >= symbolsMap.Get(tmpUpdateData.Symbol).(*LhCoinSymbol).QuantityPrecision {
-						quantity = fmt.Sprintf("%d", int64(tmpQty))
+					// 精度调整，开仓向下取整，平仓不超过持有数量
+					if isOpen {
+						quantityDecimal = roundOpenQty("binance", tmpUpdateData.Symbol, tmpQty)
 					} else {
-						quantity = strconv.FormatFloat(tmpQty, 'f', symbolsMap.Get(tmpUpdateData.Symbol).(*LhCoinSymbol).QuantityPrecision, 64)
-					}
-
-					quantityFloat, err = strconv.ParseFloat(quantity, 64)
-					if nil != err {
-						log.Println(err)
-						continue
+						quantityDecimal = roundCloseQty("binance", tmpUpdateData.Symbol, tmpQty, orderQty(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId))
 					}
+					quantity = formatQty("binance", tmpUpdateData.Symbol, quantityDecimal)
 
-					if lessThanOrEqualZero(quantityFloat, 0, 1e-7) {
+					// 开仓不满足最小下单量或最小名义价值，暂存累加
+					if isOpen {
+						if stageIfNotOrderable("binance", tmpUpdateData.Symbol, tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, tmpQty, quantityDecimal) {
+							continue
+						}
+					} else if !quantityDecimal.IsPositive() {
 						continue
 					}
 				} else if "bybit" == tmpUser.Plat {
-					// 精度调整
-					tmpQtyStep := symbolsBybitMap.Get(tmpUpdateData.Symbol).(*BybitSymbol).QtyStep
-					tmpFloatQuantity := adjustToStepSize(tmpQty, tmpQtyStep)
-
-					quantity = getStringFromStepSizePrecision(tmpFloatQuantity, tmpQtyStep)
-					quantityFloat, err = strconv.ParseFloat(quantity, 64)
-					if nil != err {
-						log.Println(err)
-						continue
+					// 精度调整，开仓向下取整，平仓不超过持有数量
+					if isOpen {
+						quantityDecimal = roundOpenQty("bybit", tmpUpdateData.Symbol, tmpQty)
+					} else {
+						quantityDecimal = roundCloseQty("bybit", tmpUpdateData.Symbol, tmpQty, orderQty(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId))
 					}
-
-					//log.Println(tmpQtyStep, tmpQty, tmpFloatQuantity, quantity, quantityFloat)
-					if lessThanOrEqualZero(quantityFloat, 0, 1e-7) {
+					quantity = formatQty("bybit", tmpUpdateData.Symbol, quantityDecimal)
+					// 开仓不满足最小下单量或最小名义价值，暂存累加
+					if isOpen {
+						if stageIfNotOrderable("bybit", tmpUpdateData.Symbol, tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, tmpQty, quantityDecimal) {
+							continue
+						}
+					} else if !quantityDecimal.IsPositive() {
 						continue
 					}
 
-					if !globalUsersOrderId.Contains(tmpUser.Id) {
+					tmpOrderLinkId, ok := nextBybitOrderLinkId(tmpUser.Id, cycle, orderSourceUpdate, sideOrder, positionSideOrder)
+					if !ok {
 						log.Println("更新，无效信息，不存在自增订单，信息", tmpUpdateData, tmpUser)
 						continue
 					}
-
-					tmpOrderId := globalUsersOrderId.Get(tmpUser.Id).(uint64)
-					globalUsersOrderId.Set(tmpUser.Id, tmpOrderId+1)
-					tmpOrderIdStr = strconv.FormatUint(uint64(tmpUser.Id), 10) + "at" + strconv.FormatUint(uint64(time.Now().Unix()), 10) + "&" + strconv.FormatUint(tmpOrderId, 10)
+					tmpOrderIdStr = tmpOrderLinkId
 
 				} else {
 					log.Println("无效信息，信息", tmpUpdateData)
@@ -1680,9 +1773,14 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 				}
 
 				wg.Add(1)
-				err = s.pool.Add(ctx, func(ctx context.Context) {
+				err = s.pool.Add(orderCtx, func(ctx context.Context) {
 					defer wg.Done()
-					var tmpExecutedQty float64
+					var tmpExecutedQty decimal.Decimal
+
+					// 加仓，杠杆
+					if isOpen {
+						ensureUserLeverage(ctx, tmpUser, tmpUpdateData.Symbol)
+					}
 
 					if "binance" == tmpUser.Plat {
 						// 下单，不用计算数量，新仓位
@@ -1691,7 +1789,7 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 							orderInfoRes    *orderInfo
 						)
 						// 请求下单
-						binanceOrderRes, orderInfoRes, err = requestBinanceOrder(tmpUpdateData.Symbol, side, orderType, positionSide, quantity, tmpUser.ApiKey, tmpUser.ApiSecret)
+						binanceOrderRes, orderInfoRes, err = placeBinanceOrder(tmpUser, tmpUpdateData.Symbol, side, positionSide, quantity, binanceClientOrderId(cycle, tmpUser.Id, tmpUpdateData.Symbol, positionSide, side))
 						if nil != err {
 							log.Println("执行下单错误，变更，错误：", err, tmpUpdateData.Symbol, side, orderType, positionSide, quantity, tmpUser.ApiKey, tmpUser.ApiSecret)
 							return
@@ -1710,7 +1808,7 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 						//	Type:          "",
 						//	Status:        "",
 						//}
-						tmpExecutedQty = quantityFloat
+						tmpExecutedQty = quantityDecimal
 
 						// 下单异常
 						if 0 >= binanceOrderRes.OrderId {
@@ -1720,6 +1818,10 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 									orderMapTmp.Set(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, tmpExecutedQty)
 									log.Println("变更，暂存：", tmpUser, tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, tmpExecutedQty, orderInfoRes)
 								}
+							} else if !isOpen && binanceReduceRejectedCodes[orderInfoRes.Code] {
+								// 平仓超过实际持仓，按实际持仓平仓并校正系统仓位
+								reduceToLivePosition(ctx, tmpUser, tmpUpdateData.Symbol, positionSide, cycle, orderSourceUpdate, tmpExecutedQty, strUserId)
+								return
 							} else if -2015 == orderInfoRes.Code {
 								log.Println("api无效，更新用户api_status：", tmpUser)
 								_, err = g.Model("user").Ctx(ctx).Data(g.Map{"api_status": 3}).Where("id=?", tmpUser.Id).Update()
@@ -1732,11 +1834,16 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 							return
 						}
 
+						// 拆单时只有部分下单成功
+						if binanceOrderRes.placedQty.IsPositive() {
+							tmpExecutedQty = binanceOrderRes.placedQty
+						}
+
 					} else if "bybit" == tmpUser.Plat {
 						var (
 							resOrder *BybitPlaceOrderResponse
 						)
-						resOrder, err = bybitPlaceOrder(ctx, tmpUser.ApiKey, tmpUser.ApiSecret, tmpUpdateData.Symbol, quantity, sideOrder, positionSideOrder, tmpOrderIdStr)
+						resOrder, err = placeBybitOrder(ctx, tmpUser, tmpUpdateData.Symbol, quantity, sideOrder, positionSideOrder, tmpOrderIdStr)
 						if nil != err {
 							log.Println("bybit 仓位下单错误", err, resOrder)
 						}
@@ -1746,7 +1853,7 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 							return
 						}
 
-						tmpExecutedQty = quantityFloat
+						tmpExecutedQty = quantityDecimal
 						if 0 != resOrder.RetCode {
 							if 10010 == resOrder.RetCode {
 								log.Println("api无效，更新用户api_status：", tmpUser)
@@ -1758,6 +1865,10 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 								orderMapTmp.Set(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, tmpExecutedQty)
 								log.Println("新增，暂存：", tmpUser, tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, tmpExecutedQty, resOrder)
 								return
+							} else if !isOpen && bybitReduceRejectedCodes[resOrder.RetCode] {
+								// 平仓超过实际持仓，按实际持仓平仓并校正系统仓位
+								reduceToLivePosition(ctx, tmpUser, tmpUpdateData.Symbol, positionSide, cycle, orderSourceUpdate, tmpExecutedQty, strUserId)
+								return
 							}
 
 							fmt.Println("bybit 新增仓位下单异常：", resOrder)
@@ -1768,120 +1879,26 @@ func (s *sBinanceTraderHistory) PullAndOrderNewGuiTu(ctx context.Context) {
 							log.Println("bybit 新增仓位下单错误，订单号错误：", resOrder)
 							return
 						}
-					}
 
-					// 不存在新增，这里只能是开仓
-					if !orderMap.Contains(tmpUpdateData.Symbol + "&" + positionSide + "&" + strUserId) {
-						// 追加仓位，开仓
-						if "LONG" == positionSide && "BUY" == side {
-							orderMap.Set(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, tmpExecutedQty)
-						} else if "SHORT" == positionSide && "SELL" == side {
-							orderMap.Set(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, tmpExecutedQty)
-						} else {
-							log.Println("未知仓位信息，信息", tmpUpdateData, tmpExecutedQty)
+						// 拆单时只有部分下单成功
+						if resOrder.placedQty.IsPositive() {
+							tmpExecutedQty = resOrder.placedQty
 						}
+					}
 
-						// 跟单人开仓成功，用户的预备仓位清空
-						orderMapTmp.Set(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, float64(0))
-					} else {
+					if ("LONG" == positionSide && "BUY" == side) || ("SHORT" == positionSide && "SELL" == side) {
 						// 追加仓位，开仓
-						if "LONG" == positionSide {
-							if "BUY" == side {
-								d1 := decimal.NewFromFloat(orderMap.Get(tmpUpdateData.Symbol + "&" + positionSide + "&" + strUserId).(float64))
-								d2 := decimal.NewFromFloat(tmpExecutedQty)
-								result := d1.Add(d2)
-
-								var (
-									newRes float64
-									exact  bool
-								)
-								newRes, exact = result.Float64()
-								if !exact {
-									fmt.Println("转换过程中可能发生了精度损失", d1, d2, tmpExecutedQty, orderMap.Get(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId).(float64), newRes)
-								}
-
-								orderMap.Set(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, newRes)
-
-								// 跟单人开仓成功，用户的预备仓位清空
-								orderMapTmp.Set(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, float64(0))
-							} else if "SELL" == side {
-								d1 := decimal.NewFromFloat(orderMap.Get(tmpUpdateData.Symbol + "&" + positionSide + "&" + strUserId).(float64))
-								d2 := decimal.NewFromFloat(tmpExecutedQty)
-								result := d1.Sub(d2)
-
-								var (
-									newRes float64
-									exact  bool
-								)
-								newRes, exact = result.Float64()
-								if !exact {
-									fmt.Println("转换过程中可能发生了精度损失", d1, d2, tmpExecutedQty, orderMap.Get(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId).(float64), newRes)
-								}
-
-								if lessThanOrEqualZero(newRes, 0, 1e-7) {
-									newRes = 0
-								}
-
-								orderMap.Set(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, newRes)
-
-								//  跟单人完全平仓，用户的预备仓位清空
-								if lessThanOrEqualZero(newRes, 0, 1e-7) {
-									orderMapTmp.Set(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, float64(0))
-								}
-							} else {
-								log.Println("未知仓位信息，信息", tmpUpdateData, tmpExecutedQty)
-							}
+						addOrderQty(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, tmpExecutedQty)
 
-						} else if "SHORT" == positionSide {
-							if "SELL" == side {
-								d1 := decimal.NewFromFloat(orderMap.Get(tmpUpdateData.Symbol + "&" + positionSide + "&" + strUserId).(float64))
-								d2 := decimal.NewFromFloat(tmpExecutedQty)
-								result := d1.Add(d2)
-
-								var (
-									newRes float64
-									exact  bool
-								)
-								newRes, exact = result.Float64()
-								if !exact {
-									fmt.Println("转换过程中可能发生了精度损失", d1, d2, tmpExecutedQty, orderMap.Get(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId).(float64), newRes)
-								}
-
-								orderMap.Set(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, newRes)
-
-								// 跟单人开仓成功，用户的预备仓位清空
-								orderMapTmp.Set(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, float64(0))
-							} else if "BUY" == side {
-								d1 := decimal.NewFromFloat(orderMap.Get(tmpUpdateData.Symbol + "&" + positionSide + "&" + strUserId).(float64))
-								d2 := decimal.NewFromFloat(tmpExecutedQty)
-								result := d1.Sub(d2)
-
-								var (
-									newRes float64
-									exact  bool
-								)
-								newRes, exact = result.Float64()
-								if !exact {
-									fmt.Println("转换过程中可能发生了精度损失", d1, d2, tmpExecutedQty, orderMap.Get(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId).(float64), newRes)
-								}
-
-								if lessThanOrEqualZero(newRes, 0, 1e-7) {
-									newRes = 0
-								}
-
-								orderMap.Set(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, newRes)
-
-								//  跟单人完全平仓，用户的预备仓位清空
-								if lessThanOrEqualZero(newRes, 0, 1e-7) {
-									orderMapTmp.Set(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, float64(0))
-								}
-							} else {
-								log.Println("未知仓位信息，信息", tmpUpdateData, tmpExecutedQty)
-							}
-
-						} else {
-							log.Println("未知仓位信息，信息", tmpUpdateData, tmpExecutedQty)
+						// 跟单人开仓成功，用户的预备仓位清空
+						orderMapTmp.Set(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, decimal.Zero)
+					} else if ("LONG" == positionSide && "SELL" == side) || ("SHORT" == positionSide && "BUY" == side) {
+						//  跟单人完全平仓，用户的预备仓位清空
+						if !subOrderQty(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, tmpExecutedQty).IsPositive() {
+							orderMapTmp.Set(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, decimal.Zero)
 						}
+					} else {
+						log.Println("未知仓位信息，信息", tmpUpdateData, tmpExecutedQty)
 					}
 
 					log.Println("现有仓位：", tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId, orderMap.Get(tmpUpdateData.Symbol+"&"+positionSide+"&"+strUserId))
@@ -2027,7 +2044,7 @@ func (s *sBinanceTraderHistory) CreateUser(ctx context.Context, address, apiKey,
 	return nil
 }
 
-// SetPositionSide set position side
+// SetPositionSide set hedge position mode, returns 1 when hedge, 2 when the account stays in one-way mode, 0 on failure
 func (s *sBinanceTraderHistory) SetPositionSide(ctx context.Context, plat, apiKey, apiSecret string) (uint64, string) {
 	var (
 		res    bool
@@ -2038,9 +2055,16 @@ func (s *sBinanceTraderHistory) SetPositionSide(ctx context.Context, plat, apiKe
 	if "binance" == plat {
 		err, resStr, res = requestBinancePositionSide("true", apiKey, apiSecret)
 		if nil != err || !res {
+			// 切换不了时，账户是单向持仓也可以跟单
+			if oneWay, tmpErr := requestBinanceOneWay(apiKey, apiSecret); nil == tmpErr && oneWay {
+				setOneWay(apiKey, true)
+				return 2, resStr
+			}
+
 			return 0, resStr
 		}
 
+		setOneWay(apiKey, false)
 		return 1, resStr
 	} else if "bybit" == plat {
 		var (
@@ -2054,12 +2078,20 @@ func (s *sBinanceTraderHistory) SetPositionSide(ctx context.Context, plat, apiKe
 
 		if 0 != bybitRes.RetCode {
 			if 110025 == bybitRes.RetCode {
+				setOneWay(apiKey, false)
 				return 1, resStrBybit
 			}
 
+			// 切换不了时，账户是单向持仓也可以跟单
+			if oneWay, tmpErr := bybitOneWay(ctx, apiKey, apiSecret); nil == tmpErr && oneWay {
+				setOneWay(apiKey, true)
+				return 2, resStrBybit
+			}
+
 			return 0, resStrBybit
 		}
 
+		setOneWay(apiKey, false)
 		return 1, resStrBybit
 	}
 
@@ -2211,8 +2243,7 @@ func (s *sBinanceTraderHistory) GetSystemUserPositions(ctx context.Context, apiK
 		}
 
 		part1 := parts[1]
-		amount := v.(float64)
-		res[parts[0]+"&"+part1] = math.Abs(amount)
+		res[parts[0]+"&"+part1] = v.(decimal.Decimal).Abs().InexactFloat64()
 		return true
 	})
 
@@ -2243,15 +2274,15 @@ func (s *sBinanceTraderHistory) GetPlatUserPositions(ctx context.Context, apiKey
 		for _, v := range positions {
 			// 新增
 			var (
-				currentAmount float64
+				currentAmount decimal.Decimal
 			)
-			currentAmount, err = strconv.ParseFloat(v.PositionAmt, 64)
+			currentAmount, err = decimal.NewFromString(v.PositionAmt)
 			if nil != err {
 				log.Println("获取用户仓位接口，解析出错")
 				continue
 			}
 
-			if floatEqual(currentAmount, 0, 1e-7) {
+			if currentAmount.IsZero() {
 				continue
 			}
 
@@ -2274,15 +2305,15 @@ func (s *sBinanceTraderHistory) GetPlatUserPositions(ctx context.Context, apiKey
 
 			// 新增
 			var (
-				currentAmount float64
+				currentAmount decimal.Decimal
 			)
-			currentAmount, err = strconv.ParseFloat(v.Size, 64)
+			currentAmount, err = decimal.NewFromString(v.Size)
 			if nil != err {
 				log.Println("获取用户仓位接口，解析出错")
 				continue
 			}
 
-			if floatEqual(currentAmount, 0, 1e-7) {
+			if currentAmount.IsZero() {
 				continue
 			}
 
@@ -2306,6 +2337,7 @@ func (s *sBinanceTraderHistory) CloseBinanceUserPositions(ctx context.Context) u
 	var (
 		err   error
 		users []*entity.User
+		cycle = orderCycle(time.Now())
 	)
 
 	err = g.Model("user").Where("api_status=?", 1).Ctx(ctx).Scan(&users)
@@ -2325,30 +2357,32 @@ func (s *sBinanceTraderHistory) CloseBinanceUserPositions(ctx context.Context) u
 			for _, v := range positions {
 				// 新增
 				var (
-					currentAmount float64
+					currentAmount decimal.Decimal
 				)
-				currentAmount, err = strconv.ParseFloat(v.PositionAmt, 64)
+				currentAmount, err = decimal.NewFromString(v.PositionAmt)
 				if nil != err {
 					log.Println("close positions 获取用户仓位接口，解析出错", v, vUser)
 					continue
 				}
 
-				currentAmount = math.Abs(currentAmount)
-				if floatEqual(currentAmount, 0, 1e-7) {
+				// 单向持仓按正负折算成多空
+				positionSide := netPositionSide(v.PositionSide, currentAmount)
+				currentAmount = currentAmount.Abs()
+				if currentAmount.IsZero() {
 					continue
 				}
 
 				var (
-					symbolRel     = v.Symbol
-					tmpQty        float64
-					quantity      string
-					quantityFloat float64
-					orderType     = "MARKET"
-					side          string
+					symbolRel       = v.Symbol
+					tmpQty          decimal.Decimal
+					quantity        string
+					quantityDecimal decimal.Decimal
+					orderType       = "MARKET"
+					side            string
 				)
-				if "LONG" == v.PositionSide {
+				if "LONG" == positionSide {
 					side = "SELL"
-				} else if "SHORT" == v.PositionSide {
+				} else if "SHORT" == positionSide {
 					side = "BUY"
 				} else {
 					log.Println("close positions 仓位错误", v, vUser)
@@ -2361,20 +2395,11 @@ func (s *sBinanceTraderHistory) CloseBinanceUserPositions(ctx context.Context) u
 					continue
 				}
 
-				// 精度调整
-				if 0 >= symbolsMap.Get(symbolRel).(*LhCoinSymbol).QuantityPrecision {
-					quantity = fmt.Sprintf("%d", int64(tmpQty))
-				} else {
-					quantity = strconv.FormatFloat(tmpQty, 'f', symbolsMap.Get(symbolRel).(*LhCoinSymbol).QuantityPrecision, 64)
-				}
-
-				quantityFloat, err = strconv.ParseFloat(quantity, 64)
-				if nil != err {
-					log.Println("close positions，数量解析", v, vUser, err)
-					continue
-				}
+				// 精度调整，全平按持有数量
+				quantityDecimal = roundCloseQty("binance", symbolRel, tmpQty, tmpQty)
+				quantity = formatQty("binance", symbolRel, quantityDecimal)
 
-				if lessThanOrEqualZero(quantityFloat, 0, 1e-7) {
+				if !quantityDecimal.IsPositive() {
 					continue
 				}
 
@@ -2384,9 +2409,9 @@ func (s *sBinanceTraderHistory) CloseBinanceUserPositions(ctx context.Context) u
 				)
 
 				// 请求下单
-				binanceOrderRes, orderInfoRes, err = requestBinanceOrder(symbolRel, side, orderType, v.PositionSide, quantity, vUser.ApiKey, vUser.ApiSecret)
+				binanceOrderRes, orderInfoRes, err = requestBinanceOrderSplit(symbolRel, side, orderType, positionSide, quantity, binanceClientOrderId(cycle, vUser.Id, symbolRel, positionSide, side), vUser.ApiKey, vUser.ApiSecret)
 				if nil != err {
-					log.Println("close positions，执行下单错误，手动：", err, symbolRel, side, orderType, v.PositionSide, quantity, vUser.ApiKey, vUser.ApiSecret)
+					log.Println("close positions，执行下单错误，手动：", err, symbolRel, side, orderType, positionSide, quantity, vUser.ApiKey, vUser.ApiSecret)
 				}
 
 				// 下单异常
@@ -2414,45 +2439,53 @@ func (s *sBinanceTraderHistory) CloseBinanceUserPositions(ctx context.Context) u
 
 				// 新增
 				var (
-					currentAmount float64
+					currentAmount decimal.Decimal
 				)
-				currentAmount, err = strconv.ParseFloat(v.Size, 64)
+				currentAmount, err = decimal.NewFromString(v.Size)
 				if nil != err {
 					log.Println("获取用户仓位接口，解析出错")
 					continue
 				}
 
-				if floatEqual(currentAmount, 0, 1e-7) {
+				if currentAmount.IsZero() {
 					continue
 				}
 
 				var (
-					symbolRel = v.Symbol
-					quantity  = v.Size
-					side      string
+					symbolRel   = v.Symbol
+					quantity    = v.Size
+					side        string
+					positionIdx = v.PositionIdx
 				)
-				if 1 == v.PositionIdx {
+
+				// 单向持仓按持仓方向折算成多空，下单时再转回0
+				if 0 == positionIdx {
+					if "Buy" == v.Side {
+						positionIdx = 1
+					} else if "Sell" == v.Side {
+						positionIdx = 2
+					}
+				}
+
+				if 1 == positionIdx {
 					side = "Sell"
-				} else if 2 == v.PositionIdx {
+				} else if 2 == positionIdx {
 					side = "Buy"
 				} else {
 					log.Println("close positions 仓位错误，bybit", v, vUser)
 					continue
 				}
 
-				if !globalUsersOrderId.Contains(users[0].Id) {
-					log.Println("close position，无效信息，不存在自增订单，信息", users[0])
+				tmpOrderIdStr, ok := nextBybitOrderLinkId(vUser.Id, cycle, orderSourceClose, side, positionIdx)
+				if !ok {
+					log.Println("close position，无效信息，不存在自增订单，信息", vUser)
 					continue
 				}
 
-				tmpOrderId := globalUsersOrderId.Get(users[0].Id).(uint64)
-				globalUsersOrderId.Set(users[0].Id, tmpOrderId+1)
-				tmpOrderIdStr := strconv.FormatUint(uint64(users[0].Id), 10) + "at" + strconv.FormatUint(uint64(time.Now().Unix()), 10) + "&" + strconv.FormatUint(tmpOrderId, 10)
-
 				var (
 					resOrder *BybitPlaceOrderResponse
 				)
-				resOrder, err = bybitPlaceOrder(ctx, vUser.ApiKey, vUser.ApiSecret, symbolRel, quantity, side, v.PositionIdx, tmpOrderIdStr)
+				resOrder, err = bybitPlaceOrderSplit(ctx, vUser.ApiKey, vUser.ApiSecret, symbolRel, quantity, side, positionIdx, tmpOrderIdStr)
 				if nil != err {
 					log.Println("bybit 仓位下单错误", err, resOrder)
 				}
@@ -2484,6 +2517,7 @@ func (s *sBinanceTraderHistory) SetSystemUserPosition(ctx context.Context, syste
 	var (
 		err   error
 		users []*entity.User
+		cycle = orderCycle(time.Now())
 	)
 
 	err = g.Model("user").Where("api_key=?", apiKey).Ctx(ctx).Scan(&users)
@@ -2503,11 +2537,11 @@ func (s *sBinanceTraderHistory) SetSystemUserPosition(ctx context.Context, syste
 
 	if "binance" == vTmpUserMap.Plat {
 		var (
-			symbolRel     = symbolMapKey
-			tmpQty        float64
-			quantity      string
-			quantityFloat float64
-			orderType     = "MARKET"
+			symbolRel       = symbolMapKey
+			tmpQty          decimal.Decimal
+			quantity        string
+			quantityDecimal decimal.Decimal
+			orderType       = "MARKET"
 		)
 		if "LONG" == positionSide {
 
@@ -2535,26 +2569,17 @@ func (s *sBinanceTraderHistory) SetSystemUserPosition(ctx context.Context, syste
 			return 0
 		}
 
-		tmpQty = num // 本次开单数量
+		tmpQty = decimal.NewFromFloat(num) // 本次开单数量
 		if !symbolsMap.Contains(symbolMapKey) {
 			log.Println("自定义下单，代币信息无效，信息", apiKey, symbol, side, positionSide, num)
 			return 0
 		}
 
-		// 精度调整
-		if 0 >= symbolsMap.Get(symbolMapKey).(*LhCoinSymbol).QuantityPrecision {
-			quantity = fmt.Sprintf("%d", int64(tmpQty))
-		} else {
-			quantity = strconv.FormatFloat(tmpQty, 'f', symbolsMap.Get(symbolMapKey).(*LhCoinSymbol).QuantityPrecision, 64)
-		}
-
-		quantityFloat, err = strconv.ParseFloat(quantity, 64)
-		if nil != err {
-			log.Println(err)
-			return 0
-		}
+		// 精度调整，手动下单向下取整，不超过指定数量
+		quantityDecimal = roundOpenQty("binance", symbolMapKey, tmpQty)
+		quantity = formatQty("binance", symbolMapKey, quantityDecimal)
 
-		if lessThanOrEqualZero(quantityFloat, 0, 1e-7) {
+		if !quantityDecimal.IsPositive() {
 			return 0
 		}
 
@@ -2565,7 +2590,7 @@ func (s *sBinanceTraderHistory) SetSystemUserPosition(ctx context.Context, syste
 
 		if 1 == systemOrder {
 			// 请求下单
-			binanceOrderRes, orderInfoRes, err = requestBinanceOrder(symbolRel, side, orderType, positionSide, quantity, vTmpUserMap.ApiKey, vTmpUserMap.ApiSecret)
+			binanceOrderRes, orderInfoRes, err = requestBinanceOrderSplit(symbolRel, side, orderType, positionSide, quantity, binanceClientOrderId(cycle, vTmpUserMap.Id, symbolRel, positionSide, side), vTmpUserMap.ApiKey, vTmpUserMap.ApiSecret)
 			if nil != err {
 				log.Println("执行下单错误，手动：", err, symbolRel, side, orderType, positionSide, quantity, vTmpUserMap.ApiKey, vTmpUserMap.ApiSecret)
 			}
@@ -2586,125 +2611,46 @@ func (s *sBinanceTraderHistory) SetSystemUserPosition(ctx context.Context, syste
 
 			// 下单异常
 			if 0 >= binanceOrderRes.OrderId {
+				// 系统仓位平仓超过实际持仓，按实际持仓平仓并校正系统仓位
+				if 1 == system && nil != orderInfoRes && binanceReduceRejectedCodes[orderInfoRes.Code] && !(("LONG" == positionSide && "BUY" == side) || ("SHORT" == positionSide && "SELL" == side)) {
+					reduceToLivePosition(ctx, vTmpUserMap, symbolRel, positionSide, cycle, orderSourceManual, quantityDecimal, strUserId)
+					return 1
+				}
+
 				log.Println("自定义下单，binance下单错误：", orderInfoRes)
 				return 0
 			}
 		}
 
-		var tmpExecutedQty float64
-		tmpExecutedQty = quantityFloat
+		var tmpExecutedQty decimal.Decimal
+		tmpExecutedQty = quantityDecimal
+		// 拆单时只有部分下单成功
+		if nil != binanceOrderRes && binanceOrderRes.placedQty.IsPositive() {
+			tmpExecutedQty = binanceOrderRes.placedQty
+		}
 
 		if 1 == system {
-			// 不存在新增，这里只能是开仓
-			if !orderMap.Contains(symbolRel + "&" + positionSide + "&" + strUserId) {
-				orderMap.Set(symbolRel+"&"+positionSide+"&"+strUserId, tmpExecutedQty)
-			} else {
+			if ("LONG" == positionSide && "BUY" == side) || ("SHORT" == positionSide && "SELL" == side) {
 				// 追加仓位，开仓
-				if "LONG" == positionSide {
-					if "BUY" == side {
-						d1 := decimal.NewFromFloat(orderMap.Get(symbolRel + "&" + positionSide + "&" + strUserId).(float64))
-						d2 := decimal.NewFromFloat(tmpExecutedQty)
-						result := d1.Add(d2)
-
-						var (
-							newRes float64
-							exact  bool
-						)
-						newRes, exact = result.Float64()
-						if !exact {
-							fmt.Println("转换过程中可能发生了精度损失", d1, d2, tmpExecutedQty, orderMap.Get(symbolRel+"&"+positionSide+"&"+strUserId).(float64), newRes)
-						}
-
-						orderMap.Set(symbolRel+"&"+positionSide+"&"+strUserId, newRes)
-
-						// 跟单人开仓成功，用户的预备仓位清空
-						orderMapTmp.Set(symbolRel+"&"+positionSide+"&"+strUserId, float64(0))
-					} else if "SELL" == side {
-						d1 := decimal.NewFromFloat(orderMap.Get(symbolRel + "&" + positionSide + "&" + strUserId).(float64))
-						d2 := decimal.NewFromFloat(tmpExecutedQty)
-						result := d1.Sub(d2)
-
-						var (
-							newRes float64
-							exact  bool
-						)
-						newRes, exact = result.Float64()
-						if !exact {
-							fmt.Println("转换过程中可能发生了精度损失", d1, d2, tmpExecutedQty, orderMap.Get(symbolRel+"&"+positionSide+"&"+strUserId).(float64), newRes)
-						}
-
-						if lessThanOrEqualZero(newRes, 0, 1e-7) {
-							newRes = 0
-						}
-
-						orderMap.Set(symbolRel+"&"+positionSide+"&"+strUserId, newRes)
-
-						//  跟单人完全平仓，用户的预备仓位清空
-						if lessThanOrEqualZero(newRes, 0, 1e-7) {
-							orderMapTmp.Set(symbolRel+"&"+positionSide+"&"+strUserId, float64(0))
-						}
-					} else {
-						log.Println("手动，binance下单，数据存储:", system, apiKey, symbol, side, positionSide, num, binanceOrderRes, orderInfoRes, tmpExecutedQty)
-					}
-
-				} else if "SHORT" == positionSide {
-					if "SELL" == side {
-						d1 := decimal.NewFromFloat(orderMap.Get(symbolRel + "&" + positionSide + "&" + strUserId).(float64))
-						d2 := decimal.NewFromFloat(tmpExecutedQty)
-						result := d1.Add(d2)
-
-						var (
-							newRes float64
-							exact  bool
-						)
-						newRes, exact = result.Float64()
-						if !exact {
-							fmt.Println("转换过程中可能发生了精度损失", d1, d2, tmpExecutedQty, orderMap.Get(symbolRel+"&"+positionSide+"&"+strUserId).(float64), newRes)
-						}
-
-						orderMap.Set(symbolRel+"&"+positionSide+"&"+strUserId, newRes)
-
-						// 跟单人开仓成功，用户的预备仓位清空
-						orderMapTmp.Set(symbolRel+"&"+positionSide+"&"+strUserId, float64(0))
-					} else if "BUY" == side {
-						d1 := decimal.NewFromFloat(orderMap.Get(symbolRel + "&" + positionSide + "&" + strUserId).(float64))
-						d2 := decimal.NewFromFloat(tmpExecutedQty)
-						result := d1.Sub(d2)
-
-						var (
-							newRes float64
-							exact  bool
-						)
-						newRes, exact = result.Float64()
-						if !exact {
-							fmt.Println("转换过程中可能发生了精度损失", d1, d2, tmpExecutedQty, orderMap.Get(symbolRel+"&"+positionSide+"&"+strUserId).(float64), newRes)
-						}
-
-						if lessThanOrEqualZero(newRes, 0, 1e-7) {
-							newRes = 0
-						}
-
-						orderMap.Set(symbolRel+"&"+positionSide+"&"+strUserId, newRes)
-
-						//  跟单人完全平仓，用户的预备仓位清空
-						if lessThanOrEqualZero(newRes, 0, 1e-7) {
-							orderMapTmp.Set(symbolRel+"&"+positionSide+"&"+strUserId, float64(0))
-						}
-					} else {
-						log.Println("手动，binance下单，数据存储:", system, apiKey, symbol, side, positionSide, num, binanceOrderRes, orderInfoRes, tmpExecutedQty)
-					}
-
-				} else {
-					log.Println("手动，binance下单，数据存储:", system, apiKey, symbol, side, positionSide, num, binanceOrderRes, orderInfoRes, tmpExecutedQty)
+				addOrderQty(symbolRel+"&"+positionSide+"&"+strUserId, tmpExecutedQty)
+
+				// 跟单人开仓成功，用户的预备仓位清空
+				orderMapTmp.Set(symbolRel+"&"+positionSide+"&"+strUserId, decimal.Zero)
+			} else if ("LONG" == positionSide && "SELL" == side) || ("SHORT" == positionSide && "BUY" == side) {
+				//  跟单人完全平仓，用户的预备仓位清空
+				if !subOrderQty(symbolRel+"&"+positionSide+"&"+strUserId, tmpExecutedQty).IsPositive() {
+					orderMapTmp.Set(symbolRel+"&"+positionSide+"&"+strUserId, decimal.Zero)
 				}
+			} else {
+				log.Println("手动，binance下单，数据存储:", system, apiKey, symbol, side, positionSide, num, binanceOrderRes, orderInfoRes, tmpExecutedQty)
 			}
 		}
 	} else if "bybit" == vTmpUserMap.Plat {
 		var (
 			symbolRel         = symbolMapKey
-			tmpQty            float64
+			tmpQty            decimal.Decimal
 			quantity          string
-			quantityFloat     float64
+			quantityDecimal   decimal.Decimal
 			positionSideOrder int
 			sideOrder         string
 		)
@@ -2733,24 +2679,17 @@ func (s *sBinanceTraderHistory) SetSystemUserPosition(ctx context.Context, syste
 			return 0
 		}
 
-		tmpQty = num // 本次开单数量
+		tmpQty = decimal.NewFromFloat(num) // 本次开单数量
 		if !symbolsBybitMap.Contains(symbolMapKey) {
 			log.Println("自定义下单，代币信息无效，信息, bybit", apiKey, symbol, side, positionSide, num)
 			return 0
 		}
 
-		// 精度调整
-		tmpQtyStep := symbolsBybitMap.Get(symbolMapKey).(*BybitSymbol).QtyStep
-		tmpFloatQuantity := adjustToStepSize(tmpQty, tmpQtyStep)
+		// 精度调整，手动下单向下取整，不超过指定数量
+		quantityDecimal = roundOpenQty("bybit", symbolMapKey, tmpQty)
+		quantity = formatQty("bybit", symbolMapKey, quantityDecimal)
 
-		quantity = getStringFromStepSizePrecision(tmpFloatQuantity, tmpQtyStep)
-		quantityFloat, err = strconv.ParseFloat(quantity, 64)
-		if nil != err {
-			log.Println(err)
-			return 0
-		}
-
-		if lessThanOrEqualZero(quantityFloat, 0, 1e-7) {
+		if !quantityDecimal.IsPositive() {
 			return 0
 		}
 
@@ -2759,16 +2698,13 @@ func (s *sBinanceTraderHistory) SetSystemUserPosition(ctx context.Context, syste
 		)
 
 		if 1 == systemOrder {
-			if !globalUsersOrderId.Contains(vTmpUserMap.Id) {
+			tmpOrderIdStr, ok := nextBybitOrderLinkId(vTmpUserMap.Id, cycle, orderSourceManual, sideOrder, positionSideOrder)
+			if !ok {
 				log.Println("自定义下单，无效信息，不存在自增订单，信息", vTmpUserMap)
 				return 0
 			}
 
-			tmpOrderId := globalUsersOrderId.Get(vTmpUserMap.Id).(uint64)
-			globalUsersOrderId.Set(vTmpUserMap.Id, tmpOrderId+1)
-			tmpOrderIdStr := strconv.FormatUint(uint64(vTmpUserMap.Id), 10) + "at" + strconv.FormatUint(uint64(time.Now().Unix()), 10) + "&" + strconv.FormatUint(tmpOrderId, 10)
-
-			resOrder, err = bybitPlaceOrder(ctx, vTmpUserMap.ApiKey, vTmpUserMap.ApiSecret, symbolRel, quantity, sideOrder, positionSideOrder, tmpOrderIdStr)
+			resOrder, err = bybitPlaceOrderSplit(ctx, vTmpUserMap.ApiKey, vTmpUserMap.ApiSecret, symbolRel, quantity, sideOrder, positionSideOrder, tmpOrderIdStr)
 			if nil != err {
 				log.Println("bybit 自定义下单，错误", err, resOrder)
 			}
@@ -2779,6 +2715,12 @@ func (s *sBinanceTraderHistory) SetSystemUserPosition(ctx context.Context, syste
 			}
 
 			if 0 != resOrder.RetCode {
+				// 系统仓位平仓超过实际持仓，按实际持仓平仓并校正系统仓位
+				if 1 == system && bybitReduceRejectedCodes[resOrder.RetCode] && !(("LONG" == positionSide && "BUY" == side) || ("SHORT" == positionSide && "SELL" == side)) {
+					reduceToLivePosition(ctx, vTmpUserMap, symbolRel, positionSide, cycle, orderSourceManual, quantityDecimal, strUserId)
+					return 1
+				}
+
 				fmt.Println("bybit 自定义下单，错误，下单异常：", resOrder)
 				return 0
 			}
@@ -2789,112 +2731,27 @@ func (s *sBinanceTraderHistory) SetSystemUserPosition(ctx context.Context, syste
 			}
 		}
 
-		var tmpExecutedQty float64
-		tmpExecutedQty = quantityFloat
+		var tmpExecutedQty decimal.Decimal
+		tmpExecutedQty = quantityDecimal
+		// 拆单时只有部分下单成功
+		if nil != resOrder && resOrder.placedQty.IsPositive() {
+			tmpExecutedQty = resOrder.placedQty
+		}
 
 		if 1 == system {
-			// 不存在新增，这里只能是开仓
-			if !orderMap.Contains(symbolRel + "&" + positionSide + "&" + strUserId) {
-				orderMap.Set(symbolRel+"&"+positionSide+"&"+strUserId, tmpExecutedQty)
-			} else {
+			if ("LONG" == positionSide && "BUY" == side) || ("SHORT" == positionSide && "SELL" == side) {
 				// 追加仓位，开仓
-				if "LONG" == positionSide {
-					if "BUY" == side {
-						d1 := decimal.NewFromFloat(orderMap.Get(symbolRel + "&" + positionSide + "&" + strUserId).(float64))
-						d2 := decimal.NewFromFloat(tmpExecutedQty)
-						result := d1.Add(d2)
-
-						var (
-							newRes float64
-							exact  bool
-						)
-						newRes, exact = result.Float64()
-						if !exact {
-							fmt.Println("转换过程中可能发生了精度损失", d1, d2, tmpExecutedQty, orderMap.Get(symbolRel+"&"+positionSide+"&"+strUserId).(float64), newRes)
-						}
-
-						orderMap.Set(symbolRel+"&"+positionSide+"&"+strUserId, newRes)
-
-						// 跟单人开仓成功，用户的预备仓位清空
-						orderMapTmp.Set(symbolRel+"&"+positionSide+"&"+strUserId, float64(0))
-					} else if "SELL" == side {
-						d1 := decimal.NewFromFloat(orderMap.Get(symbolRel + "&" + positionSide + "&" + strUserId).(float64))
-						d2 := decimal.NewFromFloat(tmpExecutedQty)
-						result := d1.Sub(d2)
-
-						var (
-							newRes float64
-							exact  bool
-						)
-						newRes, exact = result.Float64()
-						if !exact {
-							fmt.Println("转换过程中可能发生了精度损失", d1, d2, tmpExecutedQty, orderMap.Get(symbolRel+"&"+positionSide+"&"+strUserId).(float64), newRes)
-						}
-
-						if lessThanOrEqualZero(newRes, 0, 1e-7) {
-							newRes = 0
-						}
-
-						orderMap.Set(symbolRel+"&"+positionSide+"&"+strUserId, newRes)
-
-						//  跟单人完全平仓，用户的预备仓位清空
-						if lessThanOrEqualZero(newRes, 0, 1e-7) {
-							orderMapTmp.Set(symbolRel+"&"+positionSide+"&"+strUserId, float64(0))
-						}
-					} else {
-						log.Println("手动，bybit下单，数据存储:", system, apiKey, symbol, side, positionSide, num, resOrder, tmpExecutedQty)
-					}
-
-				} else if "SHORT" == positionSide {
-					if "SELL" == side {
-						d1 := decimal.NewFromFloat(orderMap.Get(symbolRel + "&" + positionSide + "&" + strUserId).(float64))
-						d2 := decimal.NewFromFloat(tmpExecutedQty)
-						result := d1.Add(d2)
-
-						var (
-							newRes float64
-							exact  bool
-						)
-						newRes, exact = result.Float64()
-						if !exact {
-							fmt.Println("转换过程中可能发生了精度损失", d1, d2, tmpExecutedQty, orderMap.Get(symbolRel+"&"+positionSide+"&"+strUserId).(float64), newRes)
-						}
-
-						orderMap.Set(symbolRel+"&"+positionSide+"&"+strUserId, newRes)
-
-						// 跟单人开仓成功，用户的预备仓位清空
-						orderMapTmp.Set(symbolRel+"&"+positionSide+"&"+strUserId, float64(0))
-					} else if "BUY" == side {
-						d1 := decimal.NewFromFloat(orderMap.Get(symbolRel + "&" + positionSide + "&" + strUserId).(float64))
-						d2 := decimal.NewFromFloat(tmpExecutedQty)
-						result := d1.Sub(d2)
-
-						var (
-							newRes float64
-							exact  bool
-						)
-						newRes, exact = result.Float64()
-						if !exact {
-							fmt.Println("转换过程中可能发生了精度损失", d1, d2, tmpExecutedQty, orderMap.Get(symbolRel+"&"+positionSide+"&"+strUserId).(float64), newRes)
-						}
-
-						if lessThanOrEqualZero(newRes, 0, 1e-7) {
-							newRes = 0
-						}
-
-						orderMap.Set(symbolRel+"&"+positionSide+"&"+strUserId, newRes)
-
-						//  跟单人完全平仓，用户的预备仓位清空
-						if lessThanOrEqualZero(newRes, 0, 1e-7) {
-							orderMapTmp.Set(symbolRel+"&"+positionSide+"&"+strUserId, float64(0))
-						}
-					} else {
-						log.Println("手动，bybit下单，数据存储:", system, apiKey, symbol, side, positionSide, num, resOrder, tmpExecutedQty)
-					}
-
-				} else {
-					log.Println("手动，bybit下单，数据存储:", system, apiKey, symbol, side, positionSide, num, resOrder, tmpExecutedQty)
+				addOrderQty(symbolRel+"&"+positionSide+"&"+strUserId, tmpExecutedQty)
+
+				// 跟单人开仓成功，用户的预备仓位清空
+				orderMapTmp.Set(symbolRel+"&"+positionSide+"&"+strUserId, decimal.Zero)
+			} else if ("LONG" == positionSide && "SELL" == side) || ("SHORT" == positionSide && "BUY" == side) {
+				//  跟单人完全平仓，用户的预备仓位清空
+				if !subOrderQty(symbolRel+"&"+positionSide+"&"+strUserId, tmpExecutedQty).IsPositive() {
+					orderMapTmp.Set(symbolRel+"&"+positionSide+"&"+strUserId, decimal.Zero)
 				}
+			} else {
+				log.Println("手动，bybit下单，数据存储:", system, apiKey, symbol, side, positionSide, num, resOrder, tmpExecutedQty)
 			}
 		}
 
@@ -2928,9 +2785,14 @@ type binancePositionResp struct {
 }
 
 type binancePositionDataList struct {
-	Symbol         string
-	PositionSide   string
-	PositionAmount string
+	Symbol           string
+	PositionSide     string
+	PositionAmount   string
+	EntryPrice       string
+	MarkPrice        string
+	UnrealizedProfit string
+	Leverage         int
+	Isolated         bool
 }
 
 // 请求binance的持有仓位历史接口，新
@@ -3015,6 +2877,8 @@ type binanceOrder struct {
 	ClosePosition bool
 	Type          string
 	Status        string
+
+	placedQty decimal.Decimal // 拆单时实际下单成功的数量
 }
 
 type orderInfo struct {
@@ -3022,7 +2886,13 @@ type orderInfo struct {
 	Msg  string
 }
 
-func requestBinanceOrder(symbol string, side string, orderType string, positionSide string, quantity string, apiKey string, secretKey string) (*binanceOrder, *orderInfo, error) {
+// requestBinanceOrder 下单，网络错误和服务端异常返回errOrderUnknown，订单可能已成交
+func requestBinanceOrder(symbol string, side string, orderType string, positionSide string, quantity string, clientOrderId string, apiKey string, secretKey string) (*binanceOrder, *orderInfo, error) {
+	return requestBinanceOrderPrice(symbol, side, orderType, positionSide, quantity, "", clientOrderId, apiKey, secretKey)
+}
+
+// requestBinanceOrderPrice 下单，有价格时为GTC限价单
+func requestBinanceOrderPrice(symbol string, side string, orderType string, positionSide string, quantity string, price string, clientOrderId string, apiKey string, secretKey string) (*binanceOrder, *orderInfo, error) {
 	var (
 		client       *http.Client
 		req          *http.Request
@@ -3036,10 +2906,32 @@ func requestBinanceOrder(symbol string, side string, orderType string, positionS
 	)
 
 	//fmt.Println(symbol, side, orderType, positionSide, quantity, apiKey, secretKey)
+	// 限频，平仓优先
+	err = acquireBinance(apiKey, 1, true, isCloseOrder(side, positionSide))
+	if nil != err {
+		return &binanceOrder{}, &orderInfo{Code: -1003, Msg: err.Error()}, err
+	}
+
+	// 单向持仓，方向用BOTH，减少净仓位的平仓只减仓
+	oneWay := isOneWay(apiKey)
+	orderPositionSide := positionSide
+	reduceOnly := ""
+	if oneWay {
+		orderPositionSide = "BOTH"
+		if isCloseOrder(side, positionSide) && oneWayCloseReduces(apiKey, symbol, positionSide) {
+			reduceOnly = "&reduceOnly=true"
+		}
+	}
+
+	limitPrice := ""
+	if 0 < len(price) {
+		limitPrice = "&price=" + price + "&timeInForce=GTC"
+	}
+
 	// 时间
 	now := strconv.FormatInt(time.Now().UTC().UnixMilli(), 10)
 	// 拼请求数据
-	data = "symbol=" + symbol + "&side=" + side + "&type=" + orderType + "&positionSide=" + positionSide + "&newOrderRespType=" + "RESULT" + "&quantity=" + quantity + "&timestamp=" + now
+	data = "symbol=" + symbol + "&side=" + side + "&type=" + orderType + "&positionSide=" + orderPositionSide + reduceOnly + "&newOrderRespType=" + "RESULT" + "&quantity=" + quantity + limitPrice + "&newClientOrderId=" + clientOrderId + "&timestamp=" + now
 
 	// 加密
 	h := hmac.New(sha256.New, []byte(secretKey))
@@ -3049,7 +2941,7 @@ func requestBinanceOrder(symbol string, side string, orderType string, positionS
 
 	req, err = http.NewRequest("POST", apiUrl, strings.NewReader(data+"&signature="+signature))
 	if err != nil {
-		return nil, nil, err
+		return &binanceOrder{}, &orderInfo{Msg: err.Error()}, err
 	}
 	// 添加头信息
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
@@ -3059,8 +2951,9 @@ func requestBinanceOrder(symbol string, side string, orderType string, positionS
 	client = &http.Client{Timeout: 3 * time.Second}
 	resp, err = client.Do(req)
 	if err != nil {
-		return nil, nil, err
+		return &binanceOrder{}, &orderInfo{Msg: err.Error()}, fmt.Errorf("%w: %v", errOrderUnknown, err)
 	}
+	updateBinanceRate(apiKey, resp)
 
 	// 结果
 	defer func() {
@@ -3075,14 +2968,18 @@ func requestBinanceOrder(symbol string, side string, orderType string, positionS
 	b, err = ioutil.ReadAll(resp.Body)
 	if err != nil {
 		fmt.Println(string(b), err)
-		return nil, nil, err
+		return &binanceOrder{}, &orderInfo{Msg: err.Error()}, fmt.Errorf("%w: %v", errOrderUnknown, err)
 	}
 
 	var o binanceOrder
 	err = json.Unmarshal(b, &o)
 	if err != nil {
 		fmt.Println(string(b), err)
-		return nil, nil, err
+		// 网关错误等非json返回
+		if http.StatusInternalServerError <= resp.StatusCode {
+			return &binanceOrder{}, &orderInfo{Msg: err.Error()}, fmt.Errorf("%w: %v", errOrderUnknown, err)
+		}
+		return &binanceOrder{}, &orderInfo{Msg: err.Error()}, err
 	}
 
 	res = &binanceOrder{
@@ -3096,6 +2993,7 @@ func requestBinanceOrder(symbol string, side string, orderType string, positionS
 		PositionSide:  o.PositionSide,
 		ClosePosition: o.ClosePosition,
 		Type:          o.Type,
+		Status:        o.Status,
 	}
 
 	if 0 >= res.OrderId {
@@ -3103,7 +3001,15 @@ func requestBinanceOrder(symbol string, side string, orderType string, positionS
 		err = json.Unmarshal(b, &resOrderInfo)
 		if err != nil {
 			fmt.Println(string(b), err)
-			return nil, nil, err
+			return res, &orderInfo{Msg: err.Error()}, err
+		}
+
+		// 持仓模式和记录的不一致，重新查询，模式有变化时按新模式重新下单
+		if -4061 == resOrderInfo.Code {
+			if tmpOneWay, tmpErr := requestBinanceOneWay(apiKey, secretKey); nil == tmpErr && setOneWay(apiKey, tmpOneWay) {
+				log.Println("持仓模式不一致，重新下单，单向持仓：", symbol, clientOrderId, tmpOneWay)
+				return requestBinanceOrderPrice(symbol, side, orderType, positionSide, quantity, price, clientOrderId, apiKey, secretKey)
+			}
 		}
 	}
 
@@ -3128,11 +3034,18 @@ func requestBinanceTraderDetail(portfolioId uint64) (string, error) {
 		apiUrl = "https://www.binance.com/bapi/futures/v1/friendly/future/copy-trade/lead-portfolio/detail?portfolioId=" + strconv.FormatUint(portfolioId, 10)
 	)
 
+	// 限频，同一出口ip
+	err = acquireBinance("", 1, false, false)
+	if nil != err {
+		return res, err
+	}
+
 	// 构造请求
 	resp, err = http.Get(apiUrl)
 	if err != nil {
 		return res, err
 	}
+	updateBinanceRate("", resp)
 
 	// 结果
 	defer func() {
@@ -3171,26 +3084,43 @@ type BinanceExchangeInfoResp struct {
 
 // BinanceSymbolInfo 结构体表示单个交易对的信息
 type BinanceSymbolInfo struct {
-	Symbol            string `json:"symbol"`
-	Pair              string `json:"pair"`
-	ContractType      string `json:"contractType"`
-	Status            string `json:"status"`
-	BaseAsset         string `json:"baseAsset"`
-	QuoteAsset        string `json:"quoteAsset"`
-	MarginAsset       string `json:"marginAsset"`
-	PricePrecision    int    `json:"pricePrecision"`
-	QuantityPrecision int    `json:"quantityPrecision"`
+	Symbol            string                 `json:"symbol"`
+	Pair              string                 `json:"pair"`
+	ContractType      string                 `json:"contractType"`
+	Status            string                 `json:"status"`
+	BaseAsset         string                 `json:"baseAsset"`
+	QuoteAsset        string                 `json:"quoteAsset"`
+	MarginAsset       string                 `json:"marginAsset"`
+	PricePrecision    int                    `json:"pricePrecision"`
+	QuantityPrecision int                    `json:"quantityPrecision"`
+	Filters           []*BinanceSymbolFilter `json:"filters"`
+}
+
+// BinanceSymbolFilter 交易规则，只解析用到的字段
+type BinanceSymbolFilter struct {
+	FilterType string `json:"filterType"`
+	MinQty     string `json:"minQty"`
+	MaxQty     string `json:"maxQty"`
+	StepSize   string `json:"stepSize"`
+	Notional   string `json:"notional"`
+	TickSize   string `json:"tickSize"`
 }
 
 // 获取 Binance U 本位合约交易对信息
 func getBinanceFuturesPairs() ([]*BinanceSymbolInfo, error) {
 	apiUrl := "https://fapi.binance.com/fapi/v1/exchangeInfo"
 
+	// 限频
+	if err := acquireBinance("", 1, false, false); nil != err {
+		return nil, err
+	}
+
 	// 发送 HTTP GET 请求
 	resp, err := http.Get(apiUrl)
 	if err != nil {
 		return nil, err
 	}
+	updateBinanceRate("", resp)
 	defer func() {
 		if resp != nil && resp.Body != nil {
 			err := resp.Body.Close()
@@ -3218,12 +3148,19 @@ func getBinanceFuturesPairs() ([]*BinanceSymbolInfo, error) {
 
 // 获取币安服务器时间
 func getBinanceServerTime() int64 {
-	urlTmp := "https://api.binance.com/api/v3/time"
+	// 签名用于合约接口，用合约的服务器时间，和合约接口共用限频
+	urlTmp := "https://fapi.binance.com/fapi/v1/time"
+	if err := acquireBinance("", 1, false, false); nil != err {
+		log.Println("Error getting server time:", err)
+		return 0
+	}
+
 	resp, err := http.Get(urlTmp)
 	if err != nil {
 		log.Println("Error getting server time:", err)
 		return 0
 	}
+	updateBinanceRate("", resp)
 
 	defer func() {
 		if resp != nil && resp.Body != nil {
@@ -3263,19 +3200,39 @@ func generateSignature(apiS string, params url.Values) string {
 
 // Asset 代表单个资产的保证金信息
 type Asset struct {
-	TotalMarginBalance string `json:"totalMarginBalance"` // 资产余额
+	TotalMarginBalance string             `json:"totalMarginBalance"` // 资产余额
+	AvailableBalance   string             `json:"availableBalance"`   // 可用余额
+	TotalWalletBalance string             `json:"totalWalletBalance"` // 钱包余额
+	Positions          []*BinancePosition `json:"positions"`          // 仓位信息
 }
 
 // GetBinanceInfo 获取账户信息
 func getBinanceInfo(apiK, apiS string) string {
+	o := getBinanceAccount(apiK, apiS)
+	if nil == o {
+		return ""
+	}
+
+	// 返回资产余额
+	return o.TotalMarginBalance
+}
+
+// getBinanceAccount 获取账户保证金信息
+func getBinanceAccount(apiK, apiS string) *Asset {
 	// 请求的API地址
 	endpoint := "/fapi/v2/account"
 	baseURL := "https://fapi.binance.com"
 
+	// 限频
+	if err := acquireBinance(apiK, 5, false, false); nil != err {
+		log.Println("Error acquiring rate:", err)
+		return nil
+	}
+
 	// 获取当前时间戳（使用服务器时间避免时差问题）
-	serverTime := getBinanceServerTime()
+	serverTime := binanceTimestamp()
 	if serverTime == 0 {
-		return ""
+		return nil
 	}
 	timestamp := strconv.FormatInt(serverTime, 10)
 
@@ -3297,7 +3254,7 @@ func getBinanceInfo(apiK, apiS string) string {
 	req, err := http.NewRequest("GET", requestURL, nil)
 	if err != nil {
 		log.Println("Error creating request:", err)
-		return ""
+		return nil
 	}
 
 	// 添加请求头
@@ -3308,8 +3265,9 @@ func getBinanceInfo(apiK, apiS string) string {
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Println("Error sending request:", err)
-		return ""
+		return nil
 	}
+	updateBinanceRate(apiK, resp)
 
 	defer func() {
 		if resp != nil && resp.Body != nil {
@@ -3324,7 +3282,7 @@ func getBinanceInfo(apiK, apiS string) string {
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		log.Println("Error reading response:", err)
-		return ""
+		return nil
 	}
 
 	// 解析响应
@@ -3332,11 +3290,10 @@ func getBinanceInfo(apiK, apiS string) string {
 	err = json.Unmarshal(body, &o)
 	if err != nil {
 		log.Println("Error unmarshalling response:", err)
-		return ""
+		return nil
 	}
 
-	// 返回资产余额
-	return o.TotalMarginBalance
+	return o
 }
 
 func requestBinancePositionSide(positionSide string, apiKey string, secretKey string) (error, string, bool) {
@@ -3352,6 +3309,11 @@ func requestBinancePositionSide(positionSide string, apiKey string, secretKey st
 	)
 
 	//log.Println(symbol, side, orderType, positionSide, quantity, apiKey, secretKey)
+	err = acquireBinance(apiKey, 1, false, false)
+	if nil != err {
+		return err, "", false
+	}
+
 	// 时间
 	now := strconv.FormatInt(time.Now().UTC().UnixMilli(), 10)
 	// 拼请求数据
@@ -3377,6 +3339,7 @@ func requestBinancePositionSide(positionSide string, apiKey string, secretKey st
 	if err != nil {
 		return err, "", false
 	}
+	updateBinanceRate(apiKey, resp)
 
 	// 结果
 	defer func() {
@@ -3439,8 +3402,14 @@ func getBinancePositionInfo(apiK, apiS string) []*BinancePosition {
 	endpoint := "/fapi/v2/account"
 	baseURL := "https://fapi.binance.com"
 
+	// 限频
+	if err := acquireBinance(apiK, 5, false, false); nil != err {
+		log.Println("Error acquiring rate:", err)
+		return nil
+	}
+
 	// 获取当前时间戳（使用服务器时间避免时差问题）
-	serverTime := getBinanceServerTime()
+	serverTime := binanceTimestamp()
 	if serverTime == 0 {
 		return nil
 	}
@@ -3477,6 +3446,7 @@ func getBinancePositionInfo(apiK, apiS string) []*BinancePosition {
 		log.Println("Error sending request:", err)
 		return nil
 	}
+	updateBinanceRate(apiK, resp)
 	defer func() {
 		if resp != nil && resp.Body != nil {
 			err := resp.Body.Close()
@@ -3507,9 +3477,11 @@ func getBinancePositionInfo(apiK, apiS string) []*BinancePosition {
 
 // BybitLotSizeFilter 解析 LotSizeFilter 相关信息
 type BybitLotSizeFilter struct {
-	MinOrderQty string `json:"minOrderQty"` // 最小下单量
-	MaxOrderQty string `json:"maxOrderQty"` // 最大下单量
-	QtyStep     string `json:"qtyStep"`     // 下单步长
+	MinOrderQty      string `json:"minOrderQty"`      // 最小下单量
+	MaxOrderQty      string `json:"maxOrderQty"`      // 最大下单量
+	QtyStep          string `json:"qtyStep"`          // 下单步长
+	MaxMktOrderQty   string `json:"maxMktOrderQty"`   // 市价单最大下单量
+	MinNotionalValue string `json:"minNotionalValue"` // 最小名义价值
 }
 
 // BybitContract 解析 USDT 永续合约信息
@@ -3521,6 +3493,11 @@ type BybitContract struct {
 	QuoteCoin     string             `json:"quoteCoin"`     // 计价货币
 	LaunchTime    string             `json:"launchTime"`    // 上线时间
 	LotSizeFilter BybitLotSizeFilter `json:"lotSizeFilter"` // 下单规则
+	PriceFilter   BybitPriceFilter   `json:"priceFilter"`   // 价格规则
+}
+
+type BybitPriceFilter struct {
+	TickSize string `json:"tickSize"` // 价格步长
 }
 
 func getByBitCoinInfo(ctx context.Context) ([]*BybitContract, error) {
@@ -3538,11 +3515,17 @@ func getByBitCoinInfo(ctx context.Context) ([]*BybitContract, error) {
 		info *bybit.ServerResponse
 		err  error
 	)
+	err = acquireBybit("", false)
+	if nil != err {
+		return res, err
+	}
+
 	info, err = client.NewUtaBybitServiceWithParams(params).GetInstrumentInfo(ctx)
 	if err != nil {
 		log.Println("API 请求失败:", err)
 		return res, err
 	}
+	updateBybitRate("", info.RetCode)
 
 	// 检查返回码
 	if info.RetCode != 0 {
@@ -3631,11 +3614,16 @@ func getBybitAccountBalance(ctx context.Context, apiK, apiS string) ([]*BybitBal
 		"accountType": "UNIFIED", // 只查询 USDT 永续合约
 	}
 
+	if err := acquireBybit(apiK, false); nil != err {
+		return nil, err
+	}
+
 	resp, err := client.NewUtaBybitServiceWithParams(params).GetAccountWallet(ctx)
 	if err != nil {
 		log.Println("请求账户余额失败:", err)
 		return nil, err
 	}
+	updateBybitRate(apiK, resp.RetCode)
 
 	if resp.RetCode != 0 {
 		log.Println("账户余额接口返回错误:", resp.RetCode, resp.RetMsg)
@@ -3669,9 +3657,16 @@ type BybitPlaceOrderResponse struct {
 	} `json:"result"`
 	RetExtInfo interface{} `json:"retExtInfo"`
 	Time       int64       `json:"time"`
+
+	placedQty decimal.Decimal // 拆单时实际下单成功的数量
 }
 
 func bybitPlaceOrder(ctx context.Context, apiK, apiS, symbol, qty, side string, position int, orderId string) (*BybitPlaceOrderResponse, error) {
+	return bybitPlaceOrderPrice(ctx, apiK, apiS, symbol, qty, "", side, position, orderId)
+}
+
+// bybitPlaceOrderPrice 下单，有价格时为GTC限价单
+func bybitPlaceOrderPrice(ctx context.Context, apiK, apiS, symbol, qty, price, side string, position int, orderId string) (*BybitPlaceOrderResponse, error) {
 	// 初始化客户端（选择 TESTNET 或 MAINNET）
 	client := bybit.NewBybitHttpClient(
 		apiK,
@@ -3690,6 +3685,36 @@ func bybitPlaceOrder(ctx context.Context, apiK, apiS, symbol, qty, side string,
 		"orderLinkId": orderId,
 	}
 
+	if 0 < len(price) {
+		params["orderType"] = "Limit"
+		params["price"] = price
+		params["timeInForce"] = "GTC"
+	}
+
+	// 平仓只减仓，数量超过实际持仓时不会反向开仓
+	isClose := isBybitCloseOrder(side, position)
+	if isClose {
+		params["reduceOnly"] = true
+	}
+
+	// 单向持仓，仓位索引用0，平掉较小的一边会增加净仓位，不能只减仓
+	if isOneWay(apiK) {
+		params["positionIdx"] = 0
+		closePositionSide := "LONG"
+		if 2 == position {
+			closePositionSide = "SHORT"
+		}
+		if isClose && !oneWayCloseReduces(apiK, symbol, closePositionSide) {
+			delete(params, "reduceOnly")
+		}
+	}
+
+	// 限频，平仓优先
+	err := acquireBybit(apiK, isClose)
+	if nil != err {
+		return nil, err
+	}
+
 	//log.Println("测试下单信息：", params)
 	// 发起下单请求
 	resp, err := client.NewUtaBybitServiceWithParams(params).PlaceOrder(ctx)
@@ -3697,6 +3722,7 @@ func bybitPlaceOrder(ctx context.Context, apiK, apiS, symbol, qty, side string,
 		log.Printf("下单失败: %v", err)
 		return nil, err
 	}
+	updateBybitRate(apiK, resp.RetCode)
 	var (
 		contractJSON  []byte
 		orderResponse *BybitPlaceOrderResponse
@@ -3714,6 +3740,14 @@ func bybitPlaceOrder(ctx context.Context, apiK, apiS, symbol, qty, side string,
 		return nil, err
 	}
 
+	// 持仓模式和记录的不一致，重新查询，模式有变化时按新模式重新下单
+	if 10001 == orderResponse.RetCode && strings.Contains(orderResponse.RetMsg, "position idx") {
+		if tmpOneWay, tmpErr := bybitOneWay(ctx, apiK, apiS); nil == tmpErr && setOneWay(apiK, tmpOneWay) {
+			log.Println("bybit 持仓模式不一致，重新下单，单向持仓：", symbol, orderId, tmpOneWay)
+			return bybitPlaceOrderPrice(ctx, apiK, apiS, symbol, qty, price, side, position, orderId)
+		}
+	}
+
 	return orderResponse, nil
 }
 
@@ -3740,12 +3774,19 @@ func bybitSetPositionSide(ctx context.Context, apiK, apiS, symbol string) (*Bybi
 		"mode":     3,
 	}
 
+	// 限频
+	err := acquireBybit(apiK, false)
+	if nil != err {
+		return nil, "", err
+	}
+
 	// 发起下单请求
 	resp, err := client.NewUtaBybitServiceWithParams(params).SwitchPositionMode(ctx)
 	if err != nil {
 		log.Printf("设置持仓模式失败: %v", err)
 		return nil, "", err
 	}
+	updateBybitRate(apiK, resp.RetCode)
 	var (
 		contractJSON  []byte
 		orderResponse *BybitSetPositionSideResponse
@@ -3812,11 +3853,16 @@ func bybitGetPositionInfo(ctx context.Context, apiK, apiS string) (*BybitPositio
 		"settleCoin": "USDT",
 	}
 
+	if err := acquireBybit(apiK, false); nil != err {
+		return nil, err
+	}
+
 	resp, err := client.NewUtaBybitServiceWithParams(params).GetPositionList(ctx)
 	if err != nil {
 		log.Printf("查询持仓失败: %v", err)
 		return nil, err
 	}
+	updateBybitRate(apiK, resp.RetCode)
 
 	var (
 		jsonBytes   []byte
@@ -0,0 +1,410 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/do"
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/shopspring/decimal"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// agentMaxDepth 代理层级上限，防止上级关系成环
+const agentMaxDepth = 10
+
+// agentTokenHash token的sha256，库里只存hash
+func agentTokenHash(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// newAgentToken 生成代理查询token
+func newAgentToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); nil != err {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// loadAgents 所有代理，key为id
+func loadAgents(ctx context.Context) (map[uint]*entity.Agent, error) {
+	var agents []*entity.Agent
+	err := g.Model("agent").Ctx(ctx).Scan(&agents)
+	if nil != err {
+		return nil, err
+	}
+
+	res := make(map[uint]*entity.Agent, len(agents))
+	for _, v := range agents {
+		res[v.Id] = v
+	}
+
+	return res, nil
+}
+
+// agentChain 从直属代理往上的代理链，停用的代理跳过但继续往上
+func agentChain(agents map[uint]*entity.Agent, agentId uint) []*entity.Agent {
+	res := make([]*entity.Agent, 0)
+	for i := 0; 0 < agentId && i < agentMaxDepth; i++ {
+		agent, ok := agents[agentId]
+		if !ok {
+			break
+		}
+
+		if 1 == agent.Status {
+			res = append(res, agent)
+		}
+		agentId = agent.ParentId
+	}
+
+	return res
+}
+
+// agentSubtree 代理自身和所有下级代理的id
+func agentSubtree(agents map[uint]*entity.Agent, agentId uint) []uint {
+	children := make(map[uint][]uint, 0)
+	for _, v := range agents {
+		children[v.ParentId] = append(children[v.ParentId], v.Id)
+	}
+
+	var (
+		res   = []uint{agentId}
+		queue = []uint{agentId}
+		seen  = map[uint]bool{agentId: true}
+	)
+	for 0 < len(queue) {
+		current := queue[0]
+		queue = queue[1:]
+		for _, v := range children[current] {
+			if seen[v] {
+				continue
+			}
+			seen[v] = true
+			res = append(res, v)
+			queue = append(queue, v)
+		}
+	}
+
+	return res
+}
+
+// agentByToken 按token查询可用的代理
+func agentByToken(ctx context.Context, token string) (*entity.Agent, error) {
+	token = strings.TrimSpace(token)
+	if 0 >= len(token) {
+		return nil, errors.New("token为空")
+	}
+
+	var agents []*entity.Agent
+	err := g.Model("agent").Ctx(ctx).Where("token_hash=? AND status=?", agentTokenHash(token), 1).Scan(&agents)
+	if nil != err {
+		return nil, err
+	}
+	if 0 >= len(agents) {
+		return nil, errors.New("token无效")
+	}
+
+	return agents[0], nil
+}
+
+// agentCommissions 按代理链拆分账单的佣金，每一级实得自身比例减去下级的比例
+func agentCommissions(chain []*entity.Agent, statement *entity.FeeSettlement) []*do.AgentCommission {
+	var (
+		res       = make([]*do.AgentCommission, 0)
+		childRate float64
+		fee       = decimal.NewFromFloat(statement.Fee)
+	)
+	for level, v := range chain {
+		rate := v.CommissionRate - childRate
+		if v.CommissionRate > childRate {
+			childRate = v.CommissionRate
+		}
+		if 0 >= rate || IsEqual(rate, 0) {
+			continue
+		}
+
+		res = append(res, &do.AgentCommission{
+			AgentId:      v.Id,
+			SettlementId: statement.Id,
+			UserId:       statement.UserId,
+			Level:        level,
+			Rate:         rate,
+			Fee:          statement.Fee,
+			Commission:   fee.Mul(decimal.NewFromFloat(rate)).InexactFloat64(),
+			CreatedAt:    gtime.Now(),
+		})
+	}
+
+	return res
+}
+
+// recordAgentCommissions 账单支付后记录代理佣金，重复的忽略
+func recordAgentCommissions(ctx context.Context, statement *entity.FeeSettlement) error {
+	if 0 >= statement.Fee {
+		return nil
+	}
+
+	var users []*entity.User
+	err := g.Model("user").Ctx(ctx).Where("id=?", statement.UserId).Scan(&users)
+	if nil != err {
+		return err
+	}
+	if 0 >= len(users) || 0 >= users[0].Dai {
+		return nil
+	}
+
+	agents, err := loadAgents(ctx)
+	if nil != err {
+		return err
+	}
+
+	commissions := agentCommissions(agentChain(agents, uint(users[0].Dai)), statement)
+	if 0 >= len(commissions) {
+		return nil
+	}
+
+	_, err = g.Model("agent_commission").Ctx(ctx).Data(commissions).InsertIgnore()
+	return err
+}
+
+// GetAgents get all agents, token hashes are not returned
+func (s *sBinanceTraderHistory) GetAgents(ctx context.Context) []*entity.Agent {
+	res := make([]*entity.Agent, 0)
+	err := g.Model("agent").Ctx(ctx).OrderAsc("id").Scan(&res)
+	if nil != err {
+		log.Println("查询代理，数据库查询错误：", err)
+	}
+
+	for _, v := range res {
+		v.TokenHash = ""
+	}
+
+	return res
+}
+
+// SetAgent create the agent when id is 0 and return its token, otherwise update it.
+// The commission rate includes the sub-agents' part, so it can not be lower than any sub-agent's or higher than the parent's.
+func (s *sBinanceTraderHistory) SetAgent(ctx context.Context, id uint, parentId uint, name string, commissionRate float64, status int) (string, error) {
+	if 0 > commissionRate || 1 < commissionRate {
+		return "", errors.New("佣金比例错误")
+	}
+	if 1 != status && 2 != status {
+		return "", errors.New("状态错误")
+	}
+
+	agents, err := loadAgents(ctx)
+	if nil != err {
+		return "", err
+	}
+
+	if 0 < parentId {
+		parent, ok := agents[parentId]
+		if !ok {
+			return "", errors.New("上级代理不存在")
+		}
+		if commissionRate > parent.CommissionRate {
+			return "", errors.New("佣金比例不能高于上级代理")
+		}
+	}
+
+	if 0 >= id {
+		var token string
+		token, err = newAgentToken()
+		if nil != err {
+			return "", err
+		}
+
+		_, err = g.Model("agent").Ctx(ctx).Insert(&do.Agent{
+			ParentId:       parentId,
+			Name:           name,
+			TokenHash:      agentTokenHash(token),
+			CommissionRate: commissionRate,
+			Status:         status,
+			CreatedAt:      gtime.Now(),
+			UpdatedAt:      gtime.Now(),
+		})
+		if nil != err {
+			log.Println("新增代理失败：", err)
+			return "", err
+		}
+
+		return token, nil
+	}
+
+	if _, ok := agents[id]; !ok {
+		return "", errors.New("代理不存在")
+	}
+
+	// 上级不能是自己或自己的下级
+	for _, v := range agentSubtree(agents, id) {
+		if v == parentId {
+			return "", errors.New("上级代理不能是自己或下级代理")
+		}
+	}
+
+	for _, v := range agents {
+		if id == v.ParentId && v.CommissionRate > commissionRate {
+			return "", errors.New("佣金比例不能低于下级代理")
+		}
+	}
+
+	_, err = g.Model("agent").Ctx(ctx).Data(g.Map{
+		"parent_id":       parentId,
+		"name":            name,
+		"commission_rate": commissionRate,
+		"status":          status,
+		"updated_at":      gtime.Now(),
+	}).Where("id=?", id).Update()
+	if nil != err {
+		log.Println("更新代理失败：", err)
+		return "", err
+	}
+
+	return "", nil
+}
+
+// ResetAgentToken generate a new token for the agent, the old one stops working
+func (s *sBinanceTraderHistory) ResetAgentToken(ctx context.Context, id uint) (string, error) {
+	token, err := newAgentToken()
+	if nil != err {
+		return "", err
+	}
+
+	result, err := g.Model("agent").Ctx(ctx).Data(g.Map{
+		"token_hash": agentTokenHash(token),
+		"updated_at": gtime.Now(),
+	}).Where("id=?", id).Update()
+	if nil != err {
+		return "", err
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); 0 >= rowsAffected {
+		return "", errors.New("代理不存在")
+	}
+
+	return token, nil
+}
+
+// SetUserAgent set the user's direct agent, 0 for none
+func (s *sBinanceTraderHistory) SetUserAgent(ctx context.Context, apiKey string, agentId uint) error {
+	if 0 < agentId {
+		count, err := g.Model("agent").Ctx(ctx).Where("id=?", agentId).Count()
+		if nil != err {
+			return err
+		}
+		if 0 >= count {
+			return errors.New("代理不存在")
+		}
+	}
+
+	result, err := g.Model("user").Ctx(ctx).Data("dai", agentId).Where("api_key=?", apiKey).Update()
+	if nil != err {
+		log.Println("更新用户代理失败：", err)
+		return err
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); 0 >= rowsAffected {
+		return errors.New("用户不存在或未变更")
+	}
+
+	return nil
+}
+
+// GetAgentUsers get status, margin and system positions of the followers under the token's agent and its sub-agents.
+// Api keys are never returned.
+func (s *sBinanceTraderHistory) GetAgentUsers(ctx context.Context, token string) ([]map[string]interface{}, error) {
+	agent, err := agentByToken(ctx, token)
+	if nil != err {
+		return nil, err
+	}
+
+	agents, err := loadAgents(ctx)
+	if nil != err {
+		return nil, err
+	}
+
+	var users []*entity.User
+	err = g.Model("user").Ctx(ctx).WhereIn("dai", agentSubtree(agents, agent.Id)).OrderAsc("id").Scan(&users)
+	if nil != err {
+		return nil, err
+	}
+
+	// 系统仓位，key为symbol&positionSide&userId
+	positions := make(map[uint]map[string]float64, len(users))
+	orderMap.Iterator(func(k interface{}, v interface{}) bool {
+		parts := strings.Split(k.(string), "&")
+		if 3 != len(parts) {
+			return true
+		}
+
+		uid, parseErr := strconv.ParseUint(parts[2], 10, 64)
+		if nil != parseErr {
+			return true
+		}
+
+		qty := v.(decimal.Decimal)
+		if qty.IsZero() {
+			return true
+		}
+
+		if _, ok := positions[uint(uid)]; !ok {
+			positions[uint(uid)] = make(map[string]float64, 0)
+		}
+		positions[uint(uid)][parts[0]+"&"+parts[1]] = qty.Abs().InexactFloat64()
+		return true
+	})
+
+	res := make([]map[string]interface{}, 0, len(users))
+	for _, v := range users {
+		var margin float64
+		if tmp, ok := userBaseMoney(v.Id); ok {
+			margin = tmp.InexactFloat64()
+		}
+
+		userPositions := positions[v.Id]
+		if nil == userPositions {
+			userPositions = make(map[string]float64, 0)
+		}
+
+		res = append(res, map[string]interface{}{
+			"id":         v.Id,
+			"address":    v.Address,
+			"plat":       v.Plat,
+			"agentId":    v.Dai,
+			"apiStatus":  v.ApiStatus,
+			"openStatus": v.OpenStatus,
+			"num":        v.Num,
+			"margin":     margin,
+			"following":  globalUsers.Contains(v.Id),
+			"positions":  userPositions,
+			"createdAt":  v.CreatedAt,
+		})
+	}
+
+	return res, nil
+}
+
+// GetAgentCommissions get the token's agent commissions, newest first
+func (s *sBinanceTraderHistory) GetAgentCommissions(ctx context.Context, token string, limit int) ([]*entity.AgentCommission, error) {
+	agent, err := agentByToken(ctx, token)
+	if nil != err {
+		return nil, err
+	}
+
+	res := make([]*entity.AgentCommission, 0)
+	err = g.Model("agent_commission").Ctx(ctx).Where("agent_id=?", agent.Id).OrderDesc("id").Limit(limit).Scan(&res)
+	if nil != err {
+		return nil, err
+	}
+
+	return res, nil
+}
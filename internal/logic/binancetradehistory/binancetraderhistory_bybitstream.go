@@ -0,0 +1,472 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/do"
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/gogf/gf/v2/container/gmap"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	bybitUserStreams = gmap.NewIntAnyMap(true) // bybit私有数据流，key为用户id
+
+	bybitStreamPing      = 20 * time.Second // 心跳间隔
+	bybitStreamReadWait  = time.Minute      // 超过没有消息视为断线
+	bybitStreamResync    = 10 * time.Minute // 定期用接口校准余额和仓位
+	bybitStreamMarginAge = 15 * time.Minute // 校准后多久内可以直接用数据流计算保证金
+)
+
+// bybitUserStream 一个bybit用户的私有数据流状态
+type bybitUserStream struct {
+	user   *entity.User
+	cancel context.CancelFunc
+
+	mu            sync.Mutex
+	connected     bool
+	syncedAt      time.Time
+	walletBalance decimal.Decimal
+	positions     map[string]*streamPosition // 实际仓位，key为symbol&positionSide
+}
+
+// startBybitUserStream 开启bybit私有数据流，已开启时不处理
+func startBybitUserStream(ctx context.Context, user *entity.User) {
+	if "bybit" != user.Plat || bybitUserStreams.Contains(int(user.Id)) {
+		return
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream := &bybitUserStream{
+		user:      user,
+		cancel:    cancel,
+		positions: make(map[string]*streamPosition, 0),
+	}
+	bybitUserStreams.Set(int(user.Id), stream)
+
+	go stream.run(streamCtx)
+}
+
+// stopBybitUserStream 关闭bybit私有数据流
+func stopBybitUserStream(userId uint) {
+	if v := bybitUserStreams.Remove(int(userId)); nil != v {
+		v.(*bybitUserStream).cancel()
+	}
+}
+
+// bybitStreamMargin 数据流计算的保证金，钱包余额加未实现盈亏，未实现盈亏变化不推送，按标记价格计算。
+// 数据流断开或太久没校准时返回false
+func bybitStreamMargin(userId uint) (decimal.Decimal, bool) {
+	v := bybitUserStreams.Get(int(userId))
+	if nil == v {
+		return decimal.Zero, false
+	}
+
+	stream := v.(*bybitUserStream)
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+
+	if !stream.connected || time.Since(stream.syncedAt) > bybitStreamMarginAge {
+		return decimal.Zero, false
+	}
+
+	return stream.walletBalance.Add(streamUnrealized(stream.positions)), true
+}
+
+// run 维护连接，断线后重连
+func (s *bybitUserStream) run(ctx context.Context) {
+	for {
+		s.serve(ctx)
+
+		if !sleepWithCtx(ctx, userStreamReconnect) {
+			log.Println("bybit 私有数据流，退出：", s.user.Id)
+			return
+		}
+	}
+}
+
+// bybitStreamMessage 私有数据流消息，操作回应和推送共用
+type bybitStreamMessage struct {
+	Op      string          `json:"op"`
+	Success *bool           `json:"success"`
+	RetMsg  string          `json:"ret_msg"`
+	Topic   string          `json:"topic"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// serve 单个连接的生命周期，鉴权，订阅，心跳
+func (s *bybitUserStream) serve(ctx context.Context) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, "wss://stream.bybit.com/v5/private", nil)
+	if nil != err {
+		log.Println("bybit 私有数据流，连接失败：", s.user.Id, err)
+		return
+	}
+
+	connCtx, cancel := context.WithCancel(ctx)
+	var writeMu sync.Mutex
+	write := func(v interface{}) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_ = conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		return conn.WriteJSON(v)
+	}
+	defer func() {
+		cancel()
+		s.setConnected(false)
+		_ = conn.Close()
+	}()
+
+	// 鉴权，签名内容为 GET/realtime+过期时间
+	expires := strconv.FormatInt(time.Now().Add(10*time.Second).UnixMilli(), 10)
+	h := hmac.New(sha256.New, []byte(s.user.ApiSecret))
+	h.Write([]byte("GET/realtime" + expires))
+	if err = write(g.Map{"op": "auth", "args": []string{s.user.ApiKey, expires, hex.EncodeToString(h.Sum(nil))}}); nil != err {
+		log.Println("bybit 私有数据流，鉴权发送失败：", s.user.Id, err)
+		return
+	}
+
+	// 心跳和定期校准，连接结束时关闭连接让读取返回
+	go func() {
+		ping := time.NewTicker(bybitStreamPing)
+		resync := time.NewTicker(bybitStreamResync)
+		defer ping.Stop()
+		defer resync.Stop()
+
+		for {
+			select {
+			case <-connCtx.Done():
+				_ = conn.Close()
+				return
+			case <-ping.C:
+				if err := write(g.Map{"op": "ping"}); nil != err {
+					log.Println("bybit 私有数据流，心跳失败：", s.user.Id, err)
+					_ = conn.Close()
+					return
+				}
+			case <-resync.C:
+				s.resync(connCtx)
+			}
+		}
+	}()
+
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(bybitStreamReadWait))
+
+		var message []byte
+		_, message, err = conn.ReadMessage()
+		if nil != err {
+			if nil == connCtx.Err() {
+				log.Println("bybit 私有数据流，读取错误，重连：", s.user.Id, err)
+			}
+			return
+		}
+
+		var msg *bybitStreamMessage
+		if err = json.Unmarshal(message, &msg); nil != err || nil == msg {
+			log.Println("bybit 私有数据流，解析错误：", s.user.Id, err, string(message))
+			continue
+		}
+
+		switch msg.Op {
+		case "auth":
+			if nil == msg.Success || !*msg.Success {
+				log.Println("bybit 私有数据流，鉴权失败：", s.user.Id, msg.RetMsg)
+				return
+			}
+
+			if err = write(g.Map{"op": "subscribe", "args": []string{"execution.linear", "position.linear", "wallet"}}); nil != err {
+				log.Println("bybit 私有数据流，订阅发送失败：", s.user.Id, err)
+				return
+			}
+			continue
+		case "subscribe":
+			if nil == msg.Success || !*msg.Success {
+				log.Println("bybit 私有数据流，订阅失败：", s.user.Id, msg.RetMsg)
+				return
+			}
+
+			// 订阅成功后校准一次
+			s.resync(connCtx)
+			s.setConnected(true)
+			log.Println("bybit 私有数据流，已连接：", s.user.Id)
+			continue
+		}
+
+		if err = s.handle(ctx, msg); nil != err {
+			log.Println("bybit 私有数据流，处理错误：", s.user.Id, msg.Topic, err, string(message))
+		}
+	}
+}
+
+func (s *bybitUserStream) setConnected(connected bool) {
+	s.mu.Lock()
+	s.connected = connected
+	s.mu.Unlock()
+}
+
+// resync 用余额和仓位接口校准
+func (s *bybitUserStream) resync(ctx context.Context) {
+	list, err := getBybitAccountBalance(ctx, s.user.ApiKey, s.user.ApiSecret)
+	if nil != err || 0 >= len(list) {
+		return
+	}
+
+	positionRes, err := bybitGetPositionInfo(ctx, s.user.ApiKey, s.user.ApiSecret)
+	if nil != err || nil == positionRes || nil == positionRes.Result {
+		return
+	}
+
+	positions := make(map[string]*streamPosition, 0)
+	for _, v := range positionRes.Result.List {
+		if key, position := bybitStreamPositionOf(v.Symbol, bybitNetPositionIdx(v), v.Size, v.EntryPrice, v.UnrealisedPnl); nil != position {
+			positions[key] = position
+		}
+	}
+
+	if !s.setWallet(list[0].TotalWalletBalance, list[0].TotalAvailableBalance) {
+		return
+	}
+
+	s.mu.Lock()
+	s.positions = positions
+	s.syncedAt = time.Now()
+	s.mu.Unlock()
+}
+
+// setWallet 更新钱包余额和可用保证金
+func (s *bybitUserStream) setWallet(walletBalance string, availableBalance string) bool {
+	tmp, err := decimal.NewFromString(walletBalance)
+	if nil != err {
+		log.Println("bybit 私有数据流，钱包余额解析错误：", s.user.Id, walletBalance)
+		return false
+	}
+	setAvailableMoney(s.user.Id, availableBalance)
+
+	s.mu.Lock()
+	s.walletBalance = tmp
+	s.mu.Unlock()
+	return true
+}
+
+// bybitStreamPositionOf 仓位，positionIdx为折算后的1多2空，空仓数量为负，没有仓位时返回nil
+func bybitStreamPositionOf(symbol string, positionIdx int, size, entryPrice, unrealisedPnl string) (string, *streamPosition) {
+	amount, err := decimal.NewFromString(size)
+	if nil != err || amount.IsZero() {
+		return "", nil
+	}
+
+	var positionSide string
+	if 1 == positionIdx {
+		positionSide = "LONG"
+	} else if 2 == positionIdx {
+		positionSide = "SHORT"
+		amount = amount.Neg()
+	} else {
+		return "", nil
+	}
+
+	entry, _ := decimal.NewFromString(entryPrice)
+	unrealized, _ := decimal.NewFromString(unrealisedPnl)
+	return symbol + "&" + positionSide, &streamPosition{amount: amount, entry: entry, unrealized: unrealized}
+}
+
+// bybitExecution 成交推送
+type bybitExecution struct {
+	Category    string `json:"category"`
+	Symbol      string `json:"symbol"`
+	Side        string `json:"side"`
+	OrderId     string `json:"orderId"`
+	OrderLinkId string `json:"orderLinkId"`
+	OrderType   string `json:"orderType"`
+	ExecType    string `json:"execType"`
+	ExecQty     string `json:"execQty"`
+	ExecPrice   string `json:"execPrice"`
+	ExecFee     string `json:"execFee"`
+	ExecTime    string `json:"execTime"`
+	ClosedSize  string `json:"closedSize"`
+	OrderQty    string `json:"orderQty"`
+	LeavesQty   string `json:"leavesQty"`
+}
+
+// bybitStreamPosition 仓位推送
+type bybitStreamPosition struct {
+	Category      string `json:"category"`
+	Symbol        string `json:"symbol"`
+	Side          string `json:"side"`
+	Size          string `json:"size"`
+	PositionIdx   int    `json:"positionIdx"`
+	EntryPrice    string `json:"entryPrice"`
+	UnrealisedPnl string `json:"unrealisedPnl"`
+}
+
+// bybitStreamWallet 余额推送
+type bybitStreamWallet struct {
+	AccountType           string `json:"accountType"`
+	TotalWalletBalance    string `json:"totalWalletBalance"`
+	TotalAvailableBalance string `json:"totalAvailableBalance"`
+}
+
+// handle 处理推送
+func (s *bybitUserStream) handle(ctx context.Context, msg *bybitStreamMessage) error {
+	switch msg.Topic {
+	case "execution.linear":
+		var list []*bybitExecution
+		if err := json.Unmarshal(msg.Data, &list); nil != err {
+			return err
+		}
+
+		for _, v := range list {
+			s.handleExecution(ctx, v)
+		}
+	case "position.linear":
+		var list []*bybitStreamPosition
+		if err := json.Unmarshal(msg.Data, &list); nil != err {
+			return err
+		}
+
+		for _, v := range list {
+			s.handlePosition(v)
+		}
+	case "wallet":
+		var list []*bybitStreamWallet
+		if err := json.Unmarshal(msg.Data, &list); nil != err {
+			return err
+		}
+
+		for _, v := range list {
+			if "UNIFIED" == v.AccountType {
+				s.setWallet(v.TotalWalletBalance, v.TotalAvailableBalance)
+			}
+		}
+	case "":
+		// pong等操作回应
+		return nil
+	default:
+		return errors.New("未知推送")
+	}
+
+	return nil
+}
+
+// bybitExecSource 成交来源，系统下的单按orderLinkId识别
+func bybitExecSource(execution *bybitExecution, userId uint) string {
+	switch execution.ExecType {
+	case "BustTrade":
+		return "liquidation"
+	case "AdlTrade":
+		return "adl"
+	}
+
+	if link, err := parseBybitOrderLinkId(execution.OrderLinkId); nil == err && userId == link.UserId {
+		return "system"
+	}
+
+	return "manual"
+}
+
+// handleExecution 成交记入流水，非系统下单的平仓成交同步减少系统仓位。
+// 双向持仓下卖出平多，买入平空，平仓数量为closedSize
+func (s *bybitUserStream) handleExecution(ctx context.Context, execution *bybitExecution) {
+	if "Trade" != execution.ExecType && "BustTrade" != execution.ExecType && "AdlTrade" != execution.ExecType {
+		return
+	}
+
+	var (
+		source       = bybitExecSource(execution, s.user.Id)
+		closedSize   = decimal.Zero
+		positionSide = "LONG"
+	)
+	if tmp, err := decimal.NewFromString(execution.ClosedSize); nil == err {
+		closedSize = tmp
+	}
+
+	// 开仓买入为多，平仓卖出为多
+	if ("Sell" == execution.Side) != closedSize.IsPositive() {
+		positionSide = "SHORT"
+	}
+
+	if "system" != source && closedSize.IsPositive() {
+		key := execution.Symbol + "&" + positionSide + "&" + strconv.FormatUint(uint64(s.user.Id), 10)
+		if orderMap.Contains(key) {
+			log.Println("bybit 私有数据流，非系统平仓，减少系统仓位：", key, source, closedSize, subOrderQty(key, closedSize))
+		}
+	}
+
+	cumQty := decimal.Zero
+	orderQty, errOrderQty := decimal.NewFromString(execution.OrderQty)
+	leavesQty, errLeavesQty := decimal.NewFromString(execution.LeavesQty)
+	if nil == errOrderQty && nil == errLeavesQty {
+		cumQty = orderQty.Sub(leavesQty)
+
+		// 撤单失败仍在挂单的限价单，成交按累计数量计入系统仓位
+		applyOpenLimitFill(execution.OrderLinkId, cumQty, !leavesQty.IsPositive())
+	}
+
+	tradeTime, _ := strconv.ParseInt(execution.ExecTime, 10, 64)
+	_, err := g.Model("user_order_ledger").Ctx(ctx).Data(do.UserOrderLedger{
+		UserId:          s.user.Id,
+		Plat:            "bybit",
+		Symbol:          execution.Symbol,
+		PositionSide:    positionSide,
+		Side:            execution.Side,
+		OrderId:         execution.OrderId,
+		ClientOrderId:   execution.OrderLinkId,
+		Source:          source,
+		OrderType:       execution.OrderType,
+		ExecType:        execution.ExecType,
+		LastQty:         decimalOrZero(execution.ExecQty),
+		CumQty:          cumQty.String(),
+		LastPrice:       decimalOrZero(execution.ExecPrice),
+		AvgPrice:        "0",
+		RealizedPnl:     "0",
+		Commission:      decimalOrZero(execution.ExecFee),
+		CommissionAsset: "USDT",
+		TradeTime:       tradeTime,
+		CreatedAt:       gtime.Now(),
+	}).Insert()
+	if nil != err {
+		log.Println("bybit 私有数据流，成交流水记录失败：", s.user.Id, err)
+	}
+}
+
+// handlePosition 记录实际仓位。系统下单的返回和推送先后不定，这里不直接改系统仓位
+func (s *bybitUserStream) handlePosition(position *bybitStreamPosition) {
+	key, tmp := bybitStreamPositionOf(position.Symbol, bybitNetPositionIdx(&Position{PositionIdx: position.PositionIdx, Side: position.Side}), position.Size, position.EntryPrice, position.UnrealisedPnl)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if nil != tmp {
+		s.positions[key] = tmp
+		return
+	}
+
+	// 已平仓，单向持仓两个方向都清掉
+	if 2 != position.PositionIdx {
+		delete(s.positions, position.Symbol+"&LONG")
+	}
+	if 1 != position.PositionIdx {
+		delete(s.positions, position.Symbol+"&SHORT")
+	}
+}
+
+// decimalOrZero 数值字符串，解析失败为0
+func decimalOrZero(value string) string {
+	tmp, err := decimal.NewFromString(value)
+	if nil != err {
+		return "0"
+	}
+
+	return tmp.String()
+}
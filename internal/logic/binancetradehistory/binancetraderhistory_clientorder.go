@@ -0,0 +1,258 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gogf/gf/v2/container/gmap"
+	"github.com/gogf/gf/v2/container/gtype"
+	"hash/crc32"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	errOrderUnknown = errors.New("下单结果未知") // 超时等情况，订单可能已成交
+
+	orderIntentTtl        = time.Hour               // 下单意图保留时长
+	orderQueryDelay       = 1 * time.Second         // 结果未知时，等待后再查单
+	orderQueryTimes       = 3                       // 订单未终结时的查单次数
+	binanceOrderIntents   = gmap.NewStrAnyMap(true) // 已提交的下单意图，key为clientOrderId
+	binanceAmbiguousCodes = map[int64]bool{-1001: true, -1006: true, -1007: true}
+	lastOrderCycle        = gtype.NewInt64() // 上一次的下单轮次
+
+	binanceClientOrderIdMax    = 36 // binance clientOrderId最长36位
+	binanceClientOrderIdSuffix = 5  // 后缀最长5位：剩余市价-m或按实际持仓平仓-r，加36进制的拆单序号-zz
+)
+
+// orderIntent 一次下单意图的结果，订单号为0且unknown时结果未知
+type orderIntent struct {
+	res     *binanceOrder
+	unknown bool
+	at      time.Time
+}
+
+// orderCycle 一轮下单的标识，毫秒时间戳的36进制，同一毫秒内多次调用时顺延，保证单调递增
+func orderCycle(t time.Time) string {
+	for {
+		last := lastOrderCycle.Val()
+		cycle := t.UnixMilli()
+		if cycle <= last {
+			cycle = last + 1
+		}
+
+		if lastOrderCycle.Cas(last, cycle) {
+			return strconv.FormatInt(cycle, 36)
+		}
+	}
+}
+
+// binanceClientOrderId 按轮次、用户、币种、方向生成确定的clientOrderId，同一意图重复调用得到同一id
+func binanceClientOrderId(cycle string, userId uint, symbol string, positionSide string, side string) string {
+	direction := ""
+	if 0 < len(positionSide) && 0 < len(side) {
+		direction = positionSide[:1] + side[:1]
+	}
+
+	res := cycle + "_" + strconv.FormatUint(uint64(userId), 36) + "_" + symbol + "_" + direction
+
+	// 最长36位，还要留出后缀，过长时币种用校验码
+	if binanceClientOrderIdMax-binanceClientOrderIdSuffix < len(res) {
+		res = cycle + "_" + strconv.FormatUint(uint64(userId), 36) + "_" + strconv.FormatUint(uint64(crc32.ChecksumIEEE([]byte(symbol))), 36) + "_" + direction
+	}
+
+	return res
+}
+
+// pruneOrderIntents 清理过期的下单意图
+func pruneOrderIntents(now time.Time) {
+	expired := make([]string, 0)
+	binanceOrderIntents.Iterator(func(k string, v interface{}) bool {
+		if now.Sub(v.(*orderIntent).at) > orderIntentTtl {
+			expired = append(expired, k)
+		}
+		return true
+	})
+
+	binanceOrderIntents.Removes(expired)
+}
+
+// isOrderAmbiguous 下单是否结果未知
+func isOrderAmbiguous(resOrderInfo *orderInfo, err error) bool {
+	if nil != err {
+		return errors.Is(err, errOrderUnknown)
+	}
+
+	return nil != resOrderInfo && binanceAmbiguousCodes[resOrderInfo.Code]
+}
+
+// requestBinanceOrderIdempotent 按clientOrderId下单，同一意图不重复提交，结果未知时先查单，确认不存在才重试一次
+func requestBinanceOrderIdempotent(symbol string, side string, orderType string, positionSide string, quantity string, clientOrderId string, apiKey string, secretKey string) (*binanceOrder, *orderInfo, error) {
+	now := time.Now()
+	pruneOrderIntents(now)
+
+	// 已提交过的意图不再提交
+	if tmp := binanceOrderIntents.Get(clientOrderId); nil != tmp {
+		intent := tmp.(*orderIntent)
+		if intent.unknown {
+			log.Println("下单意图结果未知，不重复下单：", clientOrderId)
+			return &binanceOrder{}, &orderInfo{Msg: errOrderUnknown.Error()}, errOrderUnknown
+		}
+
+		log.Println("下单意图已提交，不重复下单：", clientOrderId, intent.res.OrderId)
+		return intent.res, nil, nil
+	}
+
+	var (
+		res          *binanceOrder
+		resOrderInfo *orderInfo
+		err          error
+	)
+	for i := 0; i < 2; i++ {
+		res, resOrderInfo, err = requestBinanceOrder(symbol, side, orderType, positionSide, quantity, clientOrderId, apiKey, secretKey)
+		if !isOrderAmbiguous(resOrderInfo, err) {
+			break
+		}
+
+		log.Println("下单结果未知，查单：", clientOrderId, err, resOrderInfo)
+		var (
+			queryRes  *binanceOrder
+			queryInfo *orderInfo
+			queryErr  error
+		)
+		for j := 0; j < orderQueryTimes; j++ {
+			time.Sleep(orderQueryDelay)
+			queryRes, queryInfo, queryErr = requestBinanceQueryOrder(symbol, clientOrderId, apiKey, secretKey)
+			if nil != queryErr || nil == queryRes || 0 >= queryRes.OrderId || "NEW" != queryRes.Status {
+				break
+			}
+		}
+
+		// 订单已存在，按查到的结果返回
+		if nil == queryErr && nil != queryRes && 0 < queryRes.OrderId {
+			log.Println("下单结果未知，查单已存在：", clientOrderId, queryRes.OrderId, queryRes.Status, queryRes.ExecutedQty)
+			res, resOrderInfo, err = queryRes, nil, nil
+			break
+		}
+
+		// 确认订单不存在，重试一次
+		if nil == queryErr && nil != queryInfo && -2013 == queryInfo.Code {
+			log.Println("下单结果未知，查单不存在，重试：", clientOrderId)
+			continue
+		}
+
+		// 查单失败，不能确认是否成交，不再下单
+		log.Println("下单结果未知，查单失败：", clientOrderId, queryErr, queryInfo)
+		binanceOrderIntents.Set(clientOrderId, &orderIntent{res: &binanceOrder{}, unknown: true, at: now})
+		return &binanceOrder{}, &orderInfo{Msg: errOrderUnknown.Error()}, errOrderUnknown
+	}
+
+	if isOrderAmbiguous(resOrderInfo, err) {
+		log.Println("下单结果未知，重试后仍未知：", clientOrderId, err, resOrderInfo)
+		binanceOrderIntents.Set(clientOrderId, &orderIntent{res: &binanceOrder{}, unknown: true, at: now})
+		return &binanceOrder{}, &orderInfo{Msg: errOrderUnknown.Error()}, errOrderUnknown
+	}
+
+	if nil == res {
+		res = &binanceOrder{}
+	}
+
+	if nil == err && 0 < res.OrderId {
+		binanceOrderIntents.Set(clientOrderId, &orderIntent{res: res, at: now})
+	}
+
+	return res, resOrderInfo, err
+}
+
+// binanceQueryOrder 查单结果
+type binanceQueryOrder struct {
+	OrderId       int64  `json:"orderId"`
+	ClientOrderId string `json:"clientOrderId"`
+	Symbol        string `json:"symbol"`
+	Status        string `json:"status"`
+	ExecutedQty   string `json:"executedQty"`
+	AvgPrice      string `json:"avgPrice"`
+	CumQuote      string `json:"cumQuote"`
+	Side          string `json:"side"`
+	PositionSide  string `json:"positionSide"`
+	ClosePosition bool   `json:"closePosition"`
+	Type          string `json:"type"`
+}
+
+// requestBinanceQueryOrder 按clientOrderId查单，不存在时code为-2013
+func requestBinanceQueryOrder(symbol string, clientOrderId string, apiKey string, secretKey string) (*binanceOrder, *orderInfo, error) {
+	return requestBinanceClientOrder("GET", symbol, clientOrderId, apiKey, secretKey)
+}
+
+// requestBinanceClientOrder 按clientOrderId查单或撤单，返回订单当前状态
+func requestBinanceClientOrder(method string, symbol string, clientOrderId string, apiKey string, secretKey string) (*binanceOrder, *orderInfo, error) {
+	err := acquireBinance(apiKey, 1, false, false)
+	if nil != err {
+		return &binanceOrder{}, &orderInfo{Msg: err.Error()}, err
+	}
+
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("origClientOrderId", clientOrderId)
+	params.Set("timestamp", strconv.FormatInt(time.Now().UTC().UnixMilli(), 10))
+
+	req, err := http.NewRequest(method, "https://fapi.binance.com/fapi/v1/order?"+params.Encode()+"&signature="+generateSignature(secretKey, params), nil)
+	if nil != err {
+		return &binanceOrder{}, &orderInfo{Msg: err.Error()}, err
+	}
+	req.Header.Set("X-MBX-APIKEY", apiKey)
+
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Do(req)
+	if nil != err {
+		return &binanceOrder{}, &orderInfo{Msg: err.Error()}, err
+	}
+	updateBinanceRate(apiKey, resp)
+
+	defer func() {
+		if resp != nil && resp.Body != nil {
+			err := resp.Body.Close()
+			if err != nil {
+				log.Println("关闭响应体错误：", err)
+			}
+		}
+	}()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if nil != err {
+		return &binanceOrder{}, &orderInfo{Msg: err.Error()}, err
+	}
+
+	var o *binanceQueryOrder
+	err = json.Unmarshal(b, &o)
+	if nil != err {
+		return &binanceOrder{}, &orderInfo{Msg: err.Error()}, err
+	}
+
+	if nil == o || 0 >= o.OrderId {
+		var resOrderInfo *orderInfo
+		err = json.Unmarshal(b, &resOrderInfo)
+		if nil != err {
+			return &binanceOrder{}, &orderInfo{Msg: err.Error()}, err
+		}
+
+		return &binanceOrder{}, resOrderInfo, nil
+	}
+
+	return &binanceOrder{
+		OrderId:       o.OrderId,
+		ExecutedQty:   o.ExecutedQty,
+		ClientOrderId: o.ClientOrderId,
+		Symbol:        o.Symbol,
+		AvgPrice:      o.AvgPrice,
+		CumQuote:      o.CumQuote,
+		Side:          o.Side,
+		PositionSide:  o.PositionSide,
+		ClosePosition: o.ClosePosition,
+		Type:          o.Type,
+		Status:        o.Status,
+	}, nil, nil
+}
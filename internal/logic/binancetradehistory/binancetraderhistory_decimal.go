@@ -0,0 +1,176 @@
+package logic
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+var (
+	moneyChangeRatio = decimal.NewFromFloat(0.005) // 保证金相对变化超过该比例才更新
+)
+
+// orderQty 系统仓位数量，不存在时为0
+func orderQty(key string) decimal.Decimal {
+	if v := orderMap.Get(key); nil != v {
+		return v.(decimal.Decimal)
+	}
+
+	return decimal.Zero
+}
+
+// orderTmpQty 预备仓位数量，不存在时为0
+func orderTmpQty(key string) decimal.Decimal {
+	if v := orderMapTmp.Get(key); nil != v {
+		return v.(decimal.Decimal)
+	}
+
+	return decimal.Zero
+}
+
+// addOrderQty 系统仓位增加，返回增加后的仓位
+func addOrderQty(key string, qty decimal.Decimal) decimal.Decimal {
+	var res decimal.Decimal
+	orderMap.LockFunc(func(m map[interface{}]interface{}) {
+		if v, ok := m[key]; ok {
+			res = v.(decimal.Decimal)
+		}
+
+		res = res.Add(qty)
+		m[key] = res
+	})
+
+	return res
+}
+
+// subOrderQty 系统仓位减少，不小于0，返回减少后的仓位
+func subOrderQty(key string, qty decimal.Decimal) decimal.Decimal {
+	var res decimal.Decimal
+	orderMap.LockFunc(func(m map[interface{}]interface{}) {
+		if v, ok := m[key]; ok {
+			res = v.(decimal.Decimal)
+		}
+
+		res = res.Sub(qty)
+		if res.IsNegative() {
+			res = decimal.Zero
+		}
+		m[key] = res
+	})
+
+	return res
+}
+
+// setOrderQty 按实际持仓校正系统仓位
+func setOrderQty(key string, qty decimal.Decimal) {
+	if qty.IsNegative() {
+		qty = decimal.Zero
+	}
+
+	orderMap.Set(key, qty)
+}
+
+// traderBaseMoney 带单员保证金
+func traderBaseMoney() decimal.Decimal {
+	if v, ok := baseMoneyGuiTu.Val().(decimal.Decimal); ok {
+		return v
+	}
+
+	return decimal.Zero
+}
+
+// userBaseMoney 用户保证金，已乘以num
+func userBaseMoney(userId uint) (decimal.Decimal, bool) {
+	if v := baseMoneyUserAllMap.Get(int(userId)); nil != v {
+		return v.(decimal.Decimal), true
+	}
+
+	return decimal.Zero, false
+}
+
+// moneyChanged 保证金相对变化是否超过比例，原保证金不为正时有变化就更新
+func moneyChanged(newMoney, oldMoney decimal.Decimal, ratio decimal.Decimal) bool {
+	if !oldMoney.IsPositive() {
+		return !newMoney.Equal(oldMoney)
+	}
+
+	return newMoney.Sub(oldMoney).Abs().Div(oldMoney).GreaterThan(ratio)
+}
+
+// sizingQty 跟单数量，带单员币的数量 * (用户保证金/带单员保证金)
+func sizingQty(traderQty decimal.Decimal, userMoney decimal.Decimal, traderMoney decimal.Decimal) decimal.Decimal {
+	if !traderMoney.IsPositive() {
+		return decimal.Zero
+	}
+
+	return traderQty.Abs().Mul(userMoney).Div(traderMoney)
+}
+
+// symbolQtyStep 下单数量步长，binance优先用市价单步长，没有时按数量精度
+func symbolQtyStep(plat string, symbol string) decimal.Decimal {
+	if "binance" == plat {
+		if !symbolsMap.Contains(symbol) {
+			return decimal.Zero
+		}
+
+		tmpSymbol := symbolsMap.Get(symbol).(*LhCoinSymbol)
+		if 0 < tmpSymbol.MarketStepSize {
+			return decimal.NewFromFloat(tmpSymbol.MarketStepSize)
+		}
+
+		if 0 < tmpSymbol.StepSize {
+			return decimal.NewFromFloat(tmpSymbol.StepSize)
+		}
+
+		return decimal.New(1, -int32(tmpSymbol.QuantityPrecision))
+	} else if "bybit" == plat {
+		if !symbolsBybitMap.Contains(symbol) {
+			return decimal.Zero
+		}
+
+		return decimal.NewFromFloat(symbolsBybitMap.Get(symbol).(*BybitSymbol).QtyStep)
+	}
+
+	return decimal.Zero
+}
+
+// qtyPlaces 步长的小数位数
+func qtyPlaces(step decimal.Decimal) int32 {
+	if 0 > step.Exponent() {
+		return -step.Exponent()
+	}
+
+	return 0
+}
+
+// roundOpenQty 开仓数量向下取整到步长，不超过跟单比例
+func roundOpenQty(plat string, symbol string, qty decimal.Decimal) decimal.Decimal {
+	step := symbolQtyStep(plat, symbol)
+	if !step.IsPositive() {
+		return decimal.Zero
+	}
+
+	return qty.Div(step).Floor().Mul(step)
+}
+
+// roundCloseQty 平仓数量，达到持有数量时按持有数量全平，部分平仓四舍五入到步长且不超过持有数量
+func roundCloseQty(plat string, symbol string, qty decimal.Decimal, held decimal.Decimal) decimal.Decimal {
+	step := symbolQtyStep(plat, symbol)
+	if !step.IsPositive() {
+		return decimal.Zero
+	}
+
+	if qty.GreaterThanOrEqual(held) {
+		return held.Truncate(qtyPlaces(step))
+	}
+
+	res := qty.Div(step).Round(0).Mul(step)
+	if res.GreaterThan(held) {
+		return held.Truncate(qtyPlaces(step))
+	}
+
+	return res
+}
+
+// formatQty 下单数量字符串，小数位数和步长一致
+func formatQty(plat string, symbol string, qty decimal.Decimal) string {
+	return qty.StringFixed(qtyPlaces(symbolQtyStep(plat, symbol)))
+}
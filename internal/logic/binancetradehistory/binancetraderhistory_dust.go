@@ -0,0 +1,240 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/do"
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"errors"
+	"github.com/gogf/gf/v2/container/gtype"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/shopspring/decimal"
+	"log"
+	"strconv"
+	"time"
+)
+
+const orderSourceDust = "d" // 碎仓清理
+
+var (
+	dustSweepConfig = gtype.NewAny(defaultDustSweepConfig()) // 碎仓清理配置
+	traderPositions = gtype.NewAny()                         // 带单员仓位快照，key为symbol&positionSide，单向持仓拆成多空
+)
+
+func defaultDustSweepConfig() *entity.DustSweepConfig {
+	return &entity.DustSweepConfig{
+		Enabled:     1,
+		MaxNotional: 5,
+	}
+}
+
+// loadDustSweepConfig 加载碎仓清理配置，没有配置时用默认值
+func loadDustSweepConfig(ctx context.Context) {
+	var (
+		err     error
+		configs []*entity.DustSweepConfig
+	)
+
+	err = g.Model("dust_sweep_config").Ctx(ctx).OrderAsc("id").Limit(1).Scan(&configs)
+	if nil != err {
+		log.Println("碎仓清理配置，数据库查询错误：", err)
+		return
+	}
+
+	if 0 < len(configs) {
+		dustSweepConfig.Set(configs[0])
+	} else {
+		dustSweepConfig.Set(defaultDustSweepConfig())
+	}
+}
+
+// publishTraderPositions 同步订单每轮更新后发布带单员仓位，供其他协程读取
+func publishTraderPositions(positions map[string]*TraderPosition) {
+	res := make(map[string]float64, 0)
+	for _, v := range positions {
+		if "BOTH" != v.PositionSide {
+			res[v.Symbol+"&"+v.PositionSide] += v.PositionAmount
+			continue
+		}
+
+		if 0 < v.PositionAmount {
+			res[v.Symbol+"&LONG"] += v.PositionAmount
+		} else if 0 > v.PositionAmount {
+			res[v.Symbol+"&SHORT"] -= v.PositionAmount
+		}
+	}
+
+	traderPositions.Set(res)
+}
+
+// traderPositionClosed 带单员该币种方向是否已完全平仓，还没拉到仓位时返回false
+func traderPositionClosed(symbol string, positionSide string) bool {
+	v := traderPositions.Val()
+	if nil == v {
+		return false
+	}
+
+	return lessThanOrEqual(v.(map[string]float64)[symbol+"&"+positionSide], 0)
+}
+
+// dustPosition 用户的一个实际持仓
+type dustPosition struct {
+	symbol       string
+	positionSide string
+	qty          decimal.Decimal
+	entryPrice   decimal.Decimal
+}
+
+// userLivePositions 用户所有不为0的实际持仓
+func userLivePositions(ctx context.Context, user *entity.User) ([]*dustPosition, error) {
+	res := make([]*dustPosition, 0)
+	if "binance" == user.Plat {
+		positions := getBinancePositionInfo(user.ApiKey, user.ApiSecret)
+		if nil == positions {
+			return nil, errors.New("查询仓位失败")
+		}
+
+		for _, v := range positions {
+			qty, err := decimal.NewFromString(v.PositionAmt)
+			if nil != err || qty.IsZero() {
+				continue
+			}
+
+			entryPrice, _ := decimal.NewFromString(v.EntryPrice)
+			res = append(res, &dustPosition{symbol: v.Symbol, positionSide: netPositionSide(v.PositionSide, qty), qty: qty.Abs(), entryPrice: entryPrice})
+		}
+	} else if "bybit" == user.Plat {
+		positionRes, err := bybitGetPositionInfo(ctx, user.ApiKey, user.ApiSecret)
+		if nil != err {
+			return nil, err
+		}
+
+		if nil == positionRes || 0 != positionRes.RetCode || nil == positionRes.Result {
+			return nil, errors.New("查询仓位失败")
+		}
+
+		for _, v := range positionRes.Result.List {
+			qty, err := decimal.NewFromString(v.Size)
+			if nil != err || qty.IsZero() {
+				continue
+			}
+
+			var positionSide string
+			if positionIdx := bybitNetPositionIdx(v); 1 == positionIdx {
+				positionSide = "LONG"
+			} else if 2 == positionIdx {
+				positionSide = "SHORT"
+			} else {
+				continue
+			}
+
+			entryPrice, _ := decimal.NewFromString(v.EntryPrice)
+			res = append(res, &dustPosition{symbol: v.Symbol, positionSide: positionSide, qty: qty.Abs(), entryPrice: entryPrice})
+		}
+	} else {
+		return nil, errors.New("平台错误")
+	}
+
+	return res, nil
+}
+
+// SweepDust close leftover position fragments of followers after the trader fully closed the symbol and side
+func (s *sBinanceTraderHistory) SweepDust(ctx context.Context) {
+	config := dustSweepConfig.Val().(*entity.DustSweepConfig)
+	if 1 != config.Enabled || 0 >= config.MaxNotional {
+		return
+	}
+
+	maxNotional := decimal.NewFromFloat(config.MaxNotional)
+	users := make([]*entity.User, 0)
+	globalUsers.Iterator(func(k interface{}, v interface{}) bool {
+		users = append(users, v.(*entity.User))
+		return true
+	})
+
+	for _, vUser := range users {
+		if nil != ctx.Err() {
+			return
+		}
+
+		positions, err := userLivePositions(ctx, vUser)
+		if nil != err {
+			log.Println("碎仓清理，查询仓位失败：", err, vUser.Id)
+			continue
+		}
+
+		strUserId := strconv.FormatUint(uint64(vUser.Id), 10)
+		for _, vPosition := range positions {
+			key := vPosition.symbol + "&" + vPosition.positionSide + "&" + strUserId
+
+			// 只清理系统跟过的仓位，带单员已完全平仓，系统仓位也已平完
+			if !orderMap.Contains(key) || orderQty(key).IsPositive() || !traderPositionClosed(vPosition.symbol, vPosition.positionSide) {
+				continue
+			}
+
+			price := vPosition.entryPrice
+			if markPrice := getMarkPrice(vPosition.symbol); 0 < markPrice {
+				price = decimal.NewFromFloat(markPrice)
+			}
+
+			notional := vPosition.qty.Mul(price)
+			if notional.GreaterThanOrEqual(maxNotional) {
+				continue
+			}
+
+			log.Println("碎仓清理，平仓：", vUser.Id, vPosition.symbol, vPosition.positionSide, vPosition.qty, notional)
+			remaining, ok := closeLivePosition(ctx, vUser, vPosition.symbol, vPosition.positionSide, orderCycle(time.Now()), orderSourceDust, vPosition.qty)
+			if !ok {
+				continue
+			}
+
+			if remaining.IsPositive() {
+				log.Println("碎仓清理，未能平完：", vUser.Id, vPosition.symbol, vPosition.positionSide, remaining)
+				continue
+			}
+
+			orderMapTmp.Set(key, decimal.Zero)
+			log.Println("碎仓清理，完成：", vUser.Id, vPosition.symbol, vPosition.positionSide)
+		}
+	}
+}
+
+// GetDustSweepConfig get dust sweep config
+func (s *sBinanceTraderHistory) GetDustSweepConfig(ctx context.Context) *entity.DustSweepConfig {
+	return dustSweepConfig.Val().(*entity.DustSweepConfig)
+}
+
+// SetDustSweepConfig enable or disable dust sweep and set the max notional of a fragment
+func (s *sBinanceTraderHistory) SetDustSweepConfig(ctx context.Context, enabled int, maxNotional float64) error {
+	if 0 > maxNotional {
+		return errors.New("名义价值不能为负数")
+	}
+
+	var (
+		err     error
+		configs []*entity.DustSweepConfig
+	)
+	err = g.Model("dust_sweep_config").Ctx(ctx).OrderAsc("id").Limit(1).Scan(&configs)
+	if nil != err {
+		return err
+	}
+
+	data := &do.DustSweepConfig{
+		Enabled:     enabled,
+		MaxNotional: maxNotional,
+		UpdatedAt:   gtime.Now(),
+	}
+	if 0 < len(configs) {
+		_, err = g.Model("dust_sweep_config").Ctx(ctx).Data(data).Where("id=?", configs[0].Id).Update()
+	} else {
+		_, err = g.Model("dust_sweep_config").Ctx(ctx).Insert(data)
+	}
+	if nil != err {
+		log.Println("设置碎仓清理配置失败：", err)
+		return err
+	}
+
+	// 立即生效
+	loadDustSweepConfig(ctx)
+	return nil
+}
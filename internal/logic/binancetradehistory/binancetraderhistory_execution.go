@@ -0,0 +1,613 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/do"
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	bybit "github.com/bybit-exchange/bybit.go.api"
+	"github.com/gogf/gf/v2/container/gtype"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/shopspring/decimal"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 下单方式
+const (
+	executionMarket = "market" // 市价
+	executionLimit  = "limit"  // 先挂限价单，超时撤单后剩余市价
+)
+
+var (
+	executionPolicies     = gtype.NewAny(make(map[string]*entity.ExecutionPolicy, 0)) // 下单方式，key为userId&symbol，0和空表示所有
+	executionPollInterval = 200 * time.Millisecond                                    // 限价单查单间隔
+	executionMaxWaitMs    = 1000                                                      // 限价单最长等待，等待时阻塞本轮跟单，远小于带单员轮询间隔
+)
+
+// loadExecutionPolicies 加载下单方式配置
+func loadExecutionPolicies(ctx context.Context) {
+	var (
+		err      error
+		policies []*entity.ExecutionPolicy
+	)
+
+	err = g.Model("execution_policy").Ctx(ctx).Scan(&policies)
+	if nil != err {
+		log.Println("下单方式配置，数据库查询错误：", err)
+		return
+	}
+
+	tmpPolicies := make(map[string]*entity.ExecutionPolicy, 0)
+	for _, v := range policies {
+		tmpPolicies[strconv.FormatUint(uint64(v.UserId), 10)+"&"+v.Symbol] = v
+	}
+
+	executionPolicies.Set(tmpPolicies)
+}
+
+// executionPolicyOf 用户币种的下单方式，用户加币种，用户，币种，全局依次匹配，没有配置或市价时返回nil
+func executionPolicyOf(userId uint, symbol string) *entity.ExecutionPolicy {
+	var (
+		policies  = executionPolicies.Val().(map[string]*entity.ExecutionPolicy)
+		strUserId = strconv.FormatUint(uint64(userId), 10)
+	)
+	for _, vKey := range []string{strUserId + "&" + symbol, strUserId + "&", "0&" + symbol, "0&"} {
+		if v, ok := policies[vKey]; ok {
+			if executionLimit != v.Mode || 0 >= v.WaitMs {
+				return nil
+			}
+
+			return v
+		}
+	}
+
+	return nil
+}
+
+// executionWait 限价单等待时长，超过上限的旧配置按上限
+func executionWait(policy *entity.ExecutionPolicy) time.Duration {
+	waitMs := policy.WaitMs
+	if executionMaxWaitMs < waitMs {
+		waitMs = executionMaxWaitMs
+	}
+
+	return time.Duration(waitMs) * time.Millisecond
+}
+
+// limitPrice 按买一卖一和偏移计算限价，买单向下、卖单向上取整到价格步长，没有步长时返回false
+func limitPrice(side string, bid decimal.Decimal, ask decimal.Decimal, offsetBps float64, tickSize float64) (string, bool) {
+	if 0 >= tickSize || !bid.IsPositive() || !ask.IsPositive() {
+		return "", false
+	}
+
+	var (
+		tick   = decimal.NewFromFloat(tickSize)
+		offset = decimal.NewFromFloat(offsetBps).Div(decimal.NewFromInt(10000))
+		price  decimal.Decimal
+	)
+	if "BUY" == strings.ToUpper(side) {
+		price = bid.Mul(decimal.NewFromInt(1).Add(offset)).Div(tick).Floor().Mul(tick)
+	} else {
+		price = ask.Mul(decimal.NewFromInt(1).Sub(offset)).Div(tick).Ceil().Mul(tick)
+	}
+
+	if !price.IsPositive() {
+		return "", false
+	}
+
+	places := int32(0)
+	if 0 > tick.Exponent() {
+		places = -tick.Exponent()
+	}
+
+	return price.StringFixed(places), true
+}
+
+// requestBinanceBookTicker 查询binance买一卖一
+func requestBinanceBookTicker(apiKey string, symbol string) (decimal.Decimal, decimal.Decimal, error) {
+	if err := acquireBinance(apiKey, 2, false, false); nil != err {
+		return decimal.Zero, decimal.Zero, err
+	}
+
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get("https://fapi.binance.com/fapi/v1/ticker/bookTicker?symbol=" + symbol)
+	if nil != err {
+		return decimal.Zero, decimal.Zero, err
+	}
+	updateBinanceRate(apiKey, resp)
+
+	defer func() {
+		if resp != nil && resp.Body != nil {
+			err := resp.Body.Close()
+			if err != nil {
+				log.Println("关闭响应体错误：", err)
+			}
+		}
+	}()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if nil != err {
+		return decimal.Zero, decimal.Zero, err
+	}
+
+	var res struct {
+		BidPrice string `json:"bidPrice"`
+		AskPrice string `json:"askPrice"`
+		Msg      string `json:"msg"`
+	}
+	if err = json.Unmarshal(b, &res); nil != err {
+		return decimal.Zero, decimal.Zero, err
+	}
+
+	bid, err := decimal.NewFromString(res.BidPrice)
+	if nil != err {
+		return decimal.Zero, decimal.Zero, errors.New("买一价格错误：" + res.Msg)
+	}
+
+	ask, err := decimal.NewFromString(res.AskPrice)
+	if nil != err {
+		return decimal.Zero, decimal.Zero, errors.New("卖一价格错误：" + res.Msg)
+	}
+
+	return bid, ask, nil
+}
+
+// bybitBookTicker 查询bybit买一卖一
+func bybitBookTicker(ctx context.Context, apiK string, symbol string) (decimal.Decimal, decimal.Decimal, error) {
+	if err := acquireBybit(apiK, false); nil != err {
+		return decimal.Zero, decimal.Zero, err
+	}
+
+	client := bybit.NewBybitHttpClient("", "", bybit.WithBaseURL(bybit.MAINNET))
+	resp, err := client.NewUtaBybitServiceWithParams(map[string]interface{}{
+		"category": "linear",
+		"symbol":   symbol,
+	}).GetMarketTickers(ctx)
+	if nil != err {
+		return decimal.Zero, decimal.Zero, err
+	}
+
+	var (
+		raw       []byte
+		tickerRes struct {
+			RetCode int    `json:"retCode"`
+			RetMsg  string `json:"retMsg"`
+			Result  struct {
+				List []struct {
+					Bid1Price string `json:"bid1Price"`
+					Ask1Price string `json:"ask1Price"`
+				} `json:"list"`
+			} `json:"result"`
+		}
+	)
+	raw, err = json.Marshal(resp)
+	if nil != err {
+		return decimal.Zero, decimal.Zero, err
+	}
+
+	if err = json.Unmarshal(raw, &tickerRes); nil != err {
+		return decimal.Zero, decimal.Zero, err
+	}
+
+	if 0 != tickerRes.RetCode || 0 >= len(tickerRes.Result.List) {
+		return decimal.Zero, decimal.Zero, errors.New("查询买一卖一失败：" + tickerRes.RetMsg)
+	}
+
+	bid, err := decimal.NewFromString(tickerRes.Result.List[0].Bid1Price)
+	if nil != err {
+		return decimal.Zero, decimal.Zero, err
+	}
+
+	ask, err := decimal.NewFromString(tickerRes.Result.List[0].Ask1Price)
+	if nil != err {
+		return decimal.Zero, decimal.Zero, err
+	}
+
+	return bid, ask, nil
+}
+
+// placeBinanceOrder 按用户币种的下单方式下单。限价时先挂单等待成交，超时撤单后剩余数量市价下单，
+// 返回的placedQty为限价成交和市价下单的合计，都没有成交时按失败返回。挂单失败或不能挂单时全部市价，平仓始终市价
+func placeBinanceOrder(user *entity.User, symbol string, side string, positionSide string, quantity string, clientOrderId string) (*binanceOrder, *orderInfo, error) {
+	policy := executionPolicyOf(user.Id, symbol)
+	if nil == policy || isCloseOrder(side, positionSide) || !symbolsMap.Contains(symbol) {
+		return requestBinanceOrderSplit(symbol, side, "MARKET", positionSide, quantity, clientOrderId, user.ApiKey, user.ApiSecret)
+	}
+
+	tmpSymbol := symbolsMap.Get(symbol).(*LhCoinSymbol)
+	total, err := decimal.NewFromString(quantity)
+	if nil != err || (0 < tmpSymbol.MaxQty && total.GreaterThan(decimal.NewFromFloat(tmpSymbol.MaxQty))) {
+		return requestBinanceOrderSplit(symbol, side, "MARKET", positionSide, quantity, clientOrderId, user.ApiKey, user.ApiSecret)
+	}
+
+	bid, ask, err := requestBinanceBookTicker(user.ApiKey, symbol)
+	if nil != err {
+		log.Println("限价下单，查询买一卖一失败，市价：", symbol, err)
+		return requestBinanceOrderSplit(symbol, side, "MARKET", positionSide, quantity, clientOrderId, user.ApiKey, user.ApiSecret)
+	}
+
+	price, ok := limitPrice(side, bid, ask, policy.OffsetBps, tmpSymbol.TickSize)
+	if !ok {
+		return requestBinanceOrderSplit(symbol, side, "MARKET", positionSide, quantity, clientOrderId, user.ApiKey, user.ApiSecret)
+	}
+
+	res, resOrderInfo, err := requestBinanceOrderPrice(symbol, side, "LIMIT", positionSide, quantity, price, clientOrderId, user.ApiKey, user.ApiSecret)
+	if isOrderAmbiguous(resOrderInfo, err) {
+		// 结果未知，查到了按挂单继续，确认不存在时市价，查不到不再下单
+		time.Sleep(orderQueryDelay)
+		var queryInfo *orderInfo
+		res, queryInfo, err = requestBinanceQueryOrder(symbol, clientOrderId, user.ApiKey, user.ApiSecret)
+		if nil != err || nil == res || (0 >= res.OrderId && (nil == queryInfo || -2013 != queryInfo.Code)) {
+			log.Println("限价下单，结果未知，查单失败：", clientOrderId, err, queryInfo)
+			return &binanceOrder{}, &orderInfo{Msg: errOrderUnknown.Error()}, errOrderUnknown
+		}
+	}
+
+	if nil == res || 0 >= res.OrderId {
+		log.Println("限价下单失败，市价：", clientOrderId, price, err, resOrderInfo)
+		return requestBinanceOrderSplit(symbol, side, "MARKET", positionSide, quantity, clientOrderId+"-m", user.ApiKey, user.ApiSecret)
+	}
+
+	filled, final := waitBinanceLimitOrder(user, symbol, clientOrderId, res, executionWait(policy))
+	log.Println("限价下单，等待结束：", clientOrderId, price, quantity, filled, final)
+	if !final {
+		// 撤单失败仍在挂单，之后的成交由跟踪计入系统仓位
+		trackOpenLimitOrder(clientOrderId, symbol+"&"+positionSide+"&"+strconv.FormatUint(uint64(user.Id), 10), filled)
+		followOpenLimitOrder(clientOrderId, func() (decimal.Decimal, bool, bool) {
+			order := cancelBinanceLimitOrder(user, symbol, clientOrderId)
+			if nil == order {
+				return decimal.Zero, false, false
+			}
+
+			return binanceExecutedQty(order), binanceOrderFinal(order.Status), true
+		})
+	}
+
+	placed := filled
+	remaining := total.Sub(filled)
+	if final && remaining.IsPositive() {
+		var (
+			marketRes  *binanceOrder
+			marketInfo *orderInfo
+		)
+		marketRes, marketInfo, err = requestBinanceOrderSplit(symbol, side, "MARKET", positionSide, formatQty("binance", symbol, remaining), clientOrderId+"-m", user.ApiKey, user.ApiSecret)
+		if nil != marketRes && 0 < marketRes.OrderId {
+			// 拆单部分结果未知时按已确认的数量计入
+			if nil != err {
+				log.Println("限价下单，剩余市价部分结果未知：", clientOrderId, remaining, marketRes.placedQty, err)
+			}
+			if marketRes.placedQty.IsPositive() {
+				remaining = marketRes.placedQty
+			}
+			placed = placed.Add(remaining)
+		} else if !filled.IsPositive() {
+			// 限价没有成交，按市价的结果返回
+			return marketRes, marketInfo, err
+		} else {
+			log.Println("限价下单，剩余市价失败：", clientOrderId, remaining, err, marketInfo)
+		}
+	}
+
+	if !placed.IsPositive() {
+		return &binanceOrder{}, &orderInfo{Msg: "限价单未成交"}, nil
+	}
+
+	res.placedQty = placed
+	return res, nil, nil
+}
+
+// waitBinanceLimitOrder 等待限价单成交，超时撤单，返回成交数量和订单是否已终结，未终结时不能再市价下单
+func waitBinanceLimitOrder(user *entity.User, symbol string, clientOrderId string, order *binanceOrder, wait time.Duration) (decimal.Decimal, bool) {
+	deadline := time.Now().Add(wait)
+	for !binanceOrderFinal(order.Status) && time.Now().Before(deadline) {
+		time.Sleep(executionPollInterval)
+
+		tmpOrder, _, err := requestBinanceQueryOrder(symbol, clientOrderId, user.ApiKey, user.ApiSecret)
+		if nil == err && nil != tmpOrder && 0 < tmpOrder.OrderId {
+			order = tmpOrder
+		}
+	}
+
+	// 撤单失败时重试，仍未终结的由调用方跟踪
+	for i := 0; i < limitCancelTimes && !binanceOrderFinal(order.Status); i++ {
+		if 0 < i {
+			time.Sleep(executionPollInterval)
+		}
+
+		if tmpOrder := cancelBinanceLimitOrder(user, symbol, clientOrderId); nil != tmpOrder {
+			order = tmpOrder
+		}
+	}
+
+	return binanceExecutedQty(order), binanceOrderFinal(order.Status)
+}
+
+// cancelBinanceLimitOrder 撤单，撤单失败时可能刚好成交了，再查一次，返回订单最新状态，都失败时返回nil
+func cancelBinanceLimitOrder(user *entity.User, symbol string, clientOrderId string) *binanceOrder {
+	cancelOrder, cancelInfo, err := requestBinanceClientOrder("DELETE", symbol, clientOrderId, user.ApiKey, user.ApiSecret)
+	if nil == err && nil != cancelOrder && 0 < cancelOrder.OrderId {
+		return cancelOrder
+	}
+
+	log.Println("限价下单，撤单失败：", clientOrderId, err, cancelInfo)
+	tmpOrder, _, tmpErr := requestBinanceQueryOrder(symbol, clientOrderId, user.ApiKey, user.ApiSecret)
+	if nil == tmpErr && nil != tmpOrder && 0 < tmpOrder.OrderId {
+		return tmpOrder
+	}
+
+	return nil
+}
+
+// binanceExecutedQty 订单已成交数量，解析失败为0
+func binanceExecutedQty(order *binanceOrder) decimal.Decimal {
+	filled, err := decimal.NewFromString(order.ExecutedQty)
+	if nil != err {
+		return decimal.Zero
+	}
+
+	return filled
+}
+
+func binanceOrderFinal(status string) bool {
+	return "FILLED" == status || "CANCELED" == status || "EXPIRED" == status || "REJECTED" == status
+}
+
+// placeBybitOrder 按用户币种的下单方式下单，同placeBinanceOrder。剩余市价用同轮次同来源的新orderLinkId
+func placeBybitOrder(ctx context.Context, user *entity.User, symbol, qty, side string, position int, orderLinkId string) (*BybitPlaceOrderResponse, error) {
+	policy := executionPolicyOf(user.Id, symbol)
+	if nil == policy || isBybitCloseOrder(side, position) || !symbolsBybitMap.Contains(symbol) {
+		return bybitPlaceOrderSplit(ctx, user.ApiKey, user.ApiSecret, symbol, qty, side, position, orderLinkId)
+	}
+
+	tmpSymbol := symbolsBybitMap.Get(symbol).(*BybitSymbol)
+	total, err := decimal.NewFromString(qty)
+	if nil != err || (0 < tmpSymbol.MaxOrderQty && total.GreaterThan(decimal.NewFromFloat(tmpSymbol.MaxOrderQty))) {
+		return bybitPlaceOrderSplit(ctx, user.ApiKey, user.ApiSecret, symbol, qty, side, position, orderLinkId)
+	}
+
+	bid, ask, err := bybitBookTicker(ctx, user.ApiKey, symbol)
+	if nil != err {
+		log.Println("bybit 限价下单，查询买一卖一失败，市价：", symbol, err)
+		return bybitPlaceOrderSplit(ctx, user.ApiKey, user.ApiSecret, symbol, qty, side, position, orderLinkId)
+	}
+
+	price, ok := limitPrice(side, bid, ask, policy.OffsetBps, tmpSymbol.TickSize)
+	if !ok {
+		return bybitPlaceOrderSplit(ctx, user.ApiKey, user.ApiSecret, symbol, qty, side, position, orderLinkId)
+	}
+
+	var (
+		res   *BybitPlaceOrderResponse
+		order *BybitOrder
+	)
+	res, err = bybitPlaceOrderPrice(ctx, user.ApiKey, user.ApiSecret, symbol, qty, price, side, position, orderLinkId)
+	if nil != err {
+		// 结果未知，查到了按挂单继续，查不到不再下单
+		time.Sleep(orderQueryDelay)
+		var queryErr error
+		order, queryErr = bybitGetOrderByLinkId(ctx, user.ApiKey, user.ApiSecret, orderLinkId)
+		if nil != queryErr {
+			log.Println("bybit 限价下单，结果未知，查单失败：", orderLinkId, err, queryErr)
+			return nil, err
+		}
+	} else if nil != res && 0 == res.RetCode && 0 < len(res.Result.OrderId) {
+		order = &BybitOrder{OrderId: res.Result.OrderId, OrderLinkId: orderLinkId, OrderStatus: "New", CumExecQty: "0"}
+	}
+
+	marketLinkId, ok := bybitRemainderLinkId(user.Id, orderLinkId, side, position)
+	if !ok {
+		log.Println("bybit 限价下单，生成订单号失败：", orderLinkId)
+		if nil == order {
+			return res, err
+		}
+	}
+
+	if nil == order {
+		log.Println("bybit 限价下单失败，市价：", orderLinkId, price, err, res)
+		return bybitPlaceOrderSplit(ctx, user.ApiKey, user.ApiSecret, symbol, qty, side, position, marketLinkId)
+	}
+
+	filled, final := waitBybitLimitOrder(ctx, user, symbol, orderLinkId, order, executionWait(policy))
+	log.Println("bybit 限价下单，等待结束：", orderLinkId, price, qty, filled, final)
+	if !final {
+		// 撤单失败仍在挂单，之后的成交由跟踪计入系统仓位
+		positionSide := "LONG"
+		if 2 == position {
+			positionSide = "SHORT"
+		}
+		followCtx := context.WithoutCancel(ctx)
+		trackOpenLimitOrder(orderLinkId, symbol+"&"+positionSide+"&"+strconv.FormatUint(uint64(user.Id), 10), filled)
+		followOpenLimitOrder(orderLinkId, func() (decimal.Decimal, bool, bool) {
+			tmpOrder := cancelBybitLimitOrder(followCtx, user, symbol, orderLinkId)
+			if nil == tmpOrder {
+				return decimal.Zero, false, false
+			}
+
+			return bybitExecutedQty(tmpOrder), bybitOrderFinal(tmpOrder.OrderStatus), true
+		})
+	}
+
+	placed := filled
+	remaining := total.Sub(filled)
+	if final && ok && remaining.IsPositive() {
+		var marketRes *BybitPlaceOrderResponse
+		marketRes, err = bybitPlaceOrderSplit(ctx, user.ApiKey, user.ApiSecret, symbol, formatQty("bybit", symbol, remaining), side, position, marketLinkId)
+		if nil != marketRes && 0 == marketRes.RetCode && 0 < len(marketRes.Result.OrderId) {
+			// 拆单部分结果未知时按已确认的数量计入
+			if nil != err {
+				log.Println("bybit 限价下单，剩余市价部分结果未知：", orderLinkId, remaining, marketRes.placedQty, err)
+			}
+			if marketRes.placedQty.IsPositive() {
+				remaining = marketRes.placedQty
+			}
+			placed = placed.Add(remaining)
+		} else if !filled.IsPositive() {
+			// 限价没有成交，按市价的结果返回
+			return marketRes, err
+		} else {
+			log.Println("bybit 限价下单，剩余市价失败：", orderLinkId, remaining, err, marketRes)
+		}
+	}
+
+	if !placed.IsPositive() {
+		return &BybitPlaceOrderResponse{RetCode: -1, RetMsg: "限价单未成交"}, nil
+	}
+
+	res = &BybitPlaceOrderResponse{placedQty: placed}
+	res.Result.OrderId = order.OrderId
+	res.Result.OrderLinkId = orderLinkId
+	return res, nil
+}
+
+// bybitRemainderLinkId 剩余市价的orderLinkId，沿用限价单的轮次和来源
+func bybitRemainderLinkId(userId uint, orderLinkId string, side string, position int) (string, bool) {
+	link, err := parseBybitOrderLinkId(orderLinkId)
+	if nil != err {
+		return "", false
+	}
+
+	return nextBybitOrderLinkId(userId, strconv.FormatInt(link.Cycle.UnixMilli(), 36), link.Source, side, position)
+}
+
+// waitBybitLimitOrder 等待限价单成交，超时撤单，返回成交数量和订单是否已终结，未终结时不能再市价下单
+func waitBybitLimitOrder(ctx context.Context, user *entity.User, symbol string, orderLinkId string, order *BybitOrder, wait time.Duration) (decimal.Decimal, bool) {
+	deadline := time.Now().Add(wait)
+	for !bybitOrderFinal(order.OrderStatus) && time.Now().Before(deadline) {
+		time.Sleep(executionPollInterval)
+
+		tmpOrder, err := bybitGetOrderByLinkId(ctx, user.ApiKey, user.ApiSecret, orderLinkId)
+		if nil == err && nil != tmpOrder {
+			order = tmpOrder
+		}
+	}
+
+	// 撤单失败时重试，仍未终结的由调用方跟踪
+	for i := 0; i < limitCancelTimes && !bybitOrderFinal(order.OrderStatus); i++ {
+		if 0 < i {
+			time.Sleep(executionPollInterval)
+		}
+
+		if tmpOrder := cancelBybitLimitOrder(ctx, user, symbol, orderLinkId); nil != tmpOrder {
+			order = tmpOrder
+		}
+	}
+
+	return bybitExecutedQty(order), bybitOrderFinal(order.OrderStatus)
+}
+
+// cancelBybitLimitOrder 撤单，撤单结果不带成交数量，再查一次，返回订单最新状态，查单失败时返回nil
+func cancelBybitLimitOrder(ctx context.Context, user *entity.User, symbol string, orderLinkId string) *BybitOrder {
+	if err := bybitCancelOrder(ctx, user.ApiKey, user.ApiSecret, symbol, orderLinkId); nil != err {
+		log.Println("bybit 限价下单，撤单失败：", orderLinkId, err)
+	}
+
+	tmpOrder, err := bybitGetOrderByLinkId(ctx, user.ApiKey, user.ApiSecret, orderLinkId)
+	if nil != err {
+		return nil
+	}
+
+	return tmpOrder
+}
+
+// bybitExecutedQty 订单已成交数量，解析失败为0
+func bybitExecutedQty(order *BybitOrder) decimal.Decimal {
+	filled, err := decimal.NewFromString(order.CumExecQty)
+	if nil != err {
+		return decimal.Zero
+	}
+
+	return filled
+}
+
+func bybitOrderFinal(status string) bool {
+	return "Filled" == status || "Cancelled" == status || "Rejected" == status || "Deactivated" == status || "PartiallyFilledCanceled" == status
+}
+
+// bybitCancelOrder 按orderLinkId撤单
+func bybitCancelOrder(ctx context.Context, apiK, apiS, symbol, orderLinkId string) error {
+	client := bybit.NewBybitHttpClient(
+		apiK,
+		apiS,
+		bybit.WithBaseURL(bybit.MAINNET),
+	)
+
+	if err := acquireBybit(apiK, false); nil != err {
+		return err
+	}
+
+	resp, err := client.NewUtaBybitServiceWithParams(map[string]interface{}{
+		"category":    "linear",
+		"symbol":      symbol,
+		"orderLinkId": orderLinkId,
+	}).CancelOrder(ctx)
+	if nil != err {
+		return err
+	}
+	updateBybitRate(apiK, resp.RetCode)
+
+	if 0 != resp.RetCode {
+		return fmt.Errorf("%d: %s", resp.RetCode, resp.RetMsg)
+	}
+
+	return nil
+}
+
+// GetExecutionPolicies get execution policies of users and symbols
+func (s *sBinanceTraderHistory) GetExecutionPolicies(ctx context.Context) []*entity.ExecutionPolicy {
+	res := make([]*entity.ExecutionPolicy, 0)
+	for _, v := range executionPolicies.Val().(map[string]*entity.ExecutionPolicy) {
+		res = append(res, v)
+	}
+
+	return res
+}
+
+// SetExecutionPolicy set execution policy, userId 0 and empty symbol apply to all, limit mode posts opens and adds at best bid or ask with offset and markets the remainder after waitMs, closes are always market
+func (s *sBinanceTraderHistory) SetExecutionPolicy(ctx context.Context, userId uint, symbol string, mode string, offsetBps float64, waitMs int) error {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if executionMarket != mode && executionLimit != mode {
+		return errors.New("下单方式错误")
+	}
+
+	if executionLimit == mode && (0 >= waitMs || executionMaxWaitMs < waitMs) {
+		return errors.New("等待时间错误")
+	}
+
+	_, err := g.Model("execution_policy").Ctx(ctx).Data(&do.ExecutionPolicy{
+		UserId:    userId,
+		Symbol:    symbol,
+		Mode:      mode,
+		OffsetBps: offsetBps,
+		WaitMs:    waitMs,
+		UpdatedAt: gtime.Now(),
+	}).Save()
+	if nil != err {
+		log.Println("设置下单方式失败：", err)
+		return err
+	}
+
+	// 立即生效
+	loadExecutionPolicies(ctx)
+	return nil
+}
+
+// DeleteExecutionPolicy delete execution policy of the user and symbol
+func (s *sBinanceTraderHistory) DeleteExecutionPolicy(ctx context.Context, userId uint, symbol string) error {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	_, err := g.Model("execution_policy").Ctx(ctx).Where("user_id=? AND symbol=?", userId, symbol).Delete()
+	if nil != err {
+		log.Println("删除下单方式失败：", err)
+		return err
+	}
+
+	loadExecutionPolicies(ctx)
+	return nil
+}
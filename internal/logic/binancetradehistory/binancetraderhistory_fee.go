@@ -0,0 +1,467 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/do"
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"log"
+	"strconv"
+	"time"
+)
+
+// 分成的盈利计算方式
+const (
+	feeBasisEquity   = "equity"   // 账户权益超过高水位的部分，周期内的转入转出计入高水位
+	feeBasisRealized = "realized" // 累计已实现盈亏（含手续费和资金费）超过高水位的部分
+)
+
+// 分成账单状态
+const (
+	feeStatusPending = "pending"
+	feeStatusPaid    = "paid"
+	feeStatusWaived  = "waived"
+)
+
+// incomeTransfer binance转入转出的收入类型，bybit的合约流水不包含转账，bybit用户不能用equity方式
+const incomeTransfer = "TRANSFER"
+
+// feePeriodStart 时间所在结算周期的开始时间，week从周一开始
+func feePeriodStart(period string, t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case "week":
+		return day.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+	case "month":
+		return day.AddDate(0, 0, 1-t.Day())
+	default:
+		return day
+	}
+}
+
+// userEquity 用户当前的账户权益，不乘num
+func userEquity(ctx context.Context, user *entity.User) (float64, error) {
+	var detail string
+	if "binance" == user.Plat {
+		account := getBinanceAccount(user.ApiKey, user.ApiSecret)
+		if nil == account {
+			return 0, errors.New("拉取binance账户失败")
+		}
+		detail = account.TotalMarginBalance
+	} else if "bybit" == user.Plat {
+		list, err := getBybitAccountBalance(ctx, user.ApiKey, user.ApiSecret)
+		if nil != err {
+			return 0, err
+		}
+		if 0 >= len(list) {
+			return 0, errors.New("拉取bybit账户失败")
+		}
+		detail = list[0].TotalMarginBalance
+	} else {
+		return 0, errors.New("不支持的平台")
+	}
+
+	return strconv.ParseFloat(detail, 64)
+}
+
+// userRealizedTotal 用户到某时间为止的累计已实现盈亏，含手续费和资金费
+func userRealizedTotal(ctx context.Context, userId uint, before time.Time) (float64, error) {
+	return g.Model("user_income").Ctx(ctx).
+		Where("user_id=? AND income_time<?", userId, before.UnixMilli()).
+		WhereIn("income_type", []string{incomeRealizedPnl, incomeCommission, incomeFundingFee}).
+		Sum("income")
+}
+
+// userTransfers 用户在时间段内的净转入
+func userTransfers(ctx context.Context, userId uint, start time.Time, end time.Time) (float64, error) {
+	return g.Model("user_income").Ctx(ctx).
+		Where("user_id=? AND income_type=? AND income_time>=? AND income_time<?", userId, incomeTransfer, start.UnixMilli(), end.UnixMilli()).
+		Sum("income")
+}
+
+// feeValue 按计算方式取用户当前和高水位比较的值
+func feeValue(ctx context.Context, user *entity.User, basis string, end time.Time) (float64, error) {
+	if feeBasisRealized == basis {
+		return userRealizedTotal(ctx, user.Id, end)
+	}
+
+	return userEquity(ctx, user)
+}
+
+// feeStatement 计算一个周期的分成账单，equity方式下净转入抬高高水位，盈利只收超过高水位的部分
+func feeStatement(config *entity.UserFeeConfig, periodEnd time.Time, value float64, transfers float64, now time.Time) *do.FeeSettlement {
+	var (
+		highWaterMark = config.HighWaterMark + transfers
+		profit        = value - highWaterMark
+		fee           float64
+		status        = feeStatusPending
+		paidAt        *gtime.Time
+	)
+	if 0 < profit {
+		fee = profit * config.FeeRate
+		highWaterMark = value
+	} else {
+		profit = 0
+	}
+
+	if 0 >= fee {
+		// 没有分成的账单直接结清
+		status = feeStatusPaid
+		paidAt = gtime.New(now)
+	}
+
+	return &do.FeeSettlement{
+		UserId:             config.UserId,
+		Basis:              config.Basis,
+		PeriodStart:        config.PeriodStart,
+		PeriodEnd:          gtime.New(periodEnd),
+		StartHighWaterMark: config.HighWaterMark,
+		Transfers:          transfers,
+		EndValue:           value,
+		Profit:             profit,
+		FeeRate:            config.FeeRate,
+		Fee:                fee,
+		EndHighWaterMark:   highWaterMark,
+		Status:             status,
+		DueAt:              gtime.New(now.AddDate(0, 0, config.DueDays)),
+		PaidAt:             paidAt,
+		Paused:             0,
+		CreatedAt:          gtime.New(now),
+	}
+}
+
+// settleUserFee 结算用户已结束的周期，多个周期未结算时合并成一个账单
+func settleUserFee(ctx context.Context, user *entity.User, config *entity.UserFeeConfig, now time.Time) error {
+	if nil == config.PeriodStart {
+		return errors.New("结算周期开始时间为空")
+	}
+
+	periodEnd := feePeriodStart(config.Period, now)
+	if !periodEnd.After(config.PeriodStart.Time) {
+		return nil
+	}
+
+	var (
+		err       error
+		value     float64
+		transfers float64
+	)
+	if feeBasisEquity == config.Basis {
+		if "binance" != user.Plat {
+			return errors.New("equity方式只支持binance用户")
+		}
+
+		// 权益只能取当前值，周期截止到当前，转入转出和下个周期的开始用同一时间，不重复计算
+		periodEnd = now
+		transfers, err = userTransfers(ctx, user.Id, config.PeriodStart.Time, periodEnd)
+		if nil != err {
+			return err
+		}
+	}
+
+	value, err = feeValue(ctx, user, config.Basis, periodEnd)
+	if nil != err {
+		return err
+	}
+
+	statement := feeStatement(config, periodEnd, value, transfers, now)
+	err = g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		_, err := tx.Ctx(ctx).Insert("fee_settlement", statement)
+		if nil != err {
+			return err
+		}
+
+		_, err = tx.Ctx(ctx).Update("user_fee_config", g.Map{
+			"high_water_mark": statement.EndHighWaterMark,
+			"period_start":    gtime.New(periodEnd),
+			"updated_at":      gtime.Now(),
+		}, "id=? AND period_start=?", config.Id, config.PeriodStart)
+		return err
+	})
+	if nil != err {
+		return err
+	}
+
+	log.Println("分成结算：", user.Id, statement.PeriodStart, statement.PeriodEnd, value, statement.Profit, statement.Fee)
+	return nil
+}
+
+// pauseOverdueUsers 账单逾期且配置了暂停的用户暂停开新仓，只暂停正在开仓的用户
+func pauseOverdueUsers(ctx context.Context, configs map[uint]*entity.UserFeeConfig, now time.Time) {
+	var statements []*entity.FeeSettlement
+	err := g.Model("fee_settlement").Ctx(ctx).
+		Where("status=? AND paused=? AND due_at<?", feeStatusPending, 0, gtime.New(now)).
+		Scan(&statements)
+	if nil != err {
+		log.Println("分成逾期，数据库查询错误：", err)
+		return
+	}
+
+	for _, v := range statements {
+		if config, ok := configs[v.UserId]; !ok || 1 != config.PauseOverdue {
+			continue
+		}
+
+		var (
+			result       sql.Result
+			rowsAffected int64
+		)
+		result, err = g.Model("user").Ctx(ctx).Data("open_status", 1).Where("id=? AND open_status=?", v.UserId, 2).Update()
+		if nil != err {
+			log.Println("分成逾期，暂停用户失败：", v.UserId, err)
+			continue
+		}
+		rowsAffected, _ = result.RowsAffected()
+		if 0 >= rowsAffected {
+			continue
+		}
+
+		// 标记由逾期暂停，付款后只恢复这些用户
+		_, err = g.Model("fee_settlement").Ctx(ctx).Data("paused", 1).Where("id=?", v.Id).Update()
+		if nil != err {
+			log.Println("分成逾期，更新账单失败：", v.Id, err)
+			continue
+		}
+
+		log.Println("分成逾期，暂停用户开新仓：", v.UserId, v.Id, v.Fee)
+	}
+}
+
+// SettleUserFees settle ended periods of every user with a fee config and pause users with overdue statements
+func (s *sBinanceTraderHistory) SettleUserFees(ctx context.Context) {
+	var (
+		err     error
+		configs []*entity.UserFeeConfig
+		users   []*entity.User
+		now     = time.Now()
+	)
+	err = g.Model("user_fee_config").Ctx(ctx).Scan(&configs)
+	if nil != err {
+		log.Println("分成结算，数据库查询错误：", err)
+		return
+	}
+	if 0 >= len(configs) {
+		return
+	}
+
+	userIds := make([]uint, 0, len(configs))
+	configMap := make(map[uint]*entity.UserFeeConfig, len(configs))
+	for _, v := range configs {
+		userIds = append(userIds, v.UserId)
+		configMap[v.UserId] = v
+	}
+
+	err = g.Model("user").Ctx(ctx).WhereIn("id", userIds).Scan(&users)
+	if nil != err {
+		log.Println("分成结算，用户查询错误：", err)
+		return
+	}
+
+	for _, vUser := range users {
+		if nil != ctx.Err() {
+			return
+		}
+
+		if err = settleUserFee(ctx, vUser, configMap[vUser.Id], now); nil != err {
+			log.Println("分成结算失败：", vUser.Id, err)
+		}
+	}
+
+	pauseOverdueUsers(ctx, configMap, now)
+}
+
+// SetUserFeeConfig set the user's profit share rate, settlement period, basis, payment days and whether to pause on overdue.
+// A new config starts its first period now with the current equity or realized pnl as the high-water mark, equity basis is binance only.
+func (s *sBinanceTraderHistory) SetUserFeeConfig(ctx context.Context, apiKey string, feeRate float64, period string, basis string, dueDays int, pauseOverdue int) error {
+	if 0 > feeRate || 1 < feeRate {
+		return errors.New("分成比例错误")
+	}
+	if "day" != period && "week" != period && "month" != period {
+		return errors.New("周期错误")
+	}
+	if feeBasisEquity != basis && feeBasisRealized != basis {
+		return errors.New("盈利计算方式错误")
+	}
+	if 0 > dueDays {
+		return errors.New("支付天数错误")
+	}
+
+	var (
+		err     error
+		users   []*entity.User
+		configs []*entity.UserFeeConfig
+	)
+	err = g.Model("user").Ctx(ctx).Where("api_key=?", apiKey).Scan(&users)
+	if nil != err {
+		return err
+	}
+	if 0 >= len(users) {
+		return errors.New("用户不存在")
+	}
+	if feeBasisEquity == basis && "binance" != users[0].Plat {
+		return errors.New("equity方式只支持binance用户，bybit的合约流水不包含转账")
+	}
+
+	err = g.Model("user_fee_config").Ctx(ctx).Where("user_id=?", users[0].Id).Scan(&configs)
+	if nil != err {
+		return err
+	}
+
+	if 0 < len(configs) {
+		if configs[0].Basis != basis {
+			return errors.New("已有配置不能修改盈利计算方式，请先删除")
+		}
+
+		_, err = g.Model("user_fee_config").Ctx(ctx).Data(g.Map{
+			"fee_rate":      feeRate,
+			"period":        period,
+			"due_days":      dueDays,
+			"pause_overdue": pauseOverdue,
+			"updated_at":    gtime.Now(),
+		}).Where("id=?", configs[0].Id).Update()
+		return err
+	}
+
+	now := time.Now()
+	highWaterMark, err := feeValue(ctx, users[0], basis, now)
+	if nil != err {
+		return err
+	}
+
+	_, err = g.Model("user_fee_config").Ctx(ctx).Insert(&do.UserFeeConfig{
+		UserId:        users[0].Id,
+		FeeRate:       feeRate,
+		Period:        period,
+		Basis:         basis,
+		HighWaterMark: highWaterMark,
+		PeriodStart:   gtime.New(now),
+		DueDays:       dueDays,
+		PauseOverdue:  pauseOverdue,
+		CreatedAt:     gtime.New(now),
+		UpdatedAt:     gtime.New(now),
+	})
+	if nil != err {
+		log.Println("设置分成配置失败：", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteUserFeeConfig delete the user's fee config, existing statements are kept
+func (s *sBinanceTraderHistory) DeleteUserFeeConfig(ctx context.Context, apiKey string) error {
+	var users []*entity.User
+	err := g.Model("user").Ctx(ctx).Where("api_key=?", apiKey).Scan(&users)
+	if nil != err {
+		return err
+	}
+	if 0 >= len(users) {
+		return errors.New("用户不存在")
+	}
+
+	_, err = g.Model("user_fee_config").Ctx(ctx).Where("user_id=?", users[0].Id).Delete()
+	return err
+}
+
+// GetUserFeeConfigs get fee configs of all users
+func (s *sBinanceTraderHistory) GetUserFeeConfigs(ctx context.Context) []*entity.UserFeeConfig {
+	res := make([]*entity.UserFeeConfig, 0)
+	err := g.Model("user_fee_config").Ctx(ctx).OrderAsc("id").Scan(&res)
+	if nil != err {
+		log.Println("查询分成配置，数据库查询错误：", err)
+	}
+
+	return res
+}
+
+// GetFeeSettlements get fee statements, newest first, filtered by user and status when given
+func (s *sBinanceTraderHistory) GetFeeSettlements(ctx context.Context, apiKey string, status string, limit int) ([]*entity.FeeSettlement, error) {
+	res := make([]*entity.FeeSettlement, 0)
+	model := g.Model("fee_settlement").Ctx(ctx)
+	if 0 < len(apiKey) {
+		var users []*entity.User
+		err := g.Model("user").Ctx(ctx).Where("api_key=?", apiKey).Scan(&users)
+		if nil != err {
+			return nil, err
+		}
+		if 0 >= len(users) {
+			return nil, errors.New("用户不存在")
+		}
+		model = model.Where("user_id=?", users[0].Id)
+	}
+	if 0 < len(status) {
+		model = model.Where("status=?", status)
+	}
+
+	err := model.OrderDesc("id").Limit(limit).Scan(&res)
+	if nil != err {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// SetFeeSettlementStatus mark a pending statement as paid or waived, and reopen the user if it was paused for overdue
+// and has no other overdue statement left
+func (s *sBinanceTraderHistory) SetFeeSettlementStatus(ctx context.Context, id uint, status string) error {
+	if feeStatusPaid != status && feeStatusWaived != status {
+		return errors.New("状态错误")
+	}
+
+	var statements []*entity.FeeSettlement
+	err := g.Model("fee_settlement").Ctx(ctx).Where("id=?", id).Scan(&statements)
+	if nil != err {
+		return err
+	}
+	if 0 >= len(statements) {
+		return errors.New("账单不存在")
+	}
+	if feeStatusPending != statements[0].Status {
+		return errors.New("账单已处理")
+	}
+
+	_, err = g.Model("fee_settlement").Ctx(ctx).Data(g.Map{
+		"status":  status,
+		"paid_at": gtime.Now(),
+	}).Where("id=? AND status=?", id, feeStatusPending).Update()
+	if nil != err {
+		return err
+	}
+
+	// 已支付的账单计算代理佣金
+	if feeStatusPaid == status {
+		if err = recordAgentCommissions(ctx, statements[0]); nil != err {
+			log.Println("记录代理佣金失败：", id, err)
+		}
+	}
+
+	// 恢复逾期暂停的用户
+	pausedCount, err := g.Model("fee_settlement").Ctx(ctx).Where("user_id=? AND paused=?", statements[0].UserId, 1).Count()
+	if nil != err || 0 >= pausedCount {
+		return err
+	}
+
+	overdueCount, err := g.Model("fee_settlement").Ctx(ctx).
+		Where("user_id=? AND status=? AND due_at<?", statements[0].UserId, feeStatusPending, gtime.Now()).
+		Count()
+	if nil != err || 0 < overdueCount {
+		return err
+	}
+
+	_, err = g.Model("user").Ctx(ctx).Data("open_status", 2).Where("id=? AND open_status=?", statements[0].UserId, 1).Update()
+	if nil != err {
+		return err
+	}
+
+	_, err = g.Model("fee_settlement").Ctx(ctx).Data("paused", 2).Where("user_id=? AND paused=?", statements[0].UserId, 1).Update()
+	if nil != err {
+		return err
+	}
+
+	log.Println("分成已结清，恢复用户开新仓：", statements[0].UserId)
+	return nil
+}
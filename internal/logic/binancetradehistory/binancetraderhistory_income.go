@@ -0,0 +1,473 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/do"
+	"binance_data_gf/internal/model/entity"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	bybit "github.com/bybit-exchange/bybit.go.api"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// 收入类型，bybit的流水按binance的类型归类
+const (
+	incomeRealizedPnl = "REALIZED_PNL"
+	incomeCommission  = "COMMISSION"
+	incomeFundingFee  = "FUNDING_FEE"
+)
+
+var (
+	incomeBackfill     = 30 * 24 * time.Hour // 第一次同步时拉取的时长
+	bybitIncomeWindow  = 7 * 24 * time.Hour  // bybit流水每次查询的最长时间范围
+	binanceIncomeLimit = 1000
+)
+
+// lastIncomeTime 用户已同步的最后一条流水时间，没有时按回溯时长
+func lastIncomeTime(ctx context.Context, userId uint, plat string) int64 {
+	value, err := g.Model("user_income").Ctx(ctx).Where("user_id=? AND plat=?", userId, plat).Max("income_time")
+	if nil != err || 0 >= value {
+		return time.Now().Add(-incomeBackfill).UnixMilli()
+	}
+
+	return int64(value)
+}
+
+// incomeCursor 用户已完整同步到的时间，bybit的流水倒序返回，不能按最后一条流水时间续拉，没有时按回溯时长
+func incomeCursor(ctx context.Context, userId uint, plat string) int64 {
+	var cursors []*entity.UserIncomeCursor
+	err := g.Model("user_income_cursor").Ctx(ctx).Where("user_id=? AND plat=?", userId, plat).Limit(1).Scan(&cursors)
+	if nil != err || 0 >= len(cursors) || 0 >= cursors[0].SyncedTo {
+		return time.Now().Add(-incomeBackfill).UnixMilli()
+	}
+
+	return cursors[0].SyncedTo
+}
+
+// saveIncomeCursor 一段时间范围全部同步完成后推进游标
+func saveIncomeCursor(ctx context.Context, userId uint, plat string, syncedTo int64) error {
+	_, err := g.Model("user_income_cursor").Ctx(ctx).Data(&do.UserIncomeCursor{
+		UserId:    userId,
+		Plat:      plat,
+		SyncedTo:  syncedTo,
+		UpdatedAt: gtime.Now(),
+	}).Save()
+	return err
+}
+
+// saveIncomes 保存流水，重复的忽略
+func saveIncomes(ctx context.Context, incomes []*do.UserIncome) error {
+	if 0 >= len(incomes) {
+		return nil
+	}
+
+	_, err := g.Model("user_income").Ctx(ctx).Data(incomes).InsertIgnore()
+	return err
+}
+
+// binanceIncome binance收入流水
+type binanceIncome struct {
+	Symbol     string `json:"symbol"`
+	IncomeType string `json:"incomeType"`
+	Income     string `json:"income"`
+	Asset      string `json:"asset"`
+	Info       string `json:"info"`
+	Time       int64  `json:"time"`
+	TranId     int64  `json:"tranId"`
+}
+
+// requestBinanceIncome 查询binance收入流水，按时间升序
+func requestBinanceIncome(apiKey string, secretKey string, startTime int64) ([]*binanceIncome, error) {
+	if err := acquireBinance(apiKey, 30, false, false); nil != err {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("startTime", strconv.FormatInt(startTime, 10))
+	params.Set("limit", strconv.Itoa(binanceIncomeLimit))
+	params.Set("timestamp", strconv.FormatInt(time.Now().UTC().UnixMilli(), 10))
+
+	req, err := http.NewRequest("GET", "https://fapi.binance.com/fapi/v1/income?"+params.Encode()+"&signature="+generateSignature(secretKey, params), nil)
+	if nil != err {
+		return nil, err
+	}
+	req.Header.Set("X-MBX-APIKEY", apiKey)
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if nil != err {
+		return nil, err
+	}
+	updateBinanceRate(apiKey, resp)
+
+	defer func() {
+		if resp != nil && resp.Body != nil {
+			err := resp.Body.Close()
+			if err != nil {
+				log.Println("关闭响应体错误：", err)
+			}
+		}
+	}()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if nil != err {
+		return nil, err
+	}
+
+	var res []*binanceIncome
+	if err = json.Unmarshal(b, &res); nil != err {
+		var resOrderInfo *orderInfo
+		if nil == json.Unmarshal(b, &resOrderInfo) && nil != resOrderInfo {
+			return nil, errors.New(resOrderInfo.Msg)
+		}
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// syncBinanceIncome 同步binance用户的收入流水
+func syncBinanceIncome(ctx context.Context, user *entity.User) error {
+	startTime := lastIncomeTime(ctx, user.Id, "binance")
+	for {
+		incomes, err := requestBinanceIncome(user.ApiKey, user.ApiSecret, startTime)
+		if nil != err {
+			return err
+		}
+
+		tmpIncomes := make([]*do.UserIncome, 0)
+		for _, v := range incomes {
+			income, _ := strconv.ParseFloat(v.Income, 64)
+			tmpIncomes = append(tmpIncomes, &do.UserIncome{
+				UserId:     user.Id,
+				Plat:       "binance",
+				IncomeType: v.IncomeType,
+				Symbol:     v.Symbol,
+				Asset:      v.Asset,
+				Income:     income,
+				TranId:     strconv.FormatInt(v.TranId, 10),
+				Info:       v.Info,
+				IncomeTime: v.Time,
+				CreatedAt:  gtime.Now(),
+			})
+		}
+
+		if err = saveIncomes(ctx, tmpIncomes); nil != err {
+			return err
+		}
+
+		// 不满一页说明拉完了，满一页时从最后一条的时间继续，同一毫秒的重复流水由唯一索引忽略
+		if binanceIncomeLimit > len(incomes) || incomes[len(incomes)-1].Time <= startTime {
+			return nil
+		}
+		startTime = incomes[len(incomes)-1].Time
+	}
+}
+
+// bybitTransaction bybit交易流水
+type bybitTransaction struct {
+	Id              string `json:"id"`
+	Symbol          string `json:"symbol"`
+	Type            string `json:"type"`
+	Currency        string `json:"currency"`
+	CashFlow        string `json:"cashFlow"`
+	Funding         string `json:"funding"`
+	Fee             string `json:"fee"`
+	TransactionTime string `json:"transactionTime"`
+}
+
+type bybitTransactionResponse struct {
+	RetCode int    `json:"retCode"`
+	RetMsg  string `json:"retMsg"`
+	Result  struct {
+		NextPageCursor string              `json:"nextPageCursor"`
+		List           []*bybitTransaction `json:"list"`
+	} `json:"result"`
+}
+
+// bybitIncomes bybit流水按binance的类型拆分，成交拆成已实现盈亏和手续费，结算为资金费，其他流水忽略。
+// bybit的手续费和资金费为正数表示支出
+func bybitIncomes(userId uint, v *bybitTransaction) []*do.UserIncome {
+	var (
+		res           = make([]*do.UserIncome, 0)
+		transactionAt int64
+	)
+	transactionAt, _ = strconv.ParseInt(v.TransactionTime, 10, 64)
+	add := func(incomeType string, value string, sign float64) {
+		income, err := strconv.ParseFloat(value, 64)
+		if nil != err || IsEqual(income, 0) {
+			return
+		}
+
+		res = append(res, &do.UserIncome{
+			UserId:     userId,
+			Plat:       "bybit",
+			IncomeType: incomeType,
+			Symbol:     v.Symbol,
+			Asset:      v.Currency,
+			Income:     income * sign,
+			TranId:     v.Id,
+			Info:       v.Type,
+			IncomeTime: transactionAt,
+			CreatedAt:  gtime.Now(),
+		})
+	}
+
+	switch v.Type {
+	case "TRADE":
+		add(incomeRealizedPnl, v.CashFlow, 1)
+		add(incomeCommission, v.Fee, -1)
+	case "SETTLEMENT":
+		add(incomeFundingFee, v.Funding, -1)
+	}
+
+	return res
+}
+
+// syncBybitIncome 同步bybit用户的交易流水，按最长时间范围分段，每段按游标翻页，整段完成后推进同步游标，失败时下次从该段重新拉取
+func syncBybitIncome(ctx context.Context, user *entity.User) error {
+	client := bybit.NewBybitHttpClient(
+		user.ApiKey,
+		user.ApiSecret,
+		bybit.WithBaseURL(bybit.MAINNET),
+	)
+
+	var (
+		startTime = incomeCursor(ctx, user.Id, "bybit") + 1
+		now       = time.Now().UnixMilli()
+	)
+	for startTime < now {
+		endTime := startTime + bybitIncomeWindow.Milliseconds()
+		if endTime > now {
+			endTime = now
+		}
+
+		cursor := ""
+		for {
+			params := map[string]interface{}{
+				"accountType": "UNIFIED",
+				"category":    "linear",
+				"startTime":   startTime,
+				"endTime":     endTime,
+				"limit":       50,
+			}
+			if 0 < len(cursor) {
+				params["cursor"] = cursor
+			}
+
+			if err := acquireBybit(user.ApiKey, false); nil != err {
+				return err
+			}
+
+			resp, err := client.NewUtaBybitServiceWithParams(params).GetTransactionLog(ctx)
+			if nil != err {
+				return err
+			}
+			updateBybitRate(user.ApiKey, resp.RetCode)
+
+			var (
+				raw     []byte
+				listRes *bybitTransactionResponse
+			)
+			raw, err = json.Marshal(resp)
+			if nil != err {
+				return err
+			}
+
+			if err = json.Unmarshal(raw, &listRes); nil != err {
+				return err
+			}
+
+			if 0 != listRes.RetCode {
+				return errors.New(listRes.RetMsg)
+			}
+
+			tmpIncomes := make([]*do.UserIncome, 0)
+			for _, v := range listRes.Result.List {
+				tmpIncomes = append(tmpIncomes, bybitIncomes(user.Id, v)...)
+			}
+
+			if err = saveIncomes(ctx, tmpIncomes); nil != err {
+				return err
+			}
+
+			cursor = listRes.Result.NextPageCursor
+			if 0 >= len(cursor) || 0 >= len(listRes.Result.List) {
+				break
+			}
+		}
+
+		if err := saveIncomeCursor(ctx, user.Id, "bybit", endTime); nil != err {
+			return err
+		}
+		startTime = endTime + 1
+	}
+
+	return nil
+}
+
+// SyncUserIncome pull realized pnl, commissions and funding fees of every follower into user_income
+func (s *sBinanceTraderHistory) SyncUserIncome(ctx context.Context) {
+	users := make([]*entity.User, 0)
+	globalUsers.Iterator(func(k interface{}, v interface{}) bool {
+		users = append(users, v.(*entity.User))
+		return true
+	})
+
+	for _, vUser := range users {
+		if nil != ctx.Err() {
+			return
+		}
+
+		var err error
+		if "binance" == vUser.Plat {
+			err = syncBinanceIncome(ctx, vUser)
+		} else if "bybit" == vUser.Plat {
+			err = syncBybitIncome(ctx, vUser)
+		} else {
+			continue
+		}
+
+		if nil != err {
+			log.Println("同步收入流水失败：", vUser.Id, err)
+		}
+	}
+}
+
+// incomeReportRow 一个周期的收入汇总
+type incomeReportRow struct {
+	Period      string  `json:"period"`
+	UserId      uint    `json:"userId"` // 汇总所有用户时为0
+	RealizedPnl float64 `json:"realizedPnl"`
+	Commission  float64 `json:"commission"`
+	FundingFee  float64 `json:"fundingFee"`
+	Other       float64 `json:"other"`
+	Net         float64 `json:"net"`
+}
+
+// incomePeriod 收入所属的周期，day为日期，week为周一的日期，month为年月
+func incomePeriod(period string, t time.Time) string {
+	switch period {
+	case "week":
+		weekday := (int(t.Weekday()) + 6) % 7
+		return t.AddDate(0, 0, -weekday).Format("2006-01-02")
+	case "month":
+		return t.Format("2006-01")
+	default:
+		return t.Format("2006-01-02")
+	}
+}
+
+// incomeReport 按周期汇总收入，apiKey为空时汇总所有用户
+func incomeReport(ctx context.Context, apiKey string, period string, days int) ([]*incomeReportRow, error) {
+	if "day" != period && "week" != period && "month" != period {
+		return nil, errors.New("周期错误")
+	}
+
+	var userId uint
+	if 0 < len(apiKey) {
+		var users []*entity.User
+		err := g.Model("user").Ctx(ctx).Where("api_key=?", apiKey).Scan(&users)
+		if nil != err {
+			return nil, err
+		}
+		if 0 >= len(users) {
+			return nil, errors.New("用户不存在")
+		}
+		userId = users[0].Id
+	}
+
+	var incomes []*entity.UserIncome
+	model := g.Model("user_income").Ctx(ctx).Where("income_time>=?", time.Now().AddDate(0, 0, -days).UnixMilli())
+	if 0 < userId {
+		model = model.Where("user_id=?", userId)
+	}
+	if err := model.OrderAsc("income_time").Scan(&incomes); nil != err {
+		return nil, err
+	}
+
+	rows := make(map[string]*incomeReportRow, 0)
+	res := make([]*incomeReportRow, 0)
+	for _, v := range incomes {
+		key := incomePeriod(period, time.UnixMilli(v.IncomeTime))
+		row, ok := rows[key]
+		if !ok {
+			row = &incomeReportRow{Period: key, UserId: userId}
+			rows[key] = row
+			res = append(res, row)
+		}
+
+		switch v.IncomeType {
+		case incomeRealizedPnl:
+			row.RealizedPnl += v.Income
+		case incomeCommission:
+			row.Commission += v.Income
+		case incomeFundingFee:
+			row.FundingFee += v.Income
+		default:
+			row.Other += v.Income
+		}
+		row.Net += v.Income
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Period < res[j].Period
+	})
+
+	return res, nil
+}
+
+// GetIncomeReport get daily, weekly or monthly income report of the user, or of all followers when apiKey is empty
+func (s *sBinanceTraderHistory) GetIncomeReport(ctx context.Context, apiKey string, period string, days int) (map[string]interface{}, error) {
+	rows, err := incomeReport(ctx, apiKey, period, days)
+	if nil != err {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"period": period,
+		"days":   days,
+		"rows":   rows,
+	}, nil
+}
+
+// ExportIncomeReportCsv export the income report as csv
+func (s *sBinanceTraderHistory) ExportIncomeReportCsv(ctx context.Context, apiKey string, period string, days int) ([]byte, error) {
+	rows, err := incomeReport(ctx, apiKey, period, days)
+	if nil != err {
+		return nil, err
+	}
+
+	var (
+		buf    bytes.Buffer
+		writer = csv.NewWriter(&buf)
+	)
+	records := [][]string{{"period", "user_id", "realized_pnl", "commission", "funding_fee", "other", "net"}}
+	for _, v := range rows {
+		records = append(records, []string{
+			v.Period,
+			strconv.FormatUint(uint64(v.UserId), 10),
+			strconv.FormatFloat(v.RealizedPnl, 'f', -1, 64),
+			strconv.FormatFloat(v.Commission, 'f', -1, 64),
+			strconv.FormatFloat(v.FundingFee, 'f', -1, 64),
+			strconv.FormatFloat(v.Other, 'f', -1, 64),
+			strconv.FormatFloat(v.Net, 'f', -1, 64),
+		})
+	}
+
+	if err = writer.WriteAll(records); nil != err {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,246 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	bybit "github.com/bybit-exchange/bybit.go.api"
+	"github.com/gogf/gf/v2/container/gmap"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TraderLeverage 交易员币种杠杆和保证金模式
+type TraderLeverage struct {
+	Leverage int
+	Isolated bool
+}
+
+var (
+	traderLeverageMap = gmap.NewStrAnyMap(true) // 交易员杠杆，key为symbol
+	userLeverageMap   = gmap.NewStrAnyMap(true) // 已给用户设置的杠杆，key为symbol&userId
+)
+
+// setTraderLeverage 记录交易员杠杆
+func setTraderLeverage(symbol string, leverage int, isolated bool) {
+	if 0 >= leverage {
+		return
+	}
+
+	if traderLeverageMap.Contains(symbol) {
+		tmp := traderLeverageMap.Get(symbol).(*TraderLeverage)
+		if tmp.Leverage == leverage && tmp.Isolated == isolated {
+			return
+		}
+
+		log.Println("交易员杠杆变更：", symbol, tmp.Leverage, tmp.Isolated, leverage, isolated)
+	}
+
+	traderLeverageMap.Set(symbol, &TraderLeverage{
+		Leverage: leverage,
+		Isolated: isolated,
+	})
+}
+
+// ensureUserLeverage 开仓前，按交易员的杠杆和保证金模式设置用户账户，已设置过的不重复请求
+func ensureUserLeverage(ctx context.Context, user *entity.User, symbol string) {
+	if !traderLeverageMap.Contains(symbol) {
+		return
+	}
+
+	target := *traderLeverageMap.Get(symbol).(*TraderLeverage)
+
+	// 用户杠杆上限
+	if globalUserRiskLimits.Contains(user.Id) {
+		leverageCap := globalUserRiskLimits.Get(user.Id).(*entity.UserRiskLimit).LeverageCap
+		if 0 < leverageCap && leverageCap < target.Leverage {
+			target.Leverage = leverageCap
+		}
+	}
+
+	key := symbol + "&" + strconv.FormatUint(uint64(user.Id), 10)
+	if userLeverageMap.Contains(key) && target == *userLeverageMap.Get(key).(*TraderLeverage) {
+		return
+	}
+
+	if "binance" == user.Plat {
+		marginType := "CROSSED"
+		if target.Isolated {
+			marginType = "ISOLATED"
+		}
+
+		// 有持仓时不能切换，不影响设置杠杆
+		resMarginType, err := requestBinanceMarginType(symbol, marginType, user.ApiKey, user.ApiSecret)
+		if nil != err || (200 != resMarginType.Code && -4046 != resMarginType.Code) {
+			log.Println("设置保证金模式失败：", err, resMarginType, symbol, marginType, user.Id)
+		}
+
+		var resLeverage *orderInfo
+		resLeverage, err = requestBinanceLeverage(symbol, target.Leverage, user.ApiKey, user.ApiSecret)
+		if nil != err || (0 != resLeverage.Code && 200 != resLeverage.Code) {
+			log.Println("设置杠杆失败：", err, resLeverage, symbol, target.Leverage, user.Id)
+			return
+		}
+	} else if "bybit" == user.Plat {
+		res, err := bybitSetLeverage(ctx, user.ApiKey, user.ApiSecret, symbol, target.Leverage)
+		if nil != err || nil == res {
+			log.Println("bybit 设置杠杆失败：", err, symbol, target.Leverage, user.Id)
+			return
+		}
+
+		// 110043 杠杆未变化
+		if 0 != res.RetCode && 110043 != res.RetCode {
+			log.Println("bybit 设置杠杆失败：", res, symbol, target.Leverage, user.Id)
+			return
+		}
+	} else {
+		return
+	}
+
+	log.Println("设置用户杠杆：", user.Id, symbol, target.Leverage, target.Isolated)
+	userLeverageMap.Set(key, &target)
+}
+
+// requestBinanceSigned binance签名的POST请求，返回code和msg
+func requestBinanceSigned(apiUrl string, data string, apiKey string, secretKey string) (*orderInfo, error) {
+	var (
+		client       *http.Client
+		req          *http.Request
+		resp         *http.Response
+		resOrderInfo *orderInfo
+		b            []byte
+		err          error
+	)
+
+	err = acquireBinance(apiKey, 1, false, false)
+	if nil != err {
+		return nil, err
+	}
+
+	// 时间
+	now := strconv.FormatInt(time.Now().UTC().UnixMilli(), 10)
+	data += "&timestamp=" + now
+
+	// 加密
+	h := hmac.New(sha256.New, []byte(secretKey))
+	h.Write([]byte(data))
+	signature := hex.EncodeToString(h.Sum(nil))
+
+	// 构造请求
+	req, err = http.NewRequest("POST", apiUrl, strings.NewReader(data+"&signature="+signature))
+	if err != nil {
+		return nil, err
+	}
+	// 添加头信息
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-MBX-APIKEY", apiKey)
+
+	// 请求执行
+	client = &http.Client{Timeout: 3 * time.Second}
+	resp, err = client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	updateBinanceRate(apiKey, resp)
+
+	// 结果
+	defer func() {
+		if resp != nil && resp.Body != nil {
+			err := resp.Body.Close()
+			if err != nil {
+				log.Println("关闭响应体错误：", err)
+			}
+		}
+	}()
+
+	b, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(b, &resOrderInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return resOrderInfo, nil
+}
+
+// requestBinanceLeverage 设置杠杆，成功时没有code
+func requestBinanceLeverage(symbol string, leverage int, apiKey string, secretKey string) (*orderInfo, error) {
+	return requestBinanceSigned(
+		"https://fapi.binance.com/fapi/v1/leverage",
+		"symbol="+symbol+"&leverage="+strconv.Itoa(leverage),
+		apiKey,
+		secretKey,
+	)
+}
+
+// requestBinanceMarginType 设置保证金模式，ISOLATED，CROSSED，-4046为无需变更
+func requestBinanceMarginType(symbol string, marginType string, apiKey string, secretKey string) (*orderInfo, error) {
+	return requestBinanceSigned(
+		"https://fapi.binance.com/fapi/v1/marginType",
+		"symbol="+symbol+"&marginType="+marginType,
+		apiKey,
+		secretKey,
+	)
+}
+
+type BybitSetLeverageResponse struct {
+	RetCode    int         `json:"retCode"`
+	RetMsg     string      `json:"retMsg"`
+	Result     struct{}    `json:"result"`
+	RetExtInfo interface{} `json:"retExtInfo"`
+	Time       int64       `json:"time"`
+}
+
+func bybitSetLeverage(ctx context.Context, apiK, apiS, symbol string, leverage int) (*BybitSetLeverageResponse, error) {
+	client := bybit.NewBybitHttpClient(
+		apiK,
+		apiS,
+		bybit.WithBaseURL(bybit.MAINNET),
+	)
+
+	// 多空同一杠杆
+	params := map[string]interface{}{
+		"category":     "linear",
+		"symbol":       symbol,
+		"buyLeverage":  strconv.Itoa(leverage),
+		"sellLeverage": strconv.Itoa(leverage),
+	}
+
+	if err := acquireBybit(apiK, false); nil != err {
+		return nil, err
+	}
+
+	resp, err := client.NewUtaBybitServiceWithParams(params).SetPositionLeverage(ctx)
+	if err != nil {
+		log.Printf("设置杠杆失败: %v", err)
+		return nil, err
+	}
+	updateBybitRate(apiK, resp.RetCode)
+
+	var (
+		contractJSON []byte
+		res          *BybitSetLeverageResponse
+	)
+	contractJSON, err = json.Marshal(resp)
+	if err != nil {
+		log.Printf("合约 JSON 序列化失败: %v", err)
+		return nil, err
+	}
+
+	if err = json.Unmarshal(contractJSON, &res); err != nil {
+		log.Printf("合约 JSON 解析失败: %v", err)
+		return nil, err
+	}
+
+	return res, nil
+}
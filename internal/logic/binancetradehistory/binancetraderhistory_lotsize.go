@@ -0,0 +1,292 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"github.com/shopspring/decimal"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	binanceMaxSplitLegs = 36 * 36 // binance拆单最多的单数，序号最多两位36进制
+)
+
+// parseFilterFloat 交易规则数值，解析失败为0
+func parseFilterFloat(value string) float64 {
+	if 0 >= len(value) {
+		return 0
+	}
+
+	tmp, err := strconv.ParseFloat(value, 64)
+	if nil != err {
+		log.Println("交易规则，解析错误：", value, err)
+		return 0
+	}
+
+	return tmp
+}
+
+// symbolOrderable 开仓数量是否满足最小下单量和最小名义价值，有最小名义价值但没有标记价格时视为不满足
+func symbolOrderable(plat string, symbol string, qty decimal.Decimal) bool {
+	if !qty.IsPositive() {
+		return false
+	}
+
+	var (
+		minQty      float64
+		minNotional float64
+	)
+	if "binance" == plat {
+		if !symbolsMap.Contains(symbol) {
+			return true
+		}
+
+		tmpSymbol := symbolsMap.Get(symbol).(*LhCoinSymbol)
+		minQty = tmpSymbol.MarketMinQty
+		if 0 >= minQty {
+			minQty = tmpSymbol.MinQty
+		}
+		minNotional = tmpSymbol.MinNotional
+	} else if "bybit" == plat {
+		if !symbolsBybitMap.Contains(symbol) {
+			return true
+		}
+
+		tmpSymbol := symbolsBybitMap.Get(symbol).(*BybitSymbol)
+		minQty = tmpSymbol.MinOrderQty
+		minNotional = tmpSymbol.MinNotional
+	} else {
+		return true
+	}
+
+	if 0 < minQty && qty.LessThan(decimal.NewFromFloat(minQty)) {
+		return false
+	}
+
+	if 0 < minNotional {
+		// 没有标记价格时无法确认名义价值，和风控一致不下单，暂存等价格到了再开
+		markPrice := getMarkPrice(symbol)
+		if 0 >= markPrice {
+			return false
+		}
+
+		if qty.Mul(decimal.NewFromFloat(markPrice)).LessThan(decimal.NewFromFloat(minNotional)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// stageIfNotOrderable 开仓数量不满足下单规则时暂存到预备仓位，等后续开仓累加到可下单，返回是否已暂存
+func stageIfNotOrderable(plat string, symbol string, key string, qty decimal.Decimal, quantityDecimal decimal.Decimal) bool {
+	if symbolOrderable(plat, symbol, quantityDecimal) {
+		return false
+	}
+
+	orderMapTmp.Set(key, qty)
+	log.Println("开仓数量不足最小下单量或没有标记价格，暂存：", key, qty, quantityDecimal)
+	return true
+}
+
+// splitOrderQuantity 超过市价单最大数量时按步长平均拆分，不超过时原样返回
+func splitOrderQuantity(quantity string, maxQty float64, stepSize float64) []string {
+	res := []string{quantity}
+	if 0 >= maxQty || 0 >= stepSize {
+		return res
+	}
+
+	total, err := decimal.NewFromString(quantity)
+	if nil != err {
+		log.Println("拆单，数量解析错误：", quantity, err)
+		return res
+	}
+
+	max := decimal.NewFromFloat(maxQty)
+	if total.LessThanOrEqual(max) {
+		return res
+	}
+
+	// 小数位数和原数量一致
+	places := int32(0)
+	if parts := strings.Split(quantity, "."); 2 == len(parts) {
+		places = int32(len(parts[1]))
+	}
+
+	step := decimal.NewFromFloat(stepSize)
+	totalSteps := total.Div(step).Floor().IntPart()
+	maxSteps := max.Div(step).Floor().IntPart()
+	if 0 >= maxSteps {
+		return res
+	}
+
+	num := (totalSteps + maxSteps - 1) / maxSteps
+	baseSteps := totalSteps / num
+	remSteps := totalSteps % num
+
+	res = make([]string, 0, num)
+	placed := decimal.Zero
+	for i := int64(0); i < num; i++ {
+		tmpSteps := baseSteps
+		if i < remSteps {
+			tmpSteps++
+		}
+
+		tmpQty := step.Mul(decimal.NewFromInt(tmpSteps))
+		if i == num-1 {
+			// 不足一个步长的部分放在最后一单
+			tmpQty = total.Sub(placed)
+		}
+
+		placed = placed.Add(tmpQty)
+		res = append(res, tmpQty.StringFixed(places))
+	}
+
+	return res
+}
+
+// requestBinanceOrderSplit 下单，超过市价单最大数量时拆单依次下单，clientOrderId加序号，部分成功时返回已下单数量。
+// 后面的拆单结果未知时按clientOrderId查单，查到的计入已下单数量，查不到时连同已下单数量返回errOrderUnknown
+func requestBinanceOrderSplit(symbol string, side string, orderType string, positionSide string, quantity string, clientOrderId string, apiKey string, secretKey string) (*binanceOrder, *orderInfo, error) {
+	var (
+		maxQty   float64
+		stepSize float64
+	)
+	if symbolsMap.Contains(symbol) {
+		tmpSymbol := symbolsMap.Get(symbol).(*LhCoinSymbol)
+		maxQty = tmpSymbol.MarketMaxQty
+		stepSize = tmpSymbol.MarketStepSize
+		if 0 >= stepSize {
+			stepSize = tmpSymbol.StepSize
+		}
+	}
+
+	quantities := splitOrderQuantity(quantity, maxQty, stepSize)
+	if 1 >= len(quantities) {
+		return requestBinanceOrderIdempotent(symbol, side, orderType, positionSide, quantity, clientOrderId, apiKey, secretKey)
+	}
+
+	log.Println("超过市价单最大数量，拆单：", symbol, positionSide, quantity, quantities)
+	if binanceMaxSplitLegs < len(quantities) {
+		log.Println("拆单数量过多，不下单：", symbol, positionSide, quantity, len(quantities))
+		return &binanceOrder{}, &orderInfo{Msg: "拆单数量过多"}, errors.New("拆单数量过多")
+	}
+
+	var (
+		res          *binanceOrder
+		tmpRes       *binanceOrder
+		resOrderInfo *orderInfo
+		err          error
+		unknownErr   error
+		placed       = decimal.Zero
+	)
+	for k, v := range quantities {
+		// 序号用36进制，最多两位，不超过clientOrderId长度限制
+		tmpClientOrderId := clientOrderId + "-" + strconv.FormatInt(int64(k), 36)
+		tmpRes, resOrderInfo, err = requestBinanceOrderIdempotent(symbol, side, orderType, positionSide, v, tmpClientOrderId, apiKey, secretKey)
+		if (nil != err || nil == tmpRes || 0 >= tmpRes.OrderId) && nil != res && isOrderAmbiguous(resOrderInfo, err) {
+			// 已有拆单成功，结果未知的这一单查单确认
+			time.Sleep(orderQueryDelay)
+			queryRes, queryInfo, queryErr := requestBinanceQueryOrder(symbol, tmpClientOrderId, apiKey, secretKey)
+			if nil == queryErr && nil != queryRes && 0 < queryRes.OrderId {
+				log.Println("拆单，结果未知，查单已存在：", symbol, positionSide, k, v, queryRes.OrderId)
+				tmpRes, resOrderInfo, err = queryRes, nil, nil
+			} else if nil == queryErr && nil != queryInfo && -2013 == queryInfo.Code {
+				log.Println("拆单，结果未知，查单不存在：", symbol, positionSide, k, v)
+			} else {
+				unknownErr = errOrderUnknown
+			}
+		}
+		if nil != err || nil == tmpRes || 0 >= tmpRes.OrderId {
+			log.Println("拆单，下单失败：", symbol, positionSide, k, v, err, resOrderInfo)
+			break
+		}
+
+		res = tmpRes
+		placed = placed.Add(decimal.RequireFromString(v))
+	}
+
+	// 第一单就失败
+	if nil == res {
+		return tmpRes, resOrderInfo, err
+	}
+
+	res.placedQty = placed
+	if nil != unknownErr {
+		log.Println("拆单，部分下单结果未知：", symbol, positionSide, quantity, placed)
+		return res, &orderInfo{Msg: unknownErr.Error()}, unknownErr
+	}
+
+	return res, resOrderInfo, nil
+}
+
+// bybitPlaceOrderSplit 下单，超过市价单最大数量时拆单依次下单，订单号加序号，部分成功时返回已下单数量。
+// 后面的拆单结果未知时按orderLinkId查单，查到的计入已下单数量，查不到时连同已下单数量返回errOrderUnknown
+func bybitPlaceOrderSplit(ctx context.Context, apiK, apiS, symbol, qty, side string, position int, orderId string) (*BybitPlaceOrderResponse, error) {
+	var (
+		maxQty   float64
+		stepSize float64
+	)
+	if symbolsBybitMap.Contains(symbol) {
+		tmpSymbol := symbolsBybitMap.Get(symbol).(*BybitSymbol)
+		maxQty = tmpSymbol.MaxMktOrderQty
+		stepSize = tmpSymbol.QtyStep
+	}
+
+	quantities := splitOrderQuantity(qty, maxQty, stepSize)
+	if 1 >= len(quantities) {
+		return bybitPlaceOrder(ctx, apiK, apiS, symbol, qty, side, position, orderId)
+	}
+
+	log.Println("bybit 超过市价单最大数量，拆单：", symbol, position, qty, quantities)
+
+	var (
+		res        *BybitPlaceOrderResponse
+		tmpRes     *BybitPlaceOrderResponse
+		err        error
+		unknownErr error
+		placed     = decimal.Zero
+	)
+	for k, v := range quantities {
+		tmpOrderId := orderId + "-" + strconv.Itoa(k)
+		tmpRes, err = bybitPlaceOrder(ctx, apiK, apiS, symbol, v, side, position, tmpOrderId)
+		if (nil != err || nil == tmpRes) && nil != res {
+			// 已有拆单成功，结果未知的这一单查单确认
+			time.Sleep(orderQueryDelay)
+			order, queryErr := bybitGetOrderByLinkId(ctx, apiK, apiS, tmpOrderId)
+			if nil == queryErr && nil != order {
+				log.Println("bybit 拆单，结果未知，查单已存在：", symbol, position, k, v, order.OrderId)
+				tmpRes, err = &BybitPlaceOrderResponse{}, nil
+				tmpRes.Result.OrderId = order.OrderId
+				tmpRes.Result.OrderLinkId = order.OrderLinkId
+			} else if nil == queryErr {
+				log.Println("bybit 拆单，结果未知，查单不存在：", symbol, position, k, v)
+			} else {
+				unknownErr = errOrderUnknown
+			}
+		}
+		if nil != err || nil == tmpRes || 0 != tmpRes.RetCode {
+			log.Println("bybit 拆单，下单失败：", symbol, position, k, v, err, tmpRes)
+			break
+		}
+
+		res = tmpRes
+		placed = placed.Add(decimal.RequireFromString(v))
+	}
+
+	// 第一单就失败
+	if nil == res {
+		return tmpRes, err
+	}
+
+	res.placedQty = placed
+	if nil != unknownErr {
+		log.Println("bybit 拆单，部分下单结果未知：", symbol, position, qty, placed)
+		return res, unknownErr
+	}
+
+	return res, nil
+}
@@ -0,0 +1,223 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"errors"
+	"github.com/gogf/gf/v2/container/gmap"
+	"github.com/gogf/gf/v2/container/gtype"
+	"github.com/shopspring/decimal"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	marginWorkers = map[string]int{ // 保证金刷新每个平台的并发数，请求额度由限频器控制
+		"binance": 8,
+		"bybit":   4,
+	}
+	moneyStaleAfter = 60 * time.Second // 保证金超过该时长没有刷新成功，按保证金计算数量的开仓加仓跳过
+
+	userMoneyRefreshedAt   = gmap.NewIntAnyMap(true)   // 用户保证金最近一次刷新成功的时间，key为用户id
+	traderMoneyRefreshedAt = gtype.NewAny(time.Time{}) // 带单员保证金最近一次刷新成功的时间
+
+	binanceTimeSyncEvery = 5 * time.Minute  // 和binance服务器对时的间隔
+	binanceTimeOffset    = gtype.NewInt64() // binance服务器时间减本地时间，毫秒
+	binanceTimeSyncedAt  = gtype.NewInt64() // 最近一次对时的本地时间，毫秒
+	binanceTimeMu        sync.Mutex
+)
+
+// binanceTimestamp 签名用的binance服务器时间，按对时的偏移计算，不再每次请求服务器时间，对时失败时返回0
+func binanceTimestamp() int64 {
+	now := time.Now().UnixMilli()
+	if syncedAt := binanceTimeSyncedAt.Val(); 0 < syncedAt && now-syncedAt < binanceTimeSyncEvery.Milliseconds() {
+		return now + binanceTimeOffset.Val()
+	}
+
+	binanceTimeMu.Lock()
+	defer binanceTimeMu.Unlock()
+
+	// 等锁期间其他请求已对时
+	now = time.Now().UnixMilli()
+	if syncedAt := binanceTimeSyncedAt.Val(); 0 < syncedAt && now-syncedAt < binanceTimeSyncEvery.Milliseconds() {
+		return now + binanceTimeOffset.Val()
+	}
+
+	serverTime := getBinanceServerTime()
+	after := time.Now().UnixMilli()
+	if 0 == serverTime {
+		if 0 < binanceTimeSyncedAt.Val() {
+			// 对时失败沿用上次的偏移
+			return after + binanceTimeOffset.Val()
+		}
+		return 0
+	}
+
+	// 按请求的中间时刻估算偏移
+	binanceTimeOffset.Set(serverTime - (now+after)/2)
+	binanceTimeSyncedAt.Set(after)
+	return serverTime
+}
+
+// markUserMoneyFresh 记录用户保证金刷新成功，没有变化也记录
+func markUserMoneyFresh(userId uint) {
+	userMoneyRefreshedAt.Set(int(userId), time.Now())
+}
+
+// userMoneyFresh 用户保证金是否在有效时长内刷新过
+func userMoneyFresh(userId uint) bool {
+	v := userMoneyRefreshedAt.Get(int(userId))
+	if nil == v {
+		return false
+	}
+
+	return time.Since(v.(time.Time)) <= moneyStaleAfter
+}
+
+// traderMoneyFresh 带单员保证金是否在有效时长内刷新过
+func traderMoneyFresh() bool {
+	return time.Since(traderMoneyRefreshedAt.Val().(time.Time)) <= moneyStaleAfter
+}
+
+// userAccountMargin 用户账户的保证金，数据流正常时直接使用推送的数据，不请求接口
+func userAccountMargin(ctx context.Context, user *entity.User) (decimal.Decimal, error) {
+	var detail string
+	if "binance" == user.Plat {
+		if streamMargin, ok := binanceStreamMargin(user.Id); ok {
+			// 数据流不推送可用保证金，快过期时用接口刷新，风控按可用保证金检查
+			if age, ok := availableMoneyAge(user.Id); !ok || age > moneyStaleAfter/2 {
+				if account := getBinanceAccount(user.ApiKey, user.ApiSecret); nil != account {
+					setAvailableMoney(user.Id, account.AvailableBalance)
+				}
+			}
+
+			return streamMargin, nil
+		}
+
+		account := getBinanceAccount(user.ApiKey, user.ApiSecret)
+		if nil == account {
+			return decimal.Zero, errors.New("拉取binance账户失败")
+		}
+		setAvailableMoney(user.Id, account.AvailableBalance)
+		detail = account.TotalMarginBalance
+	} else if "bybit" == user.Plat {
+		if streamMargin, ok := bybitStreamMargin(user.Id); ok {
+			return streamMargin, nil
+		}
+
+		list, err := getBybitAccountBalance(ctx, user.ApiKey, user.ApiSecret)
+		if nil != err {
+			return decimal.Zero, err
+		}
+		if 0 >= len(list) {
+			return decimal.Zero, errors.New("拉取bybit账户失败")
+		}
+		setAvailableMoney(user.Id, list[0].TotalAvailableBalance)
+		detail = list[0].TotalMarginBalance
+	} else {
+		return decimal.Zero, errors.New("平台错误")
+	}
+
+	if 0 >= len(detail) {
+		return decimal.Zero, errors.New("保证金为空")
+	}
+
+	return decimal.NewFromString(detail)
+}
+
+// refreshUserMoney 刷新一个用户的保证金，乘num后变化超过比例才更新
+func refreshUserMoney(ctx context.Context, user *entity.User, num float64) {
+	originTmp, err := userAccountMargin(ctx, user)
+	if nil != err {
+		log.Println("拉取保证金失败：", err, user.Id, user.Plat)
+		return
+	}
+
+	tmp := originTmp.Mul(decimal.NewFromFloat(num))
+	if oldMoney, ok := userBaseMoney(user.Id); !ok {
+		log.Println("初始化成功保证金", user.Id, user.Plat, tmp, originTmp, num)
+		baseMoneyUserAllMap.Set(int(user.Id), tmp)
+	} else if moneyChanged(tmp, oldMoney, moneyChangeRatio) {
+		log.Println("保证金变更成功", user.Id, user.Plat, tmp, oldMoney, originTmp, num)
+		baseMoneyUserAllMap.Set(int(user.Id), tmp)
+	}
+
+	markUserMoneyFresh(user.Id)
+}
+
+// refreshUserMoneys 并发刷新用户保证金，每个平台的并发数有上限，请求额度由限频器控制
+func refreshUserMoneys(ctx context.Context, users []*entity.User, nums map[uint]float64) {
+	var (
+		wg   sync.WaitGroup
+		sems = make(map[string]chan struct{}, len(marginWorkers))
+	)
+	for plat, workers := range marginWorkers {
+		sems[plat] = make(chan struct{}, workers)
+	}
+
+	for _, vUser := range users {
+		sem, ok := sems[vUser.Plat]
+		if !ok {
+			log.Println("刷新保证金，平台错误：", vUser.Id, vUser.Plat)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			// 退出中，不再发起新的刷新
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(user *entity.User, num float64) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			refreshUserMoney(ctx, user, num)
+		}(vUser, nums[vUser.Id])
+	}
+
+	wg.Wait()
+}
+
+// refreshedAtMilli 刷新时间的毫秒，没有刷新过为0
+func refreshedAtMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixMilli()
+}
+
+// GetMoneyFreshness get the last successful margin refresh time of the trader and every following user
+func (s *sBinanceTraderHistory) GetMoneyFreshness(ctx context.Context) map[string]interface{} {
+	users := make(map[int]interface{}, 0)
+	userMoneyRefreshedAt.Iterator(func(k int, v interface{}) bool {
+		var money float64
+		if tmp, ok := userBaseMoney(uint(k)); ok {
+			money = tmp.InexactFloat64()
+		}
+
+		users[k] = map[string]interface{}{
+			"money":       money,
+			"refreshedAt": refreshedAtMilli(v.(time.Time)),
+			"fresh":       userMoneyFresh(uint(k)),
+		}
+		return true
+	})
+
+	return map[string]interface{}{
+		"trader": map[string]interface{}{
+			"money":       traderBaseMoney().InexactFloat64(),
+			"refreshedAt": refreshedAtMilli(traderMoneyRefreshedAt.Val().(time.Time)),
+			"fresh":       traderMoneyFresh(),
+		},
+		"users":        users,
+		"staleAfterMs": moneyStaleAfter.Milliseconds(),
+	}
+}
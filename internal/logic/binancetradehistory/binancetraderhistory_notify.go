@@ -0,0 +1,158 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/do"
+	"binance_data_gf/internal/model/entity"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 通知webhook推送状态
+const (
+	notifyUnsent = 0
+	notifySent   = 1
+	notifyFailed = 2
+)
+
+// notifyWebhookUrl 开启推送时的webhook地址
+func notifyWebhookUrl(ctx context.Context) string {
+	var configs []*entity.NotifyConfig
+	err := g.Model("notify_config").Ctx(ctx).OrderAsc("id").Limit(1).Scan(&configs)
+	if nil != err {
+		log.Println("通知配置，数据库查询错误：", err)
+		return ""
+	}
+
+	if 0 >= len(configs) || 1 != configs[0].Enabled {
+		return ""
+	}
+
+	return configs[0].WebhookUrl
+}
+
+// postNotifyWebhook 推送到webhook
+func postNotifyWebhook(webhookUrl string, data *do.Notification) error {
+	body, err := json.Marshal(g.Map{
+		"userId":   data.UserId,
+		"category": data.Category,
+		"title":    data.Title,
+		"content":  data.Content,
+	})
+	if nil != err {
+		return err
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Post(webhookUrl, "application/json", bytes.NewReader(body))
+	if nil != err {
+		return err
+	}
+
+	defer func() {
+		if resp != nil && resp.Body != nil {
+			err := resp.Body.Close()
+			if err != nil {
+				log.Println("关闭响应体错误：", err)
+			}
+		}
+	}()
+
+	if http.StatusOK > resp.StatusCode || http.StatusMultipleChoices <= resp.StatusCode {
+		return errors.New(resp.Status)
+	}
+
+	return nil
+}
+
+// notify 记录通知，开启webhook时异步推送，不耽误调用方
+func notify(ctx context.Context, userId uint, category string, title string, content string) {
+	log.Println("通知：", userId, category, title, content)
+
+	data := &do.Notification{
+		UserId:    userId,
+		Category:  category,
+		Title:     title,
+		Content:   content,
+		Sent:      notifyUnsent,
+		CreatedAt: gtime.Now(),
+	}
+	id, err := g.Model("notification").Ctx(ctx).InsertAndGetId(data)
+	if nil != err {
+		log.Println("记录通知失败：", err)
+	}
+
+	webhookUrl := notifyWebhookUrl(ctx)
+	if 0 >= len(webhookUrl) {
+		return
+	}
+
+	go func() {
+		sent := notifySent
+		if err := postNotifyWebhook(webhookUrl, data); nil != err {
+			log.Println("通知推送失败：", err, userId, title)
+			sent = notifyFailed
+		}
+
+		if 0 < id {
+			_, err := g.Model("notification").Ctx(context.WithoutCancel(ctx)).Data("sent", sent).Where("id=?", id).Update()
+			if nil != err {
+				log.Println("更新通知推送状态失败：", err, id)
+			}
+		}
+	}()
+}
+
+// GetNotifications get notifications, newest first, filtered by user when userId is not 0
+func (s *sBinanceTraderHistory) GetNotifications(ctx context.Context, userId uint, limit int) []*entity.Notification {
+	res := make([]*entity.Notification, 0)
+	model := g.Model("notification").Ctx(ctx)
+	if 0 < userId {
+		model = model.Where("user_id=?", userId)
+	}
+
+	err := model.OrderDesc("id").Limit(limit).Scan(&res)
+	if nil != err {
+		log.Println("查询通知，数据库查询错误：", err)
+	}
+
+	return res
+}
+
+// SetNotifyConfig enable or disable pushing notifications to the webhook
+func (s *sBinanceTraderHistory) SetNotifyConfig(ctx context.Context, enabled int, webhookUrl string) error {
+	webhookUrl = strings.TrimSpace(webhookUrl)
+	if 1 == enabled && !strings.HasPrefix(webhookUrl, "http://") && !strings.HasPrefix(webhookUrl, "https://") {
+		return errors.New("webhook地址错误")
+	}
+
+	var configs []*entity.NotifyConfig
+	err := g.Model("notify_config").Ctx(ctx).OrderAsc("id").Limit(1).Scan(&configs)
+	if nil != err {
+		return err
+	}
+
+	data := &do.NotifyConfig{
+		Enabled:    enabled,
+		WebhookUrl: webhookUrl,
+		UpdatedAt:  gtime.Now(),
+	}
+	if 0 < len(configs) {
+		_, err = g.Model("notify_config").Ctx(ctx).Data(data).Where("id=?", configs[0].Id).Update()
+	} else {
+		_, err = g.Model("notify_config").Ctx(ctx).Insert(data)
+	}
+	if nil != err {
+		log.Println("设置通知配置失败：", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package logic
+
+import (
+	"github.com/gogf/gf/v2/container/gmap"
+	"github.com/shopspring/decimal"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	openLimitOrders  = gmap.NewStrAnyMap(true) // 撤单后仍未终结的限价单，key为clientOrderId或orderLinkId
+	openLimitRetry   = time.Second             // 未终结限价单的撤单查单间隔
+	openLimitMaxAge  = 30 * time.Minute        // 超过后不再跟踪，需要人工核对
+	limitCancelTimes = 3                       // 限价单等待结束后的撤单次数
+)
+
+// openLimitOrder 未终结的限价单，成交按累计数量计入系统仓位
+type openLimitOrder struct {
+	mu      sync.Mutex
+	key     string          // 系统仓位的key，symbol&positionSide&userId
+	counted decimal.Decimal // 已计入系统仓位的成交数量
+}
+
+// trackOpenLimitOrder 记录撤单后仍未终结的限价单，counted为本轮已计入的成交数量
+func trackOpenLimitOrder(clientOrderId string, key string, counted decimal.Decimal) {
+	log.Println("限价单未终结，继续跟踪：", clientOrderId, key, counted)
+	openLimitOrders.Set(clientOrderId, &openLimitOrder{key: key, counted: counted})
+}
+
+// applyOpenLimitFill 按累计成交数量把新增的成交计入系统仓位，订单终结后不再跟踪，不在跟踪中时不处理
+func applyOpenLimitFill(clientOrderId string, cumQty decimal.Decimal, final bool) {
+	v := openLimitOrders.Get(clientOrderId)
+	if nil == v {
+		return
+	}
+
+	order := v.(*openLimitOrder)
+	order.mu.Lock()
+	defer order.mu.Unlock()
+
+	if cumQty.GreaterThan(order.counted) {
+		delta := cumQty.Sub(order.counted)
+		order.counted = cumQty
+		log.Println("限价单延迟成交，计入系统仓位：", clientOrderId, order.key, delta, addOrderQty(order.key, delta))
+	}
+
+	if final {
+		log.Println("限价单已终结，结束跟踪：", clientOrderId, order.key, order.counted)
+		openLimitOrders.Remove(clientOrderId)
+	}
+}
+
+// followOpenLimitOrder 在本轮跟单之外继续撤单查单，直到订单终结或超过跟踪时长。
+// poll返回累计成交数量，订单是否终结，查询是否成功
+func followOpenLimitOrder(clientOrderId string, poll func() (decimal.Decimal, bool, bool)) {
+	go func() {
+		deadline := time.Now().Add(openLimitMaxAge)
+		for time.Now().Before(deadline) {
+			time.Sleep(openLimitRetry)
+
+			// 数据流已处理终结
+			if !openLimitOrders.Contains(clientOrderId) {
+				return
+			}
+
+			cumQty, final, ok := poll()
+			if !ok {
+				continue
+			}
+
+			applyOpenLimitFill(clientOrderId, cumQty, final)
+			if final {
+				return
+			}
+		}
+
+		if v := openLimitOrders.Remove(clientOrderId); nil != v {
+			log.Println("限价单超过跟踪时长仍未终结，需要人工核对：", clientOrderId, v.(*openLimitOrder).key)
+		}
+	}()
+}
@@ -0,0 +1,234 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"encoding/json"
+	"errors"
+	bybit "github.com/bybit-exchange/bybit.go.api"
+	"github.com/gogf/gf/v2/frame/g"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 下单来源，编码在bybit的orderLinkId里
+const (
+	orderSourceInit   = "i" // 新增用户初始化仓位
+	orderSourceNew    = "n" // 带单员新开仓位
+	orderSourceUpdate = "u" // 带单员仓位变化
+	orderSourceClose  = "c" // 全部平仓
+	orderSourceManual = "m" // 手动下单
+)
+
+// BybitOrderLink 解析后的orderLinkId
+type BybitOrderLink struct {
+	UserId       uint      `json:"userId"`
+	Cycle        time.Time `json:"cycle"`        // 下单轮次
+	Source       string    `json:"source"`       // 下单来源
+	Open         bool      `json:"open"`         // 开仓或平仓
+	PositionSide string    `json:"positionSide"` // LONG，SHORT
+	Seq          uint64    `json:"seq"`          // 用户内自增序号
+	Part         int       `json:"part"`         // 拆单序号，未拆单为-1
+}
+
+// nextBybitOrderLinkId 生成orderLinkId，格式为 用户id_轮次_来源开平方向_序号，都是36进制。
+// 轮次是单调递增的毫秒时间，重启后不会和之前的重复，序号保证同一轮次内不重复。用户不存在时返回false
+func nextBybitOrderLinkId(userId uint, cycle string, source string, side string, positionIdx int) (string, bool) {
+	var (
+		seq uint64
+		ok  bool
+	)
+	globalUsersOrderId.LockFunc(func(m map[interface{}]interface{}) {
+		var v interface{}
+		if v, ok = m[userId]; !ok {
+			return
+		}
+
+		seq = v.(uint64)
+		m[userId] = seq + 1
+	})
+	if !ok {
+		return "", false
+	}
+
+	var (
+		action    = "C"
+		direction = "L"
+	)
+	if 2 == positionIdx {
+		direction = "S"
+	}
+	if (1 == positionIdx && "Buy" == side) || (2 == positionIdx && "Sell" == side) {
+		action = "O"
+	}
+
+	return strconv.FormatUint(uint64(userId), 36) + "_" + cycle + "_" + source + action + direction + "_" + strconv.FormatUint(seq, 36), true
+}
+
+// parseBybitOrderLinkId 解析orderLinkId，拆单的序号在最后以-分隔
+func parseBybitOrderLinkId(orderLinkId string) (*BybitOrderLink, error) {
+	res := &BybitOrderLink{Part: -1}
+
+	id := orderLinkId
+	if i := strings.LastIndex(orderLinkId, "-"); 0 <= i {
+		part, err := strconv.Atoi(orderLinkId[i+1:])
+		if nil != err {
+			return nil, errors.New("拆单序号错误：" + orderLinkId)
+		}
+		res.Part = part
+		id = orderLinkId[:i]
+	}
+
+	parts := strings.Split(id, "_")
+	if 4 != len(parts) || 3 != len(parts[2]) {
+		return nil, errors.New("orderLinkId格式错误：" + orderLinkId)
+	}
+
+	userId, err := strconv.ParseUint(parts[0], 36, 64)
+	if nil != err {
+		return nil, errors.New("用户id错误：" + orderLinkId)
+	}
+	res.UserId = uint(userId)
+
+	cycle, err := strconv.ParseInt(parts[1], 36, 64)
+	if nil != err {
+		return nil, errors.New("轮次错误：" + orderLinkId)
+	}
+	res.Cycle = time.UnixMilli(cycle)
+
+	res.Source = parts[2][:1]
+	res.Open = "O" == parts[2][1:2]
+	res.PositionSide = "LONG"
+	if "S" == parts[2][2:] {
+		res.PositionSide = "SHORT"
+	}
+
+	res.Seq, err = strconv.ParseUint(parts[3], 36, 64)
+	if nil != err {
+		return nil, errors.New("序号错误：" + orderLinkId)
+	}
+
+	return res, nil
+}
+
+// BybitOrder 订单信息
+type BybitOrder struct {
+	OrderId     string `json:"orderId"`
+	OrderLinkId string `json:"orderLinkId"`
+	Symbol      string `json:"symbol"`
+	Side        string `json:"side"`
+	PositionIdx int    `json:"positionIdx"`
+	OrderStatus string `json:"orderStatus"`
+	OrderType   string `json:"orderType"`
+	Qty         string `json:"qty"`
+	CumExecQty  string `json:"cumExecQty"`
+	AvgPrice    string `json:"avgPrice"`
+	CreatedTime string `json:"createdTime"`
+	UpdatedTime string `json:"updatedTime"`
+}
+
+type BybitOrderListResponse struct {
+	RetCode int    `json:"retCode"`
+	RetMsg  string `json:"retMsg"`
+	Result  struct {
+		List []*BybitOrder `json:"list"`
+	} `json:"result"`
+}
+
+// bybitGetOrderByLinkId 按orderLinkId查单，先查实时订单，没有时查历史订单
+func bybitGetOrderByLinkId(ctx context.Context, apiK, apiS, orderLinkId string) (*BybitOrder, error) {
+	client := bybit.NewBybitHttpClient(
+		apiK,
+		apiS,
+		bybit.WithBaseURL(bybit.MAINNET),
+	)
+
+	params := map[string]interface{}{
+		"category":    "linear",
+		"orderLinkId": orderLinkId,
+	}
+
+	for _, history := range []bool{false, true} {
+		if err := acquireBybit(apiK, false); nil != err {
+			return nil, err
+		}
+
+		var (
+			resp *bybit.ServerResponse
+			err  error
+		)
+		if history {
+			resp, err = client.NewUtaBybitServiceWithParams(params).GetOrderHistory(ctx)
+		} else {
+			resp, err = client.NewUtaBybitServiceWithParams(params).GetOpenOrders(ctx)
+		}
+		if nil != err {
+			log.Println("bybit 查单失败：", orderLinkId, err)
+			return nil, err
+		}
+		updateBybitRate(apiK, resp.RetCode)
+
+		var (
+			raw     []byte
+			listRes *BybitOrderListResponse
+		)
+		raw, err = json.Marshal(resp)
+		if nil != err {
+			return nil, err
+		}
+
+		if err = json.Unmarshal(raw, &listRes); nil != err {
+			return nil, err
+		}
+
+		if 0 != listRes.RetCode {
+			return nil, errors.New(listRes.RetMsg)
+		}
+
+		if 0 < len(listRes.Result.List) {
+			return listRes.Result.List[0], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// GetBybitOrderByLinkId 按orderLinkId查询用户订单，返回解析的下单意图和订单
+func (s *sBinanceTraderHistory) GetBybitOrderByLinkId(ctx context.Context, apiKey string, orderLinkId string) (map[string]interface{}, error) {
+	var (
+		err   error
+		users []*entity.User
+		link  *BybitOrderLink
+		order *BybitOrder
+	)
+
+	link, err = parseBybitOrderLinkId(orderLinkId)
+	if nil != err {
+		return nil, err
+	}
+
+	err = g.Model("user").Where("api_key=?", apiKey).Ctx(ctx).Scan(&users)
+	if nil != err {
+		return nil, err
+	}
+
+	if 0 >= len(users) || "bybit" != users[0].Plat {
+		return nil, errors.New("用户不存在")
+	}
+
+	if users[0].Id != link.UserId {
+		return nil, errors.New("订单不属于该用户")
+	}
+
+	order, err = bybitGetOrderByLinkId(ctx, users[0].ApiKey, users[0].ApiSecret, orderLinkId)
+	if nil != err {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"link":  link,
+		"order": order,
+	}, nil
+}
@@ -0,0 +1,211 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"encoding/json"
+	"errors"
+	bybit "github.com/bybit-exchange/bybit.go.api"
+	"github.com/gogf/gf/v2/container/gmap"
+	"github.com/shopspring/decimal"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// 单向持仓时，带单员的多空两个方向都折算成一个净仓位下单：开多和平空是买，开空和平多是卖，减少净仓位的平仓只减仓。
+// 带单员同时持有多空时，用户的净仓位是两者相抵后的结果，系统仓位仍按多空分别记录
+var (
+	accountOneWay = gmap.NewStrAnyMap(true) // 账户是否单向持仓，key为apiKey，不存在时按双向持仓
+)
+
+// isOneWay 账户是否单向持仓
+func isOneWay(apiKey string) bool {
+	if v := accountOneWay.Get(apiKey); nil != v {
+		return v.(bool)
+	}
+
+	return false
+}
+
+// oneWayCloseReduces 单向持仓下平掉positionSide一边是否减少净仓位，净仓位为系统仓位的多仓减空仓。
+// 同时持有多空时平较小的一边会增加净仓位，不能只减仓。找不到用户时按减仓
+func oneWayCloseReduces(apiKey string, symbol string, positionSide string) bool {
+	var userId uint
+	globalUsers.Iterator(func(k interface{}, v interface{}) bool {
+		if apiKey == v.(*entity.User).ApiKey {
+			userId = v.(*entity.User).Id
+			return false
+		}
+		return true
+	})
+	if 0 >= userId {
+		return true
+	}
+
+	strUserId := strconv.FormatUint(uint64(userId), 10)
+	net := orderQty(symbol + "&LONG&" + strUserId).Sub(orderQty(symbol + "&SHORT&" + strUserId))
+	if "LONG" == positionSide {
+		return net.IsPositive()
+	}
+
+	return net.IsNegative()
+}
+
+// setOneWay 记录账户持仓模式，返回是否有变化
+func setOneWay(apiKey string, oneWay bool) bool {
+	changed := isOneWay(apiKey) != oneWay
+	accountOneWay.Set(apiKey, oneWay)
+	return changed
+}
+
+// refreshPositionMode 查询账户当前持仓模式并记录，返回是否有变化
+func refreshPositionMode(ctx context.Context, user *entity.User) bool {
+	var (
+		oneWay bool
+		err    error
+	)
+	if "binance" == user.Plat {
+		oneWay, err = requestBinanceOneWay(user.ApiKey, user.ApiSecret)
+	} else if "bybit" == user.Plat {
+		oneWay, err = bybitOneWay(ctx, user.ApiKey, user.ApiSecret)
+	} else {
+		return false
+	}
+
+	if nil != err {
+		log.Println("查询持仓模式失败：", user.Id, err)
+		return false
+	}
+
+	changed := setOneWay(user.ApiKey, oneWay)
+	if changed {
+		log.Println("持仓模式变化，单向持仓：", user.Id, oneWay)
+	}
+
+	return changed
+}
+
+// netPositionSide 单向持仓的仓位按数量正负折算成多空，双向持仓原样返回
+func netPositionSide(positionSide string, amount decimal.Decimal) string {
+	if "BOTH" != positionSide {
+		return positionSide
+	}
+
+	if amount.IsNegative() {
+		return "SHORT"
+	}
+
+	return "LONG"
+}
+
+// bybitNetPositionIdx 单向持仓的仓位按持仓方向折算成1多2空，双向持仓原样返回
+func bybitNetPositionIdx(position *Position) int {
+	if 0 != position.PositionIdx {
+		return position.PositionIdx
+	}
+
+	if "Buy" == position.Side {
+		return 1
+	} else if "Sell" == position.Side {
+		return 2
+	}
+
+	return 0
+}
+
+// requestBinanceOneWay 查询binance账户是否单向持仓
+func requestBinanceOneWay(apiKey string, secretKey string) (bool, error) {
+	if err := acquireBinance(apiKey, 30, false, false); nil != err {
+		return false, err
+	}
+
+	params := url.Values{}
+	params.Set("timestamp", strconv.FormatInt(time.Now().UTC().UnixMilli(), 10))
+
+	req, err := http.NewRequest("GET", "https://fapi.binance.com/fapi/v1/positionSide/dual?"+params.Encode()+"&signature="+generateSignature(secretKey, params), nil)
+	if nil != err {
+		return false, err
+	}
+	req.Header.Set("X-MBX-APIKEY", apiKey)
+
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Do(req)
+	if nil != err {
+		return false, err
+	}
+	updateBinanceRate(apiKey, resp)
+
+	defer func() {
+		if resp != nil && resp.Body != nil {
+			err := resp.Body.Close()
+			if err != nil {
+				log.Println("关闭响应体错误：", err)
+			}
+		}
+	}()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if nil != err {
+		return false, err
+	}
+
+	var res struct {
+		DualSidePosition *bool  `json:"dualSidePosition"`
+		Code             int64  `json:"code"`
+		Msg              string `json:"msg"`
+	}
+	if err = json.Unmarshal(b, &res); nil != err {
+		return false, err
+	}
+
+	if nil == res.DualSidePosition {
+		return false, errors.New(res.Msg)
+	}
+
+	return !*res.DualSidePosition, nil
+}
+
+// bybitOneWay 查询bybit账户是否单向持仓，按币种查仓位时没有持仓也会返回，单向持仓的positionIdx为0
+func bybitOneWay(ctx context.Context, apiK, apiS string) (bool, error) {
+	client := bybit.NewBybitHttpClient(
+		apiK,
+		apiS,
+		bybit.WithBaseURL(bybit.MAINNET),
+	)
+
+	if err := acquireBybit(apiK, false); nil != err {
+		return false, err
+	}
+
+	resp, err := client.NewUtaBybitServiceWithParams(map[string]interface{}{
+		"category": "linear",
+		"symbol":   "BTCUSDT",
+	}).GetPositionList(ctx)
+	if nil != err {
+		return false, err
+	}
+	updateBybitRate(apiK, resp.RetCode)
+
+	var (
+		raw         []byte
+		positionRes *BybitPositionInfoResponse
+	)
+	raw, err = json.Marshal(resp)
+	if nil != err {
+		return false, err
+	}
+
+	if err = json.Unmarshal(raw, &positionRes); nil != err {
+		return false, err
+	}
+
+	if 0 != positionRes.RetCode || nil == positionRes.Result || 0 >= len(positionRes.Result.List) {
+		return false, errors.New("查询仓位失败：" + positionRes.RetMsg)
+	}
+
+	return 0 == positionRes.Result.List[0].PositionIdx, nil
+}
@@ -0,0 +1,295 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/do"
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"errors"
+	"github.com/gogf/gf/v2/container/gmap"
+	"github.com/gogf/gf/v2/container/gtype"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	quarantineConfig   = gtype.NewAny(defaultQuarantineConfig()) // 闪烁隔离配置
+	quarantineFlickers = gmap.NewStrAnyMap(true)                 // 统计窗口内的闪烁时间，key为symbol&positionSide
+	symbolQuarantines  = gmap.NewStrAnyMap(true)                 // 隔离中的币种方向，key为symbol&positionSide
+	quarantineLoaded   = gtype.NewBool()                         // 是否已恢复隔离中的记录
+)
+
+func defaultQuarantineConfig() *entity.SymbolQuarantineConfig {
+	return &entity.SymbolQuarantineConfig{
+		FlickerWindow: 30,
+		CountWindow:   600,
+		Threshold:     1,
+		Cooldown:      1800,
+	}
+}
+
+func getQuarantineConfig() *entity.SymbolQuarantineConfig {
+	return quarantineConfig.Val().(*entity.SymbolQuarantineConfig)
+}
+
+// loadQuarantineConfig 加载闪烁隔离配置，没有配置时用默认值，首次加载时恢复未结束的隔离
+func loadQuarantineConfig(ctx context.Context) {
+	var (
+		err     error
+		configs []*entity.SymbolQuarantineConfig
+	)
+
+	err = g.Model("symbol_quarantine_config").Ctx(ctx).OrderAsc("id").Limit(1).Scan(&configs)
+	if nil != err {
+		log.Println("闪烁隔离配置，数据库查询错误：", err)
+		return
+	}
+
+	if 0 < len(configs) && 0 < configs[0].FlickerWindow && 0 < configs[0].CountWindow && 0 < configs[0].Threshold && 0 < configs[0].Cooldown {
+		quarantineConfig.Set(configs[0])
+	} else {
+		quarantineConfig.Set(defaultQuarantineConfig())
+	}
+
+	if quarantineLoaded.Val() {
+		return
+	}
+
+	var quarantines []*entity.SymbolQuarantine
+	err = g.Model("symbol_quarantine").Ctx(ctx).WhereNull("resumed_at").Scan(&quarantines)
+	if nil != err {
+		log.Println("闪烁隔离，数据库查询错误：", err)
+		return
+	}
+
+	for _, v := range quarantines {
+		symbolQuarantines.Set(v.Symbol+"&"+v.PositionSide, v)
+	}
+	quarantineLoaded.Set(true)
+}
+
+// flickerWindow 开仓或加仓后多少秒内完全平仓算闪烁
+func flickerWindow() int64 {
+	return int64(getQuarantineConfig().FlickerWindow)
+}
+
+// recordFlicker 记录一次闪烁，统计窗口内次数达到阈值时隔离该币种方向，已隔离的延长恢复时间
+func recordFlicker(ctx context.Context, symbol string, positionSide string, openAt int64, closeAt int64) {
+	var (
+		config = getQuarantineConfig()
+		key    = symbol + "&" + positionSide
+		count  int
+	)
+
+	quarantineFlickers.LockFunc(func(m map[string]interface{}) {
+		tmp := make([]int64, 0)
+		if v, ok := m[key]; ok {
+			for _, vT := range v.([]int64) {
+				if closeAt-int64(config.CountWindow) < vT {
+					tmp = append(tmp, vT)
+				}
+			}
+		}
+
+		tmp = append(tmp, closeAt)
+		count = len(tmp)
+		if count >= config.Threshold {
+			delete(m, key)
+		} else {
+			m[key] = tmp
+		}
+	})
+
+	log.Println("闪烁计数：", key, count, config.Threshold)
+	if count < config.Threshold {
+		return
+	}
+
+	resumeAt := closeAt + int64(config.Cooldown)
+	if v := symbolQuarantines.Get(key); nil != v {
+		tmpQuarantine := v.(*entity.SymbolQuarantine)
+		if tmpQuarantine.ResumeAt >= resumeAt {
+			return
+		}
+
+		// 隔离中再次闪烁，延长恢复时间
+		symbolQuarantines.Set(key, &entity.SymbolQuarantine{
+			Id:           tmpQuarantine.Id,
+			Symbol:       tmpQuarantine.Symbol,
+			PositionSide: tmpQuarantine.PositionSide,
+			FlickerCount: tmpQuarantine.FlickerCount + count,
+			OpenAt:       openAt,
+			CloseAt:      closeAt,
+			ResumeAt:     resumeAt,
+			CreatedAt:    tmpQuarantine.CreatedAt,
+		})
+		_, err := g.Model("symbol_quarantine").Ctx(context.WithoutCancel(ctx)).Data(g.Map{
+			"flicker_count": tmpQuarantine.FlickerCount + count,
+			"open_at":       openAt,
+			"close_at":      closeAt,
+			"resume_at":     resumeAt,
+		}).Where("id=?", tmpQuarantine.Id).Update()
+		if nil != err {
+			log.Println("闪烁隔离，延长失败：", err, key)
+		}
+
+		log.Println("闪烁隔离，延长：", key, resumeAt)
+		return
+	}
+
+	tmpQuarantine := &entity.SymbolQuarantine{
+		Symbol:       symbol,
+		PositionSide: positionSide,
+		FlickerCount: count,
+		OpenAt:       openAt,
+		CloseAt:      closeAt,
+		ResumeAt:     resumeAt,
+		CreatedAt:    gtime.Now(),
+	}
+	id, err := g.Model("symbol_quarantine").Ctx(context.WithoutCancel(ctx)).InsertAndGetId(&do.SymbolQuarantine{
+		Symbol:       tmpQuarantine.Symbol,
+		PositionSide: tmpQuarantine.PositionSide,
+		FlickerCount: tmpQuarantine.FlickerCount,
+		OpenAt:       tmpQuarantine.OpenAt,
+		CloseAt:      tmpQuarantine.CloseAt,
+		ResumeAt:     tmpQuarantine.ResumeAt,
+		CreatedAt:    tmpQuarantine.CreatedAt,
+	})
+	if nil != err {
+		log.Println("闪烁隔离，记录失败：", err, key)
+	}
+	tmpQuarantine.Id = uint(id)
+
+	// 记录失败也隔离，只影响开仓和加仓
+	symbolQuarantines.Set(key, tmpQuarantine)
+	log.Println("闪烁隔离，暂停开仓和加仓：", key, count, resumeAt)
+}
+
+// symbolSideQuarantined 币种方向是否隔离中，隔离中禁止开仓和加仓，平仓不受影响。到期的自动恢复
+func symbolSideQuarantined(ctx context.Context, symbol string, positionSide string) bool {
+	v := symbolQuarantines.Get(symbol + "&" + positionSide)
+	if nil == v {
+		return false
+	}
+
+	if time.Now().Unix() >= v.(*entity.SymbolQuarantine).ResumeAt {
+		resumeQuarantine(ctx, symbol+"&"+positionSide, "auto")
+		return false
+	}
+
+	return true
+}
+
+// resumeExpiredQuarantines 恢复所有到期的隔离
+func resumeExpiredQuarantines(ctx context.Context) {
+	var (
+		now  = time.Now().Unix()
+		keys = make([]string, 0)
+	)
+	symbolQuarantines.Iterator(func(k string, v interface{}) bool {
+		if now >= v.(*entity.SymbolQuarantine).ResumeAt {
+			keys = append(keys, k)
+		}
+		return true
+	})
+
+	for _, vKeys := range keys {
+		resumeQuarantine(ctx, vKeys, "auto")
+	}
+}
+
+// resumeQuarantine 解除隔离，重复调用只处理一次
+func resumeQuarantine(ctx context.Context, key string, resumeType string) bool {
+	v := symbolQuarantines.Remove(key)
+	if nil == v {
+		return false
+	}
+
+	tmpQuarantine := v.(*entity.SymbolQuarantine)
+	if 0 < tmpQuarantine.Id {
+		_, err := g.Model("symbol_quarantine").Ctx(context.WithoutCancel(ctx)).Data(g.Map{
+			"resumed_at":  gtime.Now(),
+			"resume_type": resumeType,
+		}).Where("id=?", tmpQuarantine.Id).Update()
+		if nil != err {
+			log.Println("闪烁隔离，恢复记录失败：", err, key)
+		}
+	}
+
+	log.Println("闪烁隔离，恢复：", key, resumeType)
+	return true
+}
+
+// GetSymbolQuarantines get quarantine config, active quarantines and recent events
+func (s *sBinanceTraderHistory) GetSymbolQuarantines(ctx context.Context, limit int) map[string]interface{} {
+	resumeExpiredQuarantines(ctx)
+
+	active := make([]*entity.SymbolQuarantine, 0)
+	symbolQuarantines.Iterator(func(k string, v interface{}) bool {
+		active = append(active, v.(*entity.SymbolQuarantine))
+		return true
+	})
+
+	events := make([]*entity.SymbolQuarantine, 0)
+	err := g.Model("symbol_quarantine").Ctx(ctx).OrderDesc("id").Limit(limit).Scan(&events)
+	if nil != err {
+		log.Println("查询闪烁隔离记录，数据库查询错误：", err)
+	}
+
+	return map[string]interface{}{
+		"config": getQuarantineConfig(),
+		"active": active,
+		"events": events,
+	}
+}
+
+// SetSymbolQuarantineConfig set flicker window, count window, threshold and cooldown in seconds
+func (s *sBinanceTraderHistory) SetSymbolQuarantineConfig(ctx context.Context, flickerWindow, countWindow, threshold, cooldown int) error {
+	if 0 >= flickerWindow || 0 >= countWindow || 0 >= threshold || 0 >= cooldown {
+		return errors.New("配置必须大于0")
+	}
+
+	var (
+		err     error
+		configs []*entity.SymbolQuarantineConfig
+	)
+	err = g.Model("symbol_quarantine_config").Ctx(ctx).OrderAsc("id").Limit(1).Scan(&configs)
+	if nil != err {
+		return err
+	}
+
+	data := &do.SymbolQuarantineConfig{
+		FlickerWindow: flickerWindow,
+		CountWindow:   countWindow,
+		Threshold:     threshold,
+		Cooldown:      cooldown,
+		UpdatedAt:     gtime.Now(),
+	}
+	if 0 < len(configs) {
+		_, err = g.Model("symbol_quarantine_config").Ctx(ctx).Data(data).Where("id=?", configs[0].Id).Update()
+	} else {
+		_, err = g.Model("symbol_quarantine_config").Ctx(ctx).Insert(data)
+	}
+	if nil != err {
+		log.Println("设置闪烁隔离配置失败：", err)
+		return err
+	}
+
+	// 立即生效
+	loadQuarantineConfig(ctx)
+	return nil
+}
+
+// ResumeSymbolQuarantine resume a quarantined symbol and position side manually
+func (s *sBinanceTraderHistory) ResumeSymbolQuarantine(ctx context.Context, symbol string, positionSide string) error {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	positionSide = strings.ToUpper(strings.TrimSpace(positionSide))
+
+	if !resumeQuarantine(ctx, symbol+"&"+positionSide, "manual") {
+		return errors.New("未隔离")
+	}
+
+	return nil
+}
@@ -0,0 +1,279 @@
+package logic
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateRule 固定窗口内的请求上限
+type rateRule struct {
+	limit  int64
+	window time.Duration
+}
+
+var (
+	// 各类限频规则，binance的ip权重和账户下单数，bybit的ip和账户请求数
+	rateRules = map[string][]rateRule{
+		"binance&ip":    {{limit: 2400, window: time.Minute}},
+		"binance&order": {{limit: 300, window: 10 * time.Second}, {limit: 1200, window: time.Minute}},
+		"bybit&ip":      {{limit: 600, window: 5 * time.Second}},
+		"bybit&key":     {{limit: 10, window: time.Second}},
+	}
+
+	openRateRatio    int64 = 80                  // 开仓和查询只能用到额度的百分比，剩余留给平仓
+	openRateMaxWait        = 1 * time.Second     // 开仓和查询等待额度的上限，超过直接放弃
+	closeRateMaxWait       = 10 * time.Second    // 平仓等待额度的上限
+	rateBackoffMin         = 1 * time.Second     // 429或10006没有Retry-After时的首次退避
+	rateBackoffMax         = 2 * time.Minute     // 退避上限
+	rateBanBackoff         = 2 * time.Minute     // 418没有Retry-After时的封禁时长
+	errRateLimited         = errors.New("请求限频中") // 等待额度超时
+
+	rateMu       sync.Mutex
+	rateLimiters = make(map[string]*rateLimiter, 0) // key为规则&apiKey，ip类为规则本身
+)
+
+// rateWindow 单个窗口的计数
+type rateWindow struct {
+	rateRule
+	start time.Time
+	used  int64
+}
+
+// rateLimiter 一个ip或一个api key的限频状态
+type rateLimiter struct {
+	windows      []*rateWindow
+	until        time.Time     // 被限频或封禁时的恢复时间
+	backoff      time.Duration // 连续被限频的退避时长
+	closeWaiting int           // 等待中的平仓数，有平仓等待时开仓让出额度
+}
+
+// rateCost 一次请求在某个限频器上的消耗
+type rateCost struct {
+	name   string
+	weight int64
+}
+
+// getRateLimiter 获取限频器，不存在时按规则创建，需持有rateMu
+func getRateLimiter(name string) *rateLimiter {
+	if tmp, ok := rateLimiters[name]; ok {
+		return tmp
+	}
+
+	rule := name
+	if parts := strings.Split(name, "&"); 2 < len(parts) {
+		rule = parts[0] + "&" + parts[1]
+	}
+
+	tmp := &rateLimiter{windows: make([]*rateWindow, 0)}
+	for _, v := range rateRules[rule] {
+		tmp.windows = append(tmp.windows, &rateWindow{rateRule: v})
+	}
+
+	rateLimiters[name] = tmp
+	return tmp
+}
+
+// wait 申请额度需要等待的时长，0为可以立即申请
+func (r *rateLimiter) wait(weight int64, isClose bool, now time.Time) time.Duration {
+	if now.Before(r.until) {
+		return r.until.Sub(now)
+	}
+
+	var res time.Duration
+	for _, v := range r.windows {
+		if now.Sub(v.start) >= v.window {
+			v.start = now.Truncate(v.window)
+			v.used = 0
+		}
+
+		limit := v.limit
+		if !isClose {
+			limit = v.limit * openRateRatio / 100
+		}
+
+		// 额度不足或有平仓在等待，等到窗口结束
+		if v.used+weight > limit || (!isClose && 0 < r.closeWaiting) {
+			if tmp := v.start.Add(v.window).Sub(now); tmp > res {
+				res = tmp
+			}
+		}
+	}
+
+	return res
+}
+
+// add 使用额度
+func (r *rateLimiter) add(weight int64) {
+	for _, v := range r.windows {
+		v.used += weight
+	}
+}
+
+// acquireRate 申请额度，平仓可用全部额度且等待更久，开仓和查询额度不足时直接放弃
+func acquireRate(isClose bool, costs ...rateCost) error {
+	maxWait := openRateMaxWait
+	if isClose {
+		maxWait = closeRateMaxWait
+	}
+	deadline := time.Now().Add(maxWait)
+
+	waiting := false
+	defer func() {
+		if !waiting {
+			return
+		}
+
+		rateMu.Lock()
+		for _, v := range costs {
+			getRateLimiter(v.name).closeWaiting--
+		}
+		rateMu.Unlock()
+	}()
+
+	for {
+		now := time.Now()
+
+		rateMu.Lock()
+		var wait time.Duration
+		for _, v := range costs {
+			if tmp := getRateLimiter(v.name).wait(v.weight, isClose, now); tmp > wait {
+				wait = tmp
+			}
+		}
+
+		if 0 >= wait {
+			for _, v := range costs {
+				getRateLimiter(v.name).add(v.weight)
+			}
+			rateMu.Unlock()
+			return nil
+		}
+
+		if isClose && !waiting {
+			waiting = true
+			for _, v := range costs {
+				getRateLimiter(v.name).closeWaiting++
+			}
+		}
+		rateMu.Unlock()
+
+		if now.Add(wait).After(deadline) {
+			log.Println("限频，放弃请求：", isClose, wait)
+			return errRateLimited
+		}
+
+		time.Sleep(wait)
+	}
+}
+
+// isCloseOrder 是否平仓单，双向持仓下多仓卖出和空仓买入为平仓
+func isCloseOrder(side string, positionSide string) bool {
+	side = strings.ToUpper(side)
+	return ("LONG" == positionSide && "SELL" == side) || ("SHORT" == positionSide && "BUY" == side)
+}
+
+// isBybitCloseOrder bybit订单是否平仓，position为1多仓2空仓
+func isBybitCloseOrder(side string, position int) bool {
+	return (1 == position && "Sell" == side) || (2 == position && "Buy" == side)
+}
+
+// acquireBinance binance请求前申请额度，weight为ip权重，下单同时占用账户下单数
+func acquireBinance(apiKey string, weight int64, isOrder bool, isClose bool) error {
+	costs := []rateCost{{name: "binance&ip", weight: weight}}
+	if isOrder {
+		costs = append(costs, rateCost{name: "binance&order&" + apiKey, weight: 1})
+	}
+
+	return acquireRate(isClose, costs...)
+}
+
+// acquireBybit bybit请求前申请额度
+func acquireBybit(apiKey string, isClose bool) error {
+	return acquireRate(isClose, rateCost{name: "bybit&ip", weight: 1}, rateCost{name: "bybit&key&" + apiKey, weight: 1})
+}
+
+// pause 限频后暂停请求，retryAfter为0时按退避时长翻倍
+func (r *rateLimiter) pause(retryAfter time.Duration, now time.Time) time.Duration {
+	if 0 >= retryAfter {
+		if 0 >= r.backoff {
+			r.backoff = rateBackoffMin
+		} else if r.backoff *= 2; r.backoff > rateBackoffMax {
+			r.backoff = rateBackoffMax
+		}
+		retryAfter = r.backoff
+	}
+
+	if tmp := now.Add(retryAfter); tmp.After(r.until) {
+		r.until = tmp
+	}
+
+	return retryAfter
+}
+
+// updateBinanceRate 按响应头同步已用额度，429和418时暂停
+func updateBinanceRate(apiKey string, resp *http.Response) {
+	if nil == resp {
+		return
+	}
+
+	now := time.Now()
+
+	rateMu.Lock()
+	defer rateMu.Unlock()
+
+	ipLimiter := getRateLimiter("binance&ip")
+	if tmp, err := strconv.ParseInt(resp.Header.Get("X-MBX-USED-WEIGHT-1M"), 10, 64); nil == err {
+		ipLimiter.windows[0].used = tmp
+	}
+
+	if 0 < len(apiKey) {
+		orderLimiter := getRateLimiter("binance&order&" + apiKey)
+		if tmp, err := strconv.ParseInt(resp.Header.Get("X-MBX-ORDER-COUNT-10S"), 10, 64); nil == err {
+			orderLimiter.windows[0].used = tmp
+		}
+		if tmp, err := strconv.ParseInt(resp.Header.Get("X-MBX-ORDER-COUNT-1M"), 10, 64); nil == err {
+			orderLimiter.windows[1].used = tmp
+		}
+	}
+
+	var retryAfter time.Duration
+	if tmp, err := strconv.ParseInt(resp.Header.Get("Retry-After"), 10, 64); nil == err && 0 < tmp {
+		retryAfter = time.Duration(tmp) * time.Second
+	}
+
+	if http.StatusTooManyRequests == resp.StatusCode {
+		log.Println("binance 限频429，暂停：", ipLimiter.pause(retryAfter, now))
+	} else if http.StatusTeapot == resp.StatusCode {
+		// ip被封禁
+		if 0 >= retryAfter {
+			retryAfter = rateBanBackoff
+		}
+		log.Println("binance ip被封禁418，暂停：", ipLimiter.pause(retryAfter, now))
+	} else {
+		ipLimiter.backoff = 0
+	}
+}
+
+// updateBybitRate 按返回码暂停，10006为账户请求过于频繁，10018为ip超限
+func updateBybitRate(apiKey string, retCode int) {
+	now := time.Now()
+
+	rateMu.Lock()
+	defer rateMu.Unlock()
+
+	keyLimiter := getRateLimiter("bybit&key&" + apiKey)
+	ipLimiter := getRateLimiter("bybit&ip")
+	if 10006 == retCode {
+		log.Println("bybit 限频10006，暂停：", keyLimiter.pause(0, now))
+	} else if 10018 == retCode {
+		log.Println("bybit ip超限10018，暂停：", ipLimiter.pause(0, now))
+	} else {
+		keyLimiter.backoff = 0
+		ipLimiter.backoff = 0
+	}
+}
@@ -0,0 +1,155 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"errors"
+	"github.com/shopspring/decimal"
+	"log"
+)
+
+var (
+	binanceReduceRejectedCodes = map[int64]bool{-2022: true, -4118: true} // 平仓数量超过实际持仓
+	bybitReduceRejectedCodes   = map[int]bool{110017: true}               // reduce-only数量超过实际持仓或仓位为0
+)
+
+// livePositionQty 交易所实际持仓数量，绝对值
+func livePositionQty(ctx context.Context, user *entity.User, symbol string, positionSide string) (decimal.Decimal, error) {
+	if "binance" == user.Plat {
+		positions := getBinancePositionInfo(user.ApiKey, user.ApiSecret)
+		if nil == positions {
+			return decimal.Zero, errors.New("查询仓位失败")
+		}
+
+		for _, v := range positions {
+			if symbol != v.Symbol {
+				continue
+			}
+
+			tmp, err := decimal.NewFromString(v.PositionAmt)
+			if nil != err {
+				return decimal.Zero, err
+			}
+
+			// 单向持仓按正负折算成多空
+			if tmp.IsZero() || positionSide != netPositionSide(v.PositionSide, tmp) {
+				continue
+			}
+
+			return tmp.Abs(), nil
+		}
+
+		return decimal.Zero, nil
+	} else if "bybit" == user.Plat {
+		positionRes, err := bybitGetPositionInfo(ctx, user.ApiKey, user.ApiSecret)
+		if nil != err {
+			return decimal.Zero, err
+		}
+
+		if nil == positionRes || 0 != positionRes.RetCode || nil == positionRes.Result {
+			return decimal.Zero, errors.New("查询仓位失败")
+		}
+
+		positionIdx := 1
+		if "SHORT" == positionSide {
+			positionIdx = 2
+		}
+		for _, v := range positionRes.Result.List {
+			if symbol != v.Symbol || positionIdx != bybitNetPositionIdx(v) {
+				continue
+			}
+
+			tmp, err := decimal.NewFromString(v.Size)
+			if nil != err {
+				return decimal.Zero, err
+			}
+
+			return tmp.Abs(), nil
+		}
+
+		return decimal.Zero, nil
+	}
+
+	return decimal.Zero, errors.New("平台错误")
+}
+
+// closeLivePosition 平仓数量超过实际持仓被拒绝时，重新读取实际持仓，只平剩余的部分。
+// 返回平仓后的实际持仓，用于校正系统仓位，查询仓位失败时返回false
+func closeLivePosition(ctx context.Context, user *entity.User, symbol string, positionSide string, cycle string, source string, requested decimal.Decimal) (decimal.Decimal, bool) {
+	live, err := livePositionQty(ctx, user, symbol, positionSide)
+	if nil != err {
+		log.Println("平仓被拒绝，查询实际持仓失败：", err, user.Id, symbol, positionSide)
+		return decimal.Zero, false
+	}
+
+	if !live.IsPositive() {
+		log.Println("平仓被拒绝，实际已无持仓：", user.Id, symbol, positionSide, requested)
+		return decimal.Zero, true
+	}
+
+	qty := decimal.Min(requested, live)
+	quantity := formatQty(user.Plat, symbol, qty)
+	log.Println("平仓被拒绝，按实际持仓平仓：", user.Id, symbol, positionSide, requested, live, quantity)
+
+	var (
+		side      = "SELL"
+		sideOrder = "Sell"
+		idx       = 1
+		placed    = qty
+	)
+	if "SHORT" == positionSide {
+		side = "BUY"
+		sideOrder = "Buy"
+		idx = 2
+	}
+
+	if "binance" == user.Plat {
+		binanceOrderRes, orderInfoRes, err := requestBinanceOrderSplit(symbol, side, "MARKET", positionSide, quantity, binanceClientOrderId(cycle, user.Id, symbol, positionSide, side)+"-r", user.ApiKey, user.ApiSecret)
+		if nil == binanceOrderRes || 0 >= binanceOrderRes.OrderId {
+			log.Println("按实际持仓平仓失败：", err, user.Id, symbol, positionSide, quantity, orderInfoRes)
+			return live, true
+		}
+		if nil != err {
+			log.Println("按实际持仓平仓，部分结果未知：", err, user.Id, symbol, positionSide, quantity, binanceOrderRes.placedQty)
+		}
+
+		if binanceOrderRes.placedQty.IsPositive() {
+			placed = binanceOrderRes.placedQty
+		}
+	} else {
+		orderLinkId, ok := nextBybitOrderLinkId(user.Id, cycle, source, sideOrder, idx)
+		if !ok {
+			return live, true
+		}
+
+		resOrder, err := bybitPlaceOrderSplit(ctx, user.ApiKey, user.ApiSecret, symbol, quantity, sideOrder, idx, orderLinkId)
+		if nil == resOrder || 0 != resOrder.RetCode || 0 >= len(resOrder.Result.OrderId) {
+			log.Println("bybit 按实际持仓平仓失败：", err, user.Id, symbol, positionSide, quantity, resOrder)
+			return live, true
+		}
+		if nil != err {
+			log.Println("bybit 按实际持仓平仓，部分结果未知：", err, user.Id, symbol, positionSide, quantity, resOrder.placedQty)
+		}
+
+		if resOrder.placedQty.IsPositive() {
+			placed = resOrder.placedQty
+		}
+	}
+
+	return live.Sub(placed), true
+}
+
+// reduceToLivePosition 按实际持仓平仓，并把系统仓位校正为平仓后的实际持仓
+func reduceToLivePosition(ctx context.Context, user *entity.User, symbol string, positionSide string, cycle string, source string, requested decimal.Decimal, strUserId string) {
+	remaining, ok := closeLivePosition(ctx, user, symbol, positionSide, cycle, source, requested)
+	if !ok {
+		return
+	}
+
+	key := symbol + "&" + positionSide + "&" + strUserId
+	log.Println("按实际持仓校正系统仓位：", key, orderQty(key), remaining)
+	setOrderQty(key, remaining)
+	if !remaining.IsPositive() {
+		orderMapTmp.Set(key, decimal.Zero)
+	}
+}
@@ -0,0 +1,406 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/do"
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gogf/gf/v2/container/gmap"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/shopspring/decimal"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	globalUserRiskLimits     = gmap.New(true)          // 用户风控配置
+	availableMoneyUserAllMap = gmap.NewIntAnyMap(true) // 用户可用保证金
+	availableMoneyUpdatedAt  = gmap.NewIntAnyMap(true) // 用户可用保证金的更新时间
+	markPriceMap             = gmap.NewStrAnyMap(true) // 标记价格
+)
+
+// riskPending 本轮已放行但未成交的开仓，避免同一轮多个开仓合计超限
+type riskPending struct {
+	notional  decimal.Decimal
+	symbols   map[string]decimal.Decimal
+	positions map[string]bool
+}
+
+func newRiskPending() *riskPending {
+	return &riskPending{
+		symbols:   make(map[string]decimal.Decimal, 0),
+		positions: make(map[string]bool, 0),
+	}
+}
+
+// setAvailableMoney 记录用户可用保证金
+func setAvailableMoney(userId uint, available string) {
+	if 0 >= len(available) {
+		return
+	}
+
+	tmp, err := decimal.NewFromString(available)
+	if nil != err {
+		log.Println("可用保证金，转化失败：", err, userId, available)
+		return
+	}
+
+	availableMoneyUserAllMap.Set(int(userId), tmp)
+	availableMoneyUpdatedAt.Set(int(userId), time.Now())
+}
+
+// availableMoneyAge 可用保证金距上次更新的时长，没有记录时返回false
+func availableMoneyAge(userId uint) (time.Duration, bool) {
+	v := availableMoneyUpdatedAt.Get(int(userId))
+	if nil == v {
+		return 0, false
+	}
+
+	return time.Since(v.(time.Time)), true
+}
+
+// freshAvailableMoney 有效时长内的可用保证金，没有记录或过期时返回false
+func freshAvailableMoney(userId uint) (decimal.Decimal, bool) {
+	age, ok := availableMoneyAge(userId)
+	if !ok || age > moneyStaleAfter {
+		return decimal.Zero, false
+	}
+
+	v := availableMoneyUserAllMap.Get(int(userId))
+	if nil == v {
+		return decimal.Zero, false
+	}
+
+	return v.(decimal.Decimal), true
+}
+
+// getMarkPrice 标记价格，不存在时返回0
+func getMarkPrice(symbol string) float64 {
+	if !markPriceMap.Contains(symbol) {
+		return 0
+	}
+
+	return markPriceMap.Get(symbol).(float64)
+}
+
+// UpdateMarkPrice 更新标记价格
+func (s *sBinanceTraderHistory) UpdateMarkPrice(ctx context.Context) {
+	prices, err := getBinanceMarkPrices()
+	if nil != err {
+		log.Println("更新标记价格失败：", err)
+		return
+	}
+
+	for _, v := range prices {
+		var tmp float64
+		tmp, err = strconv.ParseFloat(v.MarkPrice, 64)
+		if nil != err || 0 >= tmp {
+			continue
+		}
+
+		markPriceMap.Set(v.Symbol, tmp)
+	}
+}
+
+// loadUserRiskLimits 加载用户风控配置
+func loadUserRiskLimits(ctx context.Context) {
+	var (
+		err    error
+		limits []*entity.UserRiskLimit
+	)
+
+	err = g.Model("user_risk_limit").Ctx(ctx).Scan(&limits)
+	if nil != err {
+		log.Println("风控配置，数据库查询错误：", err)
+		return
+	}
+
+	tmpLimitMap := make(map[uint]*entity.UserRiskLimit, 0)
+	for _, v := range limits {
+		tmpLimitMap[v.UserId] = v
+		globalUserRiskLimits.Set(v.UserId, v)
+	}
+
+	tmpIds := make([]uint, 0)
+	globalUserRiskLimits.Iterator(func(k interface{}, v interface{}) bool {
+		if _, ok := tmpLimitMap[k.(uint)]; !ok {
+			tmpIds = append(tmpIds, k.(uint))
+		}
+		return true
+	})
+
+	for _, vTmpIds := range tmpIds {
+		globalUserRiskLimits.Remove(vTmpIds)
+	}
+}
+
+// userExposure 用户当前系统仓位的名义价值和持仓数
+func userExposure(userId uint) (total decimal.Decimal, symbols map[string]decimal.Decimal, positions map[string]bool) {
+	symbols = make(map[string]decimal.Decimal, 0)
+	positions = make(map[string]bool, 0)
+	suffix := "&" + strconv.FormatUint(uint64(userId), 10)
+
+	orderMap.Iterator(func(k interface{}, v interface{}) bool {
+		if !strings.HasSuffix(k.(string), suffix) {
+			return true
+		}
+
+		parts := strings.Split(k.(string), "&")
+		if 3 != len(parts) {
+			return true
+		}
+
+		amount := v.(decimal.Decimal).Abs()
+		if amount.IsZero() {
+			return true
+		}
+
+		notional := amount.Mul(decimal.NewFromFloat(getMarkPrice(parts[0])))
+		total = total.Add(notional)
+		symbols[parts[0]] = symbols[parts[0]].Add(notional)
+		positions[parts[0]+"&"+parts[1]] = true
+		return true
+	})
+
+	return total, symbols, positions
+}
+
+// checkOpenRisk 开仓前风控，返回允许的开仓数量，0为跳过
+func checkOpenRisk(ctx context.Context, user *entity.User, symbol, positionSide string, qty decimal.Decimal, pending *riskPending) decimal.Decimal {
+	if !globalUserRiskLimits.Contains(user.Id) {
+		return qty
+	}
+	limit := globalUserRiskLimits.Get(user.Id).(*entity.UserRiskLimit)
+
+	var (
+		allowQty = qty
+		reasons  = make([]string, 0)
+	)
+
+	// 可用保证金
+	if 0 < limit.MinFreeMargin {
+		available, ok := freshAvailableMoney(user.Id)
+		if !ok {
+			recordRiskDecision(ctx, user, symbol, positionSide, qty, decimal.Zero, "可用保证金未知或过期")
+			return decimal.Zero
+		}
+
+		if available.LessThan(decimal.NewFromFloat(limit.MinFreeMargin)) {
+			recordRiskDecision(ctx, user, symbol, positionSide, qty, decimal.Zero, fmt.Sprintf("可用保证金不足：%v<%v", available, limit.MinFreeMargin))
+			return decimal.Zero
+		}
+	}
+
+	total, symbols, positions := userExposure(user.Id)
+
+	// 持仓数
+	if 0 < limit.MaxPositions && !positions[symbol+"&"+positionSide] && !pending.positions[symbol+"&"+positionSide] {
+		if len(positions)+len(pending.positions) >= limit.MaxPositions {
+			recordRiskDecision(ctx, user, symbol, positionSide, qty, decimal.Zero, fmt.Sprintf("持仓数已达上限：%d", limit.MaxPositions))
+			return decimal.Zero
+		}
+	}
+
+	// 名义价值相关的限制都需要价格
+	if 0 < limit.MaxSymbolNotional || 0 < limit.MaxTotalNotional || 0 < limit.MaxLeverage {
+		price := decimal.NewFromFloat(getMarkPrice(symbol))
+		if !price.IsPositive() {
+			recordRiskDecision(ctx, user, symbol, positionSide, qty, decimal.Zero, "无标记价格")
+			return decimal.Zero
+		}
+
+		allowNotional := qty.Mul(price)
+		clip := func(remain decimal.Decimal, reason string) {
+			if remain.LessThan(allowNotional) {
+				allowNotional = decimal.Max(remain, decimal.Zero)
+				reasons = append(reasons, reason)
+			}
+		}
+
+		if 0 < limit.MaxSymbolNotional {
+			clip(decimal.NewFromFloat(limit.MaxSymbolNotional).Sub(symbols[symbol]).Sub(pending.symbols[symbol]), fmt.Sprintf("单币种名义价值上限：%v", limit.MaxSymbolNotional))
+		}
+
+		if 0 < limit.MaxTotalNotional {
+			clip(decimal.NewFromFloat(limit.MaxTotalNotional).Sub(total).Sub(pending.notional), fmt.Sprintf("总名义价值上限：%v", limit.MaxTotalNotional))
+		}
+
+		if margin, ok := userBaseMoney(user.Id); 0 < limit.MaxLeverage && ok && 0 < user.Num {
+			// 保证金存储的是乘以num后的值
+			margin = margin.Div(decimal.NewFromFloat(user.Num))
+			clip(decimal.NewFromFloat(limit.MaxLeverage).Mul(margin).Sub(total).Sub(pending.notional), fmt.Sprintf("有效杠杆上限：%v", limit.MaxLeverage))
+		}
+
+		allowQty = allowNotional.Div(price)
+		pending.notional = pending.notional.Add(allowNotional)
+		pending.symbols[symbol] = pending.symbols[symbol].Add(allowNotional)
+	}
+
+	if !allowQty.IsPositive() {
+		recordRiskDecision(ctx, user, symbol, positionSide, qty, decimal.Zero, strings.Join(reasons, "，"))
+		return decimal.Zero
+	}
+
+	pending.positions[symbol+"&"+positionSide] = true
+	if 0 < len(reasons) {
+		recordRiskDecision(ctx, user, symbol, positionSide, qty, allowQty, strings.Join(reasons, "，"))
+	}
+
+	return allowQty
+}
+
+// recordRiskDecision 记录风控结果，异步落库不阻塞下单
+func recordRiskDecision(ctx context.Context, user *entity.User, symbol, positionSide string, qty, allowQty decimal.Decimal, reason string) {
+	log.Println("风控：", user.Id, symbol, positionSide, qty, allowQty, reason)
+
+	go func() {
+		_, err := g.Model("user_risk_log").Ctx(context.WithoutCancel(ctx)).Insert(&do.UserRiskLog{
+			UserId:       user.Id,
+			Symbol:       symbol,
+			PositionSide: positionSide,
+			Qty:          qty.String(),
+			AllowQty:     allowQty.String(),
+			Reason:       reason,
+			CreatedAt:    gtime.Now(),
+		})
+		if nil != err {
+			log.Println("风控记录落库失败：", err)
+		}
+	}()
+}
+
+// GetUserRiskLimit get user risk limit
+func (s *sBinanceTraderHistory) GetUserRiskLimit(ctx context.Context, apiKey string) *entity.UserRiskLimit {
+	var (
+		err   error
+		users []*entity.User
+		limit *entity.UserRiskLimit
+	)
+
+	err = g.Model("user").Where("api_key=?", apiKey).Ctx(ctx).Scan(&users)
+	if nil != err || 0 >= len(users) {
+		log.Println("查看风控配置，数据库查询错误：", err)
+		return nil
+	}
+
+	err = g.Model("user_risk_limit").Where("user_id=?", users[0].Id).Ctx(ctx).Scan(&limit)
+	if nil != err {
+		log.Println("查看风控配置，数据库查询错误：", err)
+		return nil
+	}
+
+	return limit
+}
+
+// SetUserRiskLimit set user risk limit
+func (s *sBinanceTraderHistory) SetUserRiskLimit(ctx context.Context, apiKey string, maxSymbolNotional, maxTotalNotional float64, maxPositions int, maxLeverage, minFreeMargin float64, leverageCap int) error {
+	var (
+		err   error
+		users []*entity.User
+	)
+
+	err = g.Model("user").Where("api_key=?", apiKey).Ctx(ctx).Scan(&users)
+	if nil != err {
+		log.Println("设置风控配置，数据库查询错误：", err)
+		return err
+	}
+
+	if 0 >= len(users) {
+		return fmt.Errorf("用户不存在：%s", apiKey)
+	}
+
+	_, err = g.Model("user_risk_limit").Ctx(ctx).Data(&do.UserRiskLimit{
+		UserId:            users[0].Id,
+		MaxSymbolNotional: maxSymbolNotional,
+		MaxTotalNotional:  maxTotalNotional,
+		MaxPositions:      maxPositions,
+		MaxLeverage:       maxLeverage,
+		MinFreeMargin:     minFreeMargin,
+		LeverageCap:       leverageCap,
+		CreatedAt:         gtime.Now(),
+		UpdatedAt:         gtime.Now(),
+	}).OnDuplicate("max_symbol_notional", "max_total_notional", "max_positions", "max_leverage", "min_free_margin", "leverage_cap", "updated_at").Save()
+	if nil != err {
+		log.Println("设置风控配置失败：", err)
+		return err
+	}
+
+	loadUserRiskLimits(ctx)
+	return nil
+}
+
+// GetUserRiskLogs get user risk logs
+func (s *sBinanceTraderHistory) GetUserRiskLogs(ctx context.Context, apiKey string, limit int) []*entity.UserRiskLog {
+	var (
+		err   error
+		users []*entity.User
+		logs  []*entity.UserRiskLog
+	)
+	logs = make([]*entity.UserRiskLog, 0)
+
+	err = g.Model("user").Where("api_key=?", apiKey).Ctx(ctx).Scan(&users)
+	if nil != err || 0 >= len(users) {
+		log.Println("查看风控记录，数据库查询错误：", err)
+		return logs
+	}
+
+	err = g.Model("user_risk_log").Where("user_id=?", users[0].Id).Ctx(ctx).
+		OrderDesc("id").Limit(limit).Scan(&logs)
+	if nil != err {
+		log.Println("查看风控记录，数据库查询错误：", err)
+	}
+
+	return logs
+}
+
+// BinanceMarkPrice 标记价格
+type BinanceMarkPrice struct {
+	Symbol    string `json:"symbol"`
+	MarkPrice string `json:"markPrice"`
+}
+
+// 获取 Binance U 本位合约标记价格
+func getBinanceMarkPrices() ([]*BinanceMarkPrice, error) {
+	apiUrl := "https://fapi.binance.com/fapi/v1/premiumIndex"
+
+	// 全部币种权重10
+	if err := acquireBinance("", 10, false, false); nil != err {
+		return nil, err
+	}
+
+	// 发送 HTTP GET 请求
+	resp, err := http.Get(apiUrl)
+	if err != nil {
+		return nil, err
+	}
+	updateBinanceRate("", resp)
+	defer func() {
+		if resp != nil && resp.Body != nil {
+			err := resp.Body.Close()
+			if err != nil {
+				log.Println("关闭响应体错误：", err)
+			}
+		}
+	}()
+
+	// 读取响应体
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析 JSON 响应
+	var prices []*BinanceMarkPrice
+	err = json.Unmarshal(body, &prices)
+	if err != nil {
+		return nil, err
+	}
+
+	return prices, nil
+}
@@ -0,0 +1,170 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/do"
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"errors"
+	"github.com/gogf/gf/v2/container/gmap"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/shopspring/decimal"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// beginTask 登记一个进行中的任务，结束时调用s.running.Done()，退出中返回false
+func (s *sBinanceTraderHistory) beginTask(ctx context.Context) bool {
+	s.runningMu.Lock()
+	defer s.runningMu.Unlock()
+
+	if s.closing || nil != ctx.Err() {
+		return false
+	}
+
+	s.running.Add(1)
+	return true
+}
+
+// Shutdown 等待进行中的下单任务，超时后不再等待，系统仓位落库
+func (s *sBinanceTraderHistory) Shutdown(ctx context.Context, timeout time.Duration) {
+	// 先标记退出，之后不再登记任务，再等待
+	s.runningMu.Lock()
+	s.closing = true
+	s.runningMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Println("退出，下单任务已全部完成")
+	case <-time.After(timeout):
+		log.Println("退出，等待下单任务超时：", timeout)
+	}
+
+	if err := s.FlushSystemPositions(ctx); nil != err {
+		log.Println("退出，系统仓位落库失败：", err)
+		return
+	}
+
+	log.Println("退出，系统仓位落库成功")
+}
+
+// FlushSystemPositions 系统仓位落库
+func (s *sBinanceTraderHistory) FlushSystemPositions(ctx context.Context) error {
+	var (
+		now       = gtime.Now()
+		positions = make(map[string]*do.UserSystemPosition, 0)
+	)
+
+	collect := func(m *gmap.Map, isTmp bool) {
+		m.Iterator(func(k interface{}, v interface{}) bool {
+			parts := strings.Split(k.(string), "&")
+			if 3 != len(parts) {
+				return true
+			}
+
+			uid, err := strconv.ParseUint(parts[2], 10, 64)
+			if nil != err {
+				log.Println("系统仓位落库，解析id错误:", k)
+				return true
+			}
+
+			if _, ok := positions[k.(string)]; !ok {
+				positions[k.(string)] = &do.UserSystemPosition{
+					UserId:       uid,
+					Symbol:       parts[0],
+					PositionSide: parts[1],
+					Amount:       "0",
+					TmpAmount:    "0",
+					UpdatedAt:    now,
+				}
+			}
+
+			if isTmp {
+				positions[k.(string)].TmpAmount = v.(decimal.Decimal).String()
+			} else {
+				positions[k.(string)].Amount = v.(decimal.Decimal).String()
+			}
+
+			return true
+		})
+	}
+	collect(orderMap, false)
+	collect(orderMapTmp, true)
+
+	data := make([]*do.UserSystemPosition, 0, len(positions))
+	for _, v := range positions {
+		data = append(data, v)
+	}
+
+	return g.DB().Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		_, err := tx.Ctx(ctx).Delete("user_system_position", "id>?", 0)
+		if nil != err {
+			return err
+		}
+
+		if 0 >= len(data) {
+			return nil
+		}
+
+		_, err = tx.Ctx(ctx).Insert("user_system_position", data)
+		return err
+	})
+}
+
+// LoadSystemPositions 启动时恢复落库的系统仓位，只恢复api可用的用户
+func (s *sBinanceTraderHistory) LoadSystemPositions(ctx context.Context) error {
+	var (
+		err       error
+		users     []*entity.User
+		positions gdb.Result
+	)
+
+	if 0 < orderMap.Size() || 0 < orderMapTmp.Size() {
+		return errors.New("系统仓位已存在，不恢复")
+	}
+
+	err = g.Model("user").Ctx(ctx).Where("api_status=?", 1).Scan(&users)
+	if nil != err {
+		return err
+	}
+
+	if 0 >= len(users) {
+		return nil
+	}
+
+	userIds := make([]uint, 0, len(users))
+	for _, v := range users {
+		userIds = append(userIds, v.Id)
+	}
+
+	// 按字符串读取数量，避免精度丢失
+	positions, err = g.Model("user_system_position").Ctx(ctx).WhereIn("user_id", userIds).All()
+	if nil != err {
+		return err
+	}
+
+	for _, v := range positions {
+		amount, errAmount := decimal.NewFromString(v["amount"].String())
+		tmpAmount, errTmpAmount := decimal.NewFromString(v["tmp_amount"].String())
+		if nil != errAmount || nil != errTmpAmount {
+			log.Println("恢复系统仓位，数量解析错误：", v, errAmount, errTmpAmount)
+			continue
+		}
+
+		key := v["symbol"].String() + "&" + v["position_side"].String() + "&" + v["user_id"].String()
+		orderMap.Set(key, amount)
+		orderMapTmp.Set(key, tmpAmount)
+	}
+
+	log.Println("恢复系统仓位：", len(positions))
+	return nil
+}
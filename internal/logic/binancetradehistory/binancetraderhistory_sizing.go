@@ -0,0 +1,226 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/do"
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"errors"
+	"github.com/gogf/gf/v2/container/gtype"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/shopspring/decimal"
+	"log"
+)
+
+// 下单数量方式
+const (
+	sizingRatio          = "ratio"           // 带单员数量 * (用户保证金/带单员保证金)，默认
+	sizingFixedNotional  = "fixed_notional"  // 每笔固定名义价值
+	sizingEquityFraction = "equity_fraction" // 每笔使用用户保证金的固定比例，按带单员仓位的名义价值/保证金放大
+	sizingTraderMultiple = "trader_multiple" // 带单员数量的固定倍数
+	sizingCappedRatio    = "capped_ratio"    // 按保证金比例，比例不超过value
+)
+
+var (
+	userSizings = gtype.NewAny(make(map[uint]*entity.UserSizing, 0)) // 下单数量方式，key为用户id，0为所有用户
+)
+
+// loadUserSizings 加载下单数量方式，替换内存中的全部配置
+func loadUserSizings(ctx context.Context) {
+	var (
+		err     error
+		sizings []*entity.UserSizing
+	)
+
+	err = g.Model("user_sizing").Ctx(ctx).Scan(&sizings)
+	if nil != err {
+		log.Println("下单数量方式，数据库查询错误：", err)
+		return
+	}
+
+	tmpSizings := make(map[uint]*entity.UserSizing, 0)
+	for _, v := range sizings {
+		tmpSizings[v.UserId] = v
+	}
+
+	userSizings.Set(tmpSizings)
+}
+
+// userSizingOf 用户的下单数量方式，用户，全局依次匹配，没有配置时返回nil
+func userSizingOf(userId uint) *entity.UserSizing {
+	sizings := userSizings.Val().(map[uint]*entity.UserSizing)
+	if v, ok := sizings[userId]; ok {
+		return v
+	}
+	if v, ok := sizings[0]; ok {
+		return v
+	}
+
+	return nil
+}
+
+// traderLeverageOf 带单员仓位的杠杆，即名义价值/保证金，没有记录时为1
+func traderLeverageOf(symbol string, positionSide string) decimal.Decimal {
+	if v := traderPrices.Get(symbol + "&" + positionSide); nil != v && 0 < v.(*traderPrice).Leverage {
+		return decimal.NewFromInt(int64(v.(*traderPrice).Leverage))
+	}
+
+	return decimal.NewFromInt(1)
+}
+
+// clampSizingQty 按每笔最小最大名义价值限制数量，没有价格时不限制
+func clampSizingQty(sizing *entity.UserSizing, qty decimal.Decimal, price decimal.Decimal) decimal.Decimal {
+	if !qty.IsPositive() || !price.IsPositive() {
+		return qty
+	}
+
+	if 0 < sizing.MaxNotional {
+		if maxQty := decimal.NewFromFloat(sizing.MaxNotional).Div(price); qty.GreaterThan(maxQty) {
+			qty = maxQty
+		}
+	}
+	if 0 < sizing.MinNotional {
+		if minQty := decimal.NewFromFloat(sizing.MinNotional).Div(price); qty.LessThan(minQty) {
+			qty = minQty
+		}
+	}
+
+	return qty
+}
+
+// moneyStale 按保证金计算数量时保证金是否过期，过期时跳过本次开仓加仓
+func moneyStale(userId uint, needTrader bool) bool {
+	if !userMoneyFresh(userId) {
+		log.Println("用户保证金过期，跳过：", userId)
+		return true
+	}
+
+	if needTrader && !traderMoneyFresh() {
+		log.Println("带单员保证金过期，跳过：", userId)
+		return true
+	}
+
+	return false
+}
+
+// userSizingQty 按用户的下单数量方式计算开仓加仓数量。
+// traderQty为带单员本次开仓加仓的数量，traderOldQty为之前的持仓，held为用户当前的系统仓位。
+// 按笔计算的方式（固定名义价值，固定保证金比例）加仓时按用户持仓和带单员持仓的比例跟随，没有持仓时按开仓计算。
+// factor为滑点保护的数量比例，在名义价值限制之前计算，减少后的数量仍满足每笔最小名义价值
+func userSizingQty(userId uint, symbol string, positionSide string, traderQty decimal.Decimal, traderOldQty decimal.Decimal, held decimal.Decimal, userMoney decimal.Decimal, traderMoney decimal.Decimal, factor decimal.Decimal) decimal.Decimal {
+	sizing := userSizingOf(userId)
+	if nil == sizing {
+		if moneyStale(userId, true) {
+			return decimal.Zero
+		}
+
+		return sizingQty(traderQty, userMoney, traderMoney).Mul(factor)
+	}
+
+	var (
+		qty   decimal.Decimal
+		value = decimal.NewFromFloat(sizing.Value)
+		price = decimal.NewFromFloat(getMarkPrice(symbol))
+	)
+	traderQty = traderQty.Abs()
+	traderOldQty = traderOldQty.Abs()
+
+	switch sizing.Mode {
+	case sizingFixedNotional, sizingEquityFraction:
+		if traderOldQty.IsPositive() && held.IsPositive() {
+			qty = held.Mul(traderQty).Div(traderOldQty)
+			break
+		}
+
+		if !price.IsPositive() {
+			log.Println("下单数量方式，没有价格：", userId, symbol, sizing.Mode)
+			return decimal.Zero
+		}
+
+		if sizingFixedNotional == sizing.Mode {
+			qty = value.Div(price)
+		} else {
+			if moneyStale(userId, false) {
+				return decimal.Zero
+			}
+
+			qty = userMoney.Mul(value).Mul(traderLeverageOf(symbol, positionSide)).Div(price)
+		}
+	case sizingTraderMultiple:
+		qty = traderQty.Mul(value)
+	case sizingCappedRatio:
+		if !traderMoney.IsPositive() || moneyStale(userId, true) {
+			return decimal.Zero
+		}
+
+		ratio := userMoney.Div(traderMoney)
+		if ratio.GreaterThan(value) {
+			ratio = value
+		}
+		qty = traderQty.Mul(ratio)
+	default:
+		if moneyStale(userId, true) {
+			return decimal.Zero
+		}
+
+		qty = sizingQty(traderQty, userMoney, traderMoney)
+	}
+
+	return clampSizingQty(sizing, qty.Mul(factor), price)
+}
+
+// GetUserSizings get sizing mode of every configured user, userId 0 applies to users without their own
+func (s *sBinanceTraderHistory) GetUserSizings(ctx context.Context) []*entity.UserSizing {
+	res := make([]*entity.UserSizing, 0)
+	for _, v := range userSizings.Val().(map[uint]*entity.UserSizing) {
+		res = append(res, v)
+	}
+
+	return res
+}
+
+// SetUserSizing set the user's sizing mode with its value and per order min and max notional, userId 0 applies to all users
+func (s *sBinanceTraderHistory) SetUserSizing(ctx context.Context, userId uint, mode string, value float64, minNotional float64, maxNotional float64) error {
+	switch mode {
+	case sizingRatio:
+	case sizingFixedNotional, sizingEquityFraction, sizingTraderMultiple, sizingCappedRatio:
+		if 0 >= value {
+			return errors.New("数值错误")
+		}
+	default:
+		return errors.New("下单数量方式错误")
+	}
+
+	if 0 > minNotional || 0 > maxNotional || (0 < maxNotional && minNotional > maxNotional) {
+		return errors.New("名义价值限制错误")
+	}
+
+	_, err := g.Model("user_sizing").Ctx(ctx).Data(&do.UserSizing{
+		UserId:      userId,
+		Mode:        mode,
+		Value:       value,
+		MinNotional: minNotional,
+		MaxNotional: maxNotional,
+		UpdatedAt:   gtime.Now(),
+	}).Save()
+	if nil != err {
+		log.Println("设置下单数量方式失败：", err)
+		return err
+	}
+
+	// 立即生效
+	loadUserSizings(ctx)
+	return nil
+}
+
+// DeleteUserSizing delete the user's sizing mode, the user falls back to the global one or margin ratio
+func (s *sBinanceTraderHistory) DeleteUserSizing(ctx context.Context, userId uint) error {
+	_, err := g.Model("user_sizing").Ctx(ctx).Where("user_id=?", userId).Delete()
+	if nil != err {
+		log.Println("删除下单数量方式失败：", err)
+		return err
+	}
+
+	loadUserSizings(ctx)
+	return nil
+}
@@ -0,0 +1,248 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/do"
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"errors"
+	"github.com/gogf/gf/v2/container/gmap"
+	"github.com/gogf/gf/v2/container/gtype"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/shopspring/decimal"
+	"log"
+	"math"
+	"strconv"
+	"time"
+)
+
+var (
+	slippageGuardConfig = gtype.NewAny(&entity.SlippageGuardConfig{}) // 滑点保护配置，默认关闭
+	traderPrices        = gmap.NewStrAnyMap(true)                     // 带单员仓位价格，key为symbol&positionSide，单向持仓按正负拆成多空
+)
+
+// traderPrice 带单员仓位的价格信息
+type traderPrice struct {
+	Symbol           string    `json:"symbol"`
+	PositionSide     string    `json:"positionSide"`     // LONG，SHORT
+	Amount           float64   `json:"amount"`           // 仓位数量，绝对值
+	EntryPrice       float64   `json:"entryPrice"`       // 开仓均价
+	MarkPrice        float64   `json:"markPrice"`        // 拉取时的标记价格
+	Leverage         int       `json:"leverage"`         // 杠杆
+	UnrealizedProfit float64   `json:"unrealizedProfit"` // 未实现盈亏
+	FillPrice        float64   `json:"fillPrice"`        // 最近一次开仓或加仓的成交价，加仓按均价变化倒推
+	FillAt           time.Time `json:"fillAt"`           // 最近一次开仓或加仓拉到的时间
+}
+
+// loadSlippageGuardConfig 加载滑点保护配置，没有配置时关闭
+func loadSlippageGuardConfig(ctx context.Context) {
+	var (
+		err     error
+		configs []*entity.SlippageGuardConfig
+	)
+
+	err = g.Model("slippage_guard_config").Ctx(ctx).OrderAsc("id").Limit(1).Scan(&configs)
+	if nil != err {
+		log.Println("滑点保护配置，数据库查询错误：", err)
+		return
+	}
+
+	if 0 < len(configs) {
+		slippageGuardConfig.Set(configs[0])
+	} else {
+		slippageGuardConfig.Set(&entity.SlippageGuardConfig{})
+	}
+}
+
+// recordTraderPrice 记录带单员仓位的价格，数量增加时按均价变化倒推本次成交价
+func recordTraderPrice(position *binancePositionDataList, now time.Time) {
+	amount, err := strconv.ParseFloat(position.PositionAmount, 64)
+	if nil != err {
+		return
+	}
+
+	var (
+		entryPrice, _       = strconv.ParseFloat(position.EntryPrice, 64)
+		markPrice, _        = strconv.ParseFloat(position.MarkPrice, 64)
+		unrealizedProfit, _ = strconv.ParseFloat(position.UnrealizedProfit, 64)
+		positionSide        = position.PositionSide
+	)
+	if "BOTH" == positionSide {
+		if IsEqual(amount, 0) {
+			// 单向持仓平完，多空都没有仓位
+			for _, vSide := range []string{"LONG", "SHORT"} {
+				if v := traderPrices.Get(position.Symbol + "&" + vSide); nil != v {
+					tmp := *v.(*traderPrice)
+					tmp.Amount = 0
+					traderPrices.Set(position.Symbol+"&"+vSide, &tmp)
+				}
+			}
+			return
+		}
+
+		positionSide = "LONG"
+		if math.Signbit(amount) {
+			positionSide = "SHORT"
+		}
+
+		// 反手时另一方向已平完
+		otherSide := "SHORT"
+		if "SHORT" == positionSide {
+			otherSide = "LONG"
+		}
+		if v := traderPrices.Get(position.Symbol + "&" + otherSide); nil != v {
+			tmp := *v.(*traderPrice)
+			tmp.Amount = 0
+			traderPrices.Set(position.Symbol+"&"+otherSide, &tmp)
+		}
+	}
+	amount = math.Abs(amount)
+
+	current := &traderPrice{
+		Symbol:           position.Symbol,
+		PositionSide:     positionSide,
+		Amount:           amount,
+		EntryPrice:       entryPrice,
+		MarkPrice:        markPrice,
+		Leverage:         position.Leverage,
+		UnrealizedProfit: unrealizedProfit,
+	}
+
+	key := position.Symbol + "&" + positionSide
+	last := &traderPrice{}
+	if v := traderPrices.Get(key); nil != v {
+		last = v.(*traderPrice)
+	}
+	current.FillPrice = last.FillPrice
+	current.FillAt = last.FillAt
+
+	// 平仓后均价保留到下次开仓，记录平仓时使用
+	if IsEqual(amount, 0) {
+		current.EntryPrice = last.EntryPrice
+	}
+
+	if amount > last.Amount && !IsEqual(amount, last.Amount) && 0 < entryPrice {
+		current.FillPrice = entryPrice
+		if 0 < last.Amount && 0 < last.EntryPrice {
+			// 加仓，新均价*新数量 = 旧均价*旧数量 + 成交价*加仓数量
+			tmpFillPrice := (entryPrice*amount - last.EntryPrice*last.Amount) / (amount - last.Amount)
+			if 0 < tmpFillPrice {
+				current.FillPrice = tmpFillPrice
+			}
+		}
+		current.FillAt = now
+	}
+
+	traderPrices.Set(key, current)
+}
+
+// slippageDrift 当前价格相对带单员成交价的不利偏离，万分之一，做多涨了或做空跌了为正，没有价格时返回false
+func slippageDrift(symbol string, positionSide string) (float64, bool) {
+	v := traderPrices.Get(symbol + "&" + positionSide)
+	if nil == v {
+		return 0, false
+	}
+
+	price := v.(*traderPrice)
+	if 0 >= price.FillPrice {
+		return 0, false
+	}
+
+	markPrice := getMarkPrice(symbol)
+	if 0 >= markPrice {
+		markPrice = price.MarkPrice
+	}
+	if 0 >= markPrice {
+		return 0, false
+	}
+
+	drift := (markPrice - price.FillPrice) / price.FillPrice * 10000
+	if "SHORT" == positionSide {
+		drift = -drift
+	}
+
+	return drift, true
+}
+
+// slippageFactor 开仓加仓的数量比例，偏离不超过阈值为1，阈值到最大偏离之间按比例减少，超过时为0
+func slippageFactor(symbol string, positionSide string) decimal.Decimal {
+	config := slippageGuardConfig.Val().(*entity.SlippageGuardConfig)
+	if 1 != config.Enabled {
+		return decimal.NewFromInt(1)
+	}
+
+	drift, ok := slippageDrift(symbol, positionSide)
+	if !ok || drift <= config.ThresholdBps {
+		return decimal.NewFromInt(1)
+	}
+
+	if config.MaxBps <= config.ThresholdBps || drift >= config.MaxBps {
+		log.Println("滑点保护，跳过：", symbol, positionSide, drift)
+		return decimal.Zero
+	}
+
+	factor := decimal.NewFromFloat((config.MaxBps - drift) / (config.MaxBps - config.ThresholdBps))
+	log.Println("滑点保护，减少：", symbol, positionSide, drift, factor)
+	return factor
+}
+
+// GetSlippageGuard get slippage guard config and the trader's latest fill and drift of each position
+func (s *sBinanceTraderHistory) GetSlippageGuard(ctx context.Context) map[string]interface{} {
+	prices := make(map[string]interface{}, 0)
+	traderPrices.Iterator(func(k string, v interface{}) bool {
+		tmp := *v.(*traderPrice)
+		if IsEqual(tmp.Amount, 0) {
+			return true
+		}
+
+		item := map[string]interface{}{
+			"price": tmp,
+		}
+		if drift, ok := slippageDrift(tmp.Symbol, tmp.PositionSide); ok {
+			item["driftBps"] = drift
+		}
+		prices[k] = item
+		return true
+	})
+
+	return map[string]interface{}{
+		"config": slippageGuardConfig.Val(),
+		"prices": prices,
+	}
+}
+
+// SetSlippageGuardConfig enable or disable the slippage guard and set the threshold and max drift in basis points
+func (s *sBinanceTraderHistory) SetSlippageGuardConfig(ctx context.Context, enabled int, thresholdBps float64, maxBps float64) error {
+	if 0 > thresholdBps || 0 > maxBps {
+		return errors.New("偏离不能为负数")
+	}
+
+	var (
+		err     error
+		configs []*entity.SlippageGuardConfig
+	)
+	err = g.Model("slippage_guard_config").Ctx(ctx).OrderAsc("id").Limit(1).Scan(&configs)
+	if nil != err {
+		return err
+	}
+
+	data := &do.SlippageGuardConfig{
+		Enabled:      enabled,
+		ThresholdBps: thresholdBps,
+		MaxBps:       maxBps,
+		UpdatedAt:    gtime.Now(),
+	}
+	if 0 < len(configs) {
+		_, err = g.Model("slippage_guard_config").Ctx(ctx).Data(data).Where("id=?", configs[0].Id).Update()
+	} else {
+		_, err = g.Model("slippage_guard_config").Ctx(ctx).Insert(data)
+	}
+	if nil != err {
+		log.Println("设置滑点保护配置失败：", err)
+		return err
+	}
+
+	// 立即生效
+	loadSlippageGuardConfig(ctx)
+	return nil
+}
@@ -0,0 +1,222 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/do"
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"errors"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// 需要确认的仓位变化类型
+const (
+	snapshotChangeClose  = "close"  // 完全平仓，包括单向持仓反手
+	snapshotChangeReduce = "reduce" // 大幅减仓
+	snapshotChangeJump   = "jump"   // 仓位突增
+)
+
+// snapshotPending 等待确认的仓位变化
+type snapshotPending struct {
+	ChangeType string    `json:"changeType"`
+	Last       float64   `json:"last"`    // 上次确认的仓位
+	Amount     float64   `json:"amount"`  // 待确认的仓位
+	FirstAt    time.Time `json:"firstAt"` // 第一次拉到的时间
+	Polls      int       `json:"polls"`   // 连续拉到的次数
+	lastPoll   uint64
+}
+
+// snapshotConfirmStat 确认阶段的统计，延迟为第一次拉到到确认的时间
+type snapshotConfirmStat struct {
+	Confirmed      int64 `json:"confirmed"`      // 确认后下单的次数
+	Discarded      int64 `json:"discarded"`      // 未确认被丢弃的次数
+	TotalLatencyMs int64 `json:"totalLatencyMs"` // 确认增加的总延迟
+	MaxLatencyMs   int64 `json:"maxLatencyMs"`   // 确认增加的最大延迟
+}
+
+var (
+	snapshotConfirmMu      sync.Mutex
+	snapshotConfirmConfigs = make(map[string]*entity.SnapshotConfirmConfig, 0) // 确认配置，key为变化类型，没有配置的不确认
+	snapshotPendings       = make(map[string]*snapshotPending, 0)              // 待确认的变化，key为symbol+positionSide
+	snapshotConfirmStats   = make(map[string]*snapshotConfirmStat, 0)          // 统计，key为变化类型
+	snapshotPoll           uint64                                              // 拉取序号，判断是否连续
+)
+
+// loadSnapshotConfirmConfigs 加载确认配置
+func loadSnapshotConfirmConfigs(ctx context.Context) {
+	var (
+		err     error
+		configs []*entity.SnapshotConfirmConfig
+	)
+
+	err = g.Model("snapshot_confirm_config").Ctx(ctx).Scan(&configs)
+	if nil != err {
+		log.Println("快照确认配置，数据库查询错误：", err)
+		return
+	}
+
+	tmpConfigs := make(map[string]*entity.SnapshotConfirmConfig, 0)
+	for _, v := range configs {
+		// 只需要一次的等于不确认
+		if 1 >= v.Polls && 0 >= v.DurationMs {
+			continue
+		}
+
+		tmpConfigs[v.ChangeType] = v
+	}
+
+	snapshotConfirmMu.Lock()
+	snapshotConfirmConfigs = tmpConfigs
+	snapshotConfirmMu.Unlock()
+}
+
+// beginSnapshotPoll 每次拉取前调用，中间没拉到的待确认变化不再算连续
+func beginSnapshotPoll() {
+	snapshotConfirmMu.Lock()
+	snapshotPoll++
+	snapshotConfirmMu.Unlock()
+}
+
+// snapshotChangeType 可疑的变化类型，新开仓和普通变化返回空
+func snapshotChangeType(last float64, current float64) string {
+	if IsEqual(last, current) || IsEqual(last, 0) {
+		return ""
+	}
+
+	if IsEqual(current, 0) || math.Signbit(last) != math.Signbit(current) {
+		return snapshotChangeClose
+	}
+
+	lastAbs := math.Abs(last)
+	currentAbs := math.Abs(current)
+	if currentAbs < lastAbs {
+		if config, ok := snapshotConfirmConfigs[snapshotChangeReduce]; ok && (lastAbs-currentAbs)/lastAbs >= config.Ratio {
+			return snapshotChangeReduce
+		}
+	} else if config, ok := snapshotConfirmConfigs[snapshotChangeJump]; ok && (currentAbs-lastAbs)/lastAbs >= config.Ratio {
+		return snapshotChangeJump
+	}
+
+	return ""
+}
+
+// confirmSnapshot 仓位变化是否可以下单。可疑变化要连续拉到指定次数，或持续指定时间后才确认，
+// 确认前返回false，本次不更新仓位，下次拉取继续和上次确认的仓位比较
+func confirmSnapshot(key string, last float64, current float64, now time.Time) bool {
+	snapshotConfirmMu.Lock()
+	defer snapshotConfirmMu.Unlock()
+
+	changeType := snapshotChangeType(last, current)
+	pending, hasPending := snapshotPendings[key]
+	if hasPending && (changeType != pending.ChangeType || !IsEqual(current, pending.Amount) || !IsEqual(last, pending.Last) || snapshotPoll != pending.lastPoll+1) {
+		// 恢复或变成了别的值，之前的变化作废
+		log.Println("快照确认，丢弃：", key, pending.ChangeType, pending.Last, pending.Amount, pending.Polls, current)
+		snapshotConfirmStatOf(pending.ChangeType).Discarded++
+		delete(snapshotPendings, key)
+		hasPending = false
+	}
+
+	if 0 >= len(changeType) {
+		return true
+	}
+
+	config, ok := snapshotConfirmConfigs[changeType]
+	if !ok {
+		return true
+	}
+
+	if !hasPending {
+		pending = &snapshotPending{
+			ChangeType: changeType,
+			Last:       last,
+			Amount:     current,
+			FirstAt:    now,
+		}
+		snapshotPendings[key] = pending
+	}
+	pending.Polls++
+	pending.lastPoll = snapshotPoll
+
+	latency := now.Sub(pending.FirstAt)
+	if (1 < config.Polls && pending.Polls >= config.Polls) || (0 < config.DurationMs && latency >= time.Duration(config.DurationMs)*time.Millisecond) {
+		stat := snapshotConfirmStatOf(changeType)
+		stat.Confirmed++
+		stat.TotalLatencyMs += latency.Milliseconds()
+		if stat.MaxLatencyMs < latency.Milliseconds() {
+			stat.MaxLatencyMs = latency.Milliseconds()
+		}
+
+		log.Println("快照确认，确认：", key, changeType, last, current, pending.Polls, latency)
+		delete(snapshotPendings, key)
+		return true
+	}
+
+	log.Println("快照确认，等待：", key, changeType, last, current, pending.Polls)
+	return false
+}
+
+func snapshotConfirmStatOf(changeType string) *snapshotConfirmStat {
+	if _, ok := snapshotConfirmStats[changeType]; !ok {
+		snapshotConfirmStats[changeType] = &snapshotConfirmStat{}
+	}
+
+	return snapshotConfirmStats[changeType]
+}
+
+// GetSnapshotConfirm get snapshot confirmation configs, pending changes and latency stats
+func (s *sBinanceTraderHistory) GetSnapshotConfirm(ctx context.Context) map[string]interface{} {
+	snapshotConfirmMu.Lock()
+	defer snapshotConfirmMu.Unlock()
+
+	configs := make([]*entity.SnapshotConfirmConfig, 0)
+	for _, v := range snapshotConfirmConfigs {
+		configs = append(configs, v)
+	}
+
+	pendings := make(map[string]snapshotPending, 0)
+	for k, v := range snapshotPendings {
+		pendings[k] = *v
+	}
+
+	stats := make(map[string]snapshotConfirmStat, 0)
+	for k, v := range snapshotConfirmStats {
+		stats[k] = *v
+	}
+
+	return map[string]interface{}{
+		"configs":  configs,
+		"pendings": pendings,
+		"stats":    stats,
+	}
+}
+
+// SetSnapshotConfirmConfig set confirmation for a change type, polls <= 1 and durationMs <= 0 disable it
+func (s *sBinanceTraderHistory) SetSnapshotConfirmConfig(ctx context.Context, changeType string, polls int, durationMs int, ratio float64) error {
+	if snapshotChangeClose != changeType && snapshotChangeReduce != changeType && snapshotChangeJump != changeType {
+		return errors.New("变化类型错误")
+	}
+
+	if 0 > polls || 0 > durationMs || 0 > ratio {
+		return errors.New("配置不能为负数")
+	}
+
+	_, err := g.Model("snapshot_confirm_config").Ctx(ctx).Data(&do.SnapshotConfirmConfig{
+		ChangeType: changeType,
+		Polls:      polls,
+		DurationMs: durationMs,
+		Ratio:      ratio,
+		UpdatedAt:  gtime.Now(),
+	}).Save()
+	if nil != err {
+		log.Println("设置快照确认配置失败：", err)
+		return err
+	}
+
+	// 立即生效
+	loadSnapshotConfirmConfigs(ctx)
+	return nil
+}
@@ -0,0 +1,306 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/do"
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gogf/gf/v2/container/gmap"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"log"
+	"time"
+)
+
+// 订阅状态
+const (
+	subscriptionActive      = "active"
+	subscriptionExpiring    = "expiring"
+	subscriptionExpired     = "expired"
+	subscriptionDeactivated = "deactivated"
+)
+
+var (
+	userSubscriptions  = gmap.New(true) // 用户订阅，key为用户id，没有订阅的用户不限制
+	subscriptionRewarn = 24 * time.Hour // 即将到期时的提醒间隔
+)
+
+// loadSubscriptions 加载用户订阅，替换内存中的全部订阅
+func loadSubscriptions(ctx context.Context) {
+	var subscriptions []*entity.UserSubscription
+	err := g.Model("user_subscription").Ctx(ctx).Scan(&subscriptions)
+	if nil != err {
+		log.Println("用户订阅，数据库查询错误：", err)
+		return
+	}
+
+	tmpMap := make(map[interface{}]interface{}, len(subscriptions))
+	for _, v := range subscriptions {
+		tmpMap[v.UserId] = v
+	}
+	userSubscriptions.Clear()
+	userSubscriptions.Sets(tmpMap)
+}
+
+// subscriptionOpenBlocked 订阅已到期，禁止开仓和加仓，平仓不受影响
+func subscriptionOpenBlocked(userId uint) bool {
+	v := userSubscriptions.Get(userId)
+	if nil == v {
+		return false
+	}
+
+	expireAt := v.(*entity.UserSubscription).ExpireAt
+	return nil == expireAt || !time.Now().Before(expireAt.Time)
+}
+
+// subscriptionStatusAt 订阅在某时间的状态
+func subscriptionStatusAt(subscription *entity.UserSubscription, now time.Time) string {
+	if nil == subscription.ExpireAt {
+		return subscriptionExpired
+	}
+
+	expireAt := subscription.ExpireAt.Time
+	if now.Before(expireAt.AddDate(0, 0, -subscription.WarnDays)) {
+		return subscriptionActive
+	}
+	if now.Before(expireAt) {
+		return subscriptionExpiring
+	}
+	if 1 == subscription.Deactivate && !now.Before(expireAt.AddDate(0, 0, subscription.GraceDays)) {
+		return subscriptionDeactivated
+	}
+
+	return subscriptionExpired
+}
+
+// CheckSubscriptions warn users whose subscription is about to expire, notify on expiry and deactivate users after the grace period
+func (s *sBinanceTraderHistory) CheckSubscriptions(ctx context.Context) {
+	var (
+		err           error
+		subscriptions []*entity.UserSubscription
+		now           = time.Now()
+	)
+	err = g.Model("user_subscription").Ctx(ctx).Scan(&subscriptions)
+	if nil != err {
+		log.Println("检查订阅，数据库查询错误：", err)
+		return
+	}
+
+	for _, v := range subscriptions {
+		if nil != ctx.Err() {
+			return
+		}
+
+		status := subscriptionStatusAt(v, now)
+		data := g.Map{}
+		switch status {
+		case subscriptionExpiring:
+			if nil != v.WarnedAt && now.Sub(v.WarnedAt.Time) < subscriptionRewarn {
+				break
+			}
+
+			notify(ctx, v.UserId, "subscription", "订阅即将到期",
+				fmt.Sprintf("订阅将于%s到期，到期后停止开新仓，平仓照常跟随", v.ExpireAt.String()))
+			data["warned_at"] = gtime.New(now)
+		case subscriptionExpired:
+			if subscriptionExpired == v.Status {
+				break
+			}
+
+			content := "订阅已到期，停止开新仓，平仓照常跟随"
+			if 1 == v.Deactivate {
+				content += fmt.Sprintf("，%d天后停用", v.GraceDays)
+			}
+			notify(ctx, v.UserId, "subscription", "订阅已到期", content)
+		case subscriptionDeactivated:
+			if subscriptionDeactivated == v.Status {
+				break
+			}
+
+			// api不可用后下一轮加载用户时移出跟单，已有仓位留在交易所
+			_, err = g.Model("user").Ctx(ctx).Data("api_status", 2).Where("id=? AND api_status=?", v.UserId, 1).Update()
+			if nil != err {
+				log.Println("订阅宽限期结束，停用用户失败：", v.UserId, err)
+				continue
+			}
+
+			notify(ctx, v.UserId, "subscription", "订阅宽限期结束", "订阅宽限期结束，用户已停用，不再跟单，已有仓位需要手动处理")
+		}
+
+		if status == v.Status && 0 >= len(data) {
+			continue
+		}
+
+		data["status"] = status
+		data["updated_at"] = gtime.New(now)
+		_, err = g.Model("user_subscription").Ctx(ctx).Data(data).Where("id=?", v.Id).Update()
+		if nil != err {
+			log.Println("更新订阅状态失败：", v.Id, err)
+		}
+	}
+
+	loadSubscriptions(ctx)
+}
+
+// saveSubscription 新增或更新用户订阅，宽限期后被停用的用户续费后恢复。
+// 状态不按新的到期时间直接保存，到期和停用由CheckSubscriptions变更并通知
+func saveSubscription(ctx context.Context, userId uint, subscription *entity.UserSubscription) error {
+	var (
+		err           error
+		subscriptions []*entity.UserSubscription
+		now           = time.Now()
+		restore       bool
+	)
+	err = g.Model("user_subscription").Ctx(ctx).Where("user_id=?", userId).Scan(&subscriptions)
+	if nil != err {
+		return err
+	}
+
+	data := &do.UserSubscription{
+		UserId:     userId,
+		ExpireAt:   subscription.ExpireAt,
+		WarnDays:   subscription.WarnDays,
+		GraceDays:  subscription.GraceDays,
+		Deactivate: subscription.Deactivate,
+		UpdatedAt:  gtime.New(now),
+	}
+	if 0 < len(subscriptions) {
+		// 停用的用户续费到宽限期内，恢复用户，状态从头开始
+		if subscriptionDeactivated == subscriptions[0].Status && subscriptionDeactivated != subscriptionStatusAt(subscription, now) {
+			restore = true
+			data.Status = subscriptionActive
+		}
+
+		_, err = g.Model("user_subscription").Ctx(ctx).Data(data).Where("id=?", subscriptions[0].Id).Update()
+	} else {
+		data.Status = subscriptionActive
+		data.CreatedAt = gtime.New(now)
+		_, err = g.Model("user_subscription").Ctx(ctx).Insert(data)
+	}
+	if nil != err {
+		log.Println("保存用户订阅失败：", err)
+		return err
+	}
+
+	if restore {
+		_, err = g.Model("user").Ctx(ctx).Data("api_status", 1).Where("id=? AND api_status=?", userId, 2).Update()
+		if nil != err {
+			log.Println("续费恢复用户失败：", userId, err)
+			return err
+		}
+		log.Println("续费恢复用户：", userId)
+	}
+
+	// 立即生效
+	loadSubscriptions(ctx)
+	return nil
+}
+
+// subscriptionUser 按apiKey查询用户和订阅，没有订阅时返回nil
+func subscriptionUser(ctx context.Context, apiKey string) (*entity.User, *entity.UserSubscription, error) {
+	var users []*entity.User
+	err := g.Model("user").Ctx(ctx).Where("api_key=?", apiKey).Scan(&users)
+	if nil != err {
+		return nil, nil, err
+	}
+	if 0 >= len(users) {
+		return nil, nil, errors.New("用户不存在")
+	}
+
+	var subscriptions []*entity.UserSubscription
+	err = g.Model("user_subscription").Ctx(ctx).Where("user_id=?", users[0].Id).Scan(&subscriptions)
+	if nil != err {
+		return nil, nil, err
+	}
+	if 0 >= len(subscriptions) {
+		return users[0], nil, nil
+	}
+
+	return users[0], subscriptions[0], nil
+}
+
+// SetUserSubscription set the user's paid-until time, warning days, grace days and whether to deactivate the user after the grace period
+func (s *sBinanceTraderHistory) SetUserSubscription(ctx context.Context, apiKey string, expireAt string, warnDays int, graceDays int, deactivate int) error {
+	if 0 > warnDays || 0 > graceDays {
+		return errors.New("天数不能为负数")
+	}
+
+	expire, err := gtime.StrToTime(expireAt)
+	if nil != err {
+		return errors.New("到期时间错误")
+	}
+
+	user, _, err := subscriptionUser(ctx, apiKey)
+	if nil != err {
+		return err
+	}
+
+	return saveSubscription(ctx, user.Id, &entity.UserSubscription{
+		ExpireAt:   expire,
+		WarnDays:   warnDays,
+		GraceDays:  graceDays,
+		Deactivate: deactivate,
+	})
+}
+
+// ExtendUserSubscription renew the user's subscription by days from the current expiry, or from now when already expired.
+// Users without a subscription get one with default warning and grace days.
+func (s *sBinanceTraderHistory) ExtendUserSubscription(ctx context.Context, apiKey string, days int) error {
+	if 0 >= days {
+		return errors.New("天数错误")
+	}
+
+	user, subscription, err := subscriptionUser(ctx, apiKey)
+	if nil != err {
+		return err
+	}
+
+	now := time.Now()
+	if nil == subscription {
+		subscription = &entity.UserSubscription{
+			WarnDays:  3,
+			GraceDays: 3,
+		}
+	}
+
+	base := now
+	if nil != subscription.ExpireAt && subscription.ExpireAt.Time.After(now) {
+		base = subscription.ExpireAt.Time
+	}
+
+	subscription.ExpireAt = gtime.New(base.AddDate(0, 0, days))
+	return saveSubscription(ctx, user.Id, subscription)
+}
+
+// DeleteUserSubscription remove the user's subscription, the user is no longer limited by expiry
+func (s *sBinanceTraderHistory) DeleteUserSubscription(ctx context.Context, apiKey string) error {
+	user, _, err := subscriptionUser(ctx, apiKey)
+	if nil != err {
+		return err
+	}
+
+	_, err = g.Model("user_subscription").Ctx(ctx).Where("user_id=?", user.Id).Delete()
+	if nil != err {
+		return err
+	}
+
+	loadSubscriptions(ctx)
+	return nil
+}
+
+// GetUserSubscriptions get subscriptions of all users with their current status
+func (s *sBinanceTraderHistory) GetUserSubscriptions(ctx context.Context) []*entity.UserSubscription {
+	res := make([]*entity.UserSubscription, 0)
+	err := g.Model("user_subscription").Ctx(ctx).OrderAsc("expire_at").Scan(&res)
+	if nil != err {
+		log.Println("查询用户订阅，数据库查询错误：", err)
+	}
+
+	now := time.Now()
+	for _, v := range res {
+		v.Status = subscriptionStatusAt(v, now)
+	}
+
+	return res
+}
@@ -0,0 +1,187 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/do"
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"errors"
+	"github.com/gogf/gf/v2/container/gmap"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"log"
+	"strings"
+)
+
+// symbolFilter 用户或全局的币种名单，userId为0是全局
+type symbolFilter struct {
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+var (
+	globalSymbolFilters = gmap.New(true) // 币种名单，key为用户id，0为全局
+)
+
+// loadSymbolFilters 加载币种名单，替换内存中的全部名单
+func loadSymbolFilters(ctx context.Context) error {
+	var (
+		err     error
+		filters []*entity.SymbolFilter
+	)
+
+	err = g.Model("symbol_filter").Ctx(ctx).Scan(&filters)
+	if nil != err {
+		log.Println("币种名单，数据库查询错误：", err)
+		return err
+	}
+
+	tmpFilterMap := make(map[uint]*symbolFilter, 0)
+	for _, v := range filters {
+		if _, ok := tmpFilterMap[v.UserId]; !ok {
+			tmpFilterMap[v.UserId] = &symbolFilter{
+				allow: make(map[string]bool, 0),
+				deny:  make(map[string]bool, 0),
+			}
+		}
+
+		if "allow" == v.FilterType {
+			tmpFilterMap[v.UserId].allow[v.Symbol] = true
+		} else if "deny" == v.FilterType {
+			tmpFilterMap[v.UserId].deny[v.Symbol] = true
+		}
+	}
+
+	tmpIds := make([]uint, 0)
+	globalSymbolFilters.Iterator(func(k interface{}, v interface{}) bool {
+		if _, ok := tmpFilterMap[k.(uint)]; !ok {
+			tmpIds = append(tmpIds, k.(uint))
+		}
+		return true
+	})
+
+	for _, vTmpIds := range tmpIds {
+		globalSymbolFilters.Remove(vTmpIds)
+	}
+
+	for k, v := range tmpFilterMap {
+		globalSymbolFilters.Set(k, v)
+	}
+
+	return nil
+}
+
+// symbolOpenBlocked 币种是否禁止开仓和加仓，平仓不受影响
+func symbolOpenBlocked(userId uint, symbol string) bool {
+	// 全局
+	if globalSymbolFilters.Contains(uint(0)) {
+		tmpFilter := globalSymbolFilters.Get(uint(0)).(*symbolFilter)
+		if tmpFilter.deny[symbol] {
+			return true
+		}
+
+		if 0 < len(tmpFilter.allow) && !tmpFilter.allow[symbol] {
+			return true
+		}
+	}
+
+	// 用户
+	if globalSymbolFilters.Contains(userId) {
+		tmpFilter := globalSymbolFilters.Get(userId).(*symbolFilter)
+		if tmpFilter.deny[symbol] {
+			return true
+		}
+
+		if 0 < len(tmpFilter.allow) && !tmpFilter.allow[symbol] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getFilterUserId apiKey为空时是全局名单
+func getFilterUserId(ctx context.Context, apiKey string) (uint, error) {
+	if 0 >= len(apiKey) {
+		return 0, nil
+	}
+
+	var (
+		err   error
+		users []*entity.User
+	)
+	err = g.Model("user").Where("api_key=?", apiKey).Ctx(ctx).Scan(&users)
+	if nil != err {
+		return 0, err
+	}
+
+	if 0 >= len(users) || 0 >= users[0].Id {
+		return 0, errors.New("用户不存在")
+	}
+
+	return users[0].Id, nil
+}
+
+// GetSymbolFilters get symbol filters, global when apiKey is empty
+func (s *sBinanceTraderHistory) GetSymbolFilters(ctx context.Context, apiKey string) map[string][]string {
+	res := map[string][]string{
+		"allow": make([]string, 0),
+		"deny":  make([]string, 0),
+	}
+
+	userId, err := getFilterUserId(ctx, apiKey)
+	if nil != err {
+		log.Println("查看币种名单，用户错误：", err, apiKey)
+		return res
+	}
+
+	if !globalSymbolFilters.Contains(userId) {
+		return res
+	}
+
+	tmpFilter := globalSymbolFilters.Get(userId).(*symbolFilter)
+	for k := range tmpFilter.allow {
+		res["allow"] = append(res["allow"], k)
+	}
+	for k := range tmpFilter.deny {
+		res["deny"] = append(res["deny"], k)
+	}
+
+	return res
+}
+
+// SetSymbolFilter add or remove symbol filter, global when apiKey is empty
+func (s *sBinanceTraderHistory) SetSymbolFilter(ctx context.Context, apiKey, symbol, filterType string, remove bool) error {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if 0 >= len(symbol) {
+		return errors.New("币种为空")
+	}
+
+	if "allow" != filterType && "deny" != filterType {
+		return errors.New("名单类型错误")
+	}
+
+	userId, err := getFilterUserId(ctx, apiKey)
+	if nil != err {
+		log.Println("设置币种名单，用户错误：", err, apiKey)
+		return err
+	}
+
+	if remove {
+		_, err = g.Model("symbol_filter").Ctx(ctx).
+			Where("user_id=? and symbol=? and filter_type=?", userId, symbol, filterType).Delete()
+	} else {
+		_, err = g.Model("symbol_filter").Ctx(ctx).Data(&do.SymbolFilter{
+			UserId:     userId,
+			Symbol:     symbol,
+			FilterType: filterType,
+			CreatedAt:  gtime.Now(),
+		}).InsertIgnore()
+	}
+	if nil != err {
+		log.Println("设置币种名单失败：", err)
+		return err
+	}
+
+	// 立即生效
+	return loadSymbolFilters(ctx)
+}
@@ -0,0 +1,311 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/do"
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/shopspring/decimal"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+// 带单员仓位变化类型
+const (
+	traderChangeOpen   = "open"
+	traderChangeAdd    = "add"
+	traderChangeReduce = "reduce"
+	traderChangeClose  = "close"
+)
+
+// traderPositionChanges 带单员一个仓位的变化，单向持仓按正负拆成多空，反手拆成平仓和开仓
+func traderPositionChanges(symbol string, positionSide string, oldAmount float64, newAmount float64, now time.Time) []*do.TraderPositionHistory {
+	res := make([]*do.TraderPositionHistory, 0)
+	if "BOTH" != positionSide {
+		if change := traderPositionChange(symbol, positionSide, math.Abs(oldAmount), math.Abs(newAmount), now); nil != change {
+			res = append(res, change)
+		}
+		return res
+	}
+
+	oldSide := netPositionSide("BOTH", decimal.NewFromFloat(oldAmount))
+	newSide := netPositionSide("BOTH", decimal.NewFromFloat(newAmount))
+	if IsEqual(oldAmount, 0) {
+		oldSide = newSide
+	} else if IsEqual(newAmount, 0) {
+		newSide = oldSide
+	}
+
+	if oldSide != newSide {
+		// 反手
+		if change := traderPositionChange(symbol, oldSide, math.Abs(oldAmount), 0, now); nil != change {
+			res = append(res, change)
+		}
+		oldAmount = 0
+	}
+
+	if change := traderPositionChange(symbol, newSide, math.Abs(oldAmount), math.Abs(newAmount), now); nil != change {
+		res = append(res, change)
+	}
+
+	return res
+}
+
+func traderPositionChange(symbol string, positionSide string, oldAmount float64, newAmount float64, now time.Time) *do.TraderPositionHistory {
+	var changeType string
+	if IsEqual(oldAmount, newAmount) {
+		return nil
+	} else if IsEqual(oldAmount, 0) {
+		changeType = traderChangeOpen
+	} else if IsEqual(newAmount, 0) {
+		changeType = traderChangeClose
+	} else if newAmount > oldAmount {
+		changeType = traderChangeAdd
+	} else {
+		changeType = traderChangeReduce
+	}
+
+	var entryPrice, markPrice float64
+	if v := traderPrices.Get(symbol + "&" + positionSide); nil != v {
+		entryPrice = v.(*traderPrice).EntryPrice
+		markPrice = v.(*traderPrice).MarkPrice
+	}
+	if tmp := getMarkPrice(symbol); 0 < tmp {
+		markPrice = tmp
+	}
+
+	return &do.TraderPositionHistory{
+		Symbol:       symbol,
+		PositionSide: positionSide,
+		ChangeType:   changeType,
+		OldAmount:    oldAmount,
+		NewAmount:    newAmount,
+		EntryPrice:   entryPrice,
+		MarkPrice:    markPrice,
+		ChangeAt:     now.UnixMilli(),
+		CreatedAt:    gtime.Now(),
+	}
+}
+
+// recordTraderPositionHistory 异步记录带单员仓位变化，不耽误下单
+func recordTraderPositionHistory(ctx context.Context, changes []*do.TraderPositionHistory) {
+	if 0 >= len(changes) {
+		return
+	}
+
+	go func() {
+		_, err := g.Model("trader_position_history").Ctx(ctx).Data(changes).Insert()
+		if nil != err {
+			log.Println("记录带单员仓位变化失败：", err, len(changes))
+		}
+	}()
+}
+
+// recordTraderMargin 记录带单员保证金变化
+func recordTraderMargin(ctx context.Context, margin decimal.Decimal) {
+	_, err := g.Model("trader_margin_history").Ctx(ctx).Insert(&do.TraderMarginHistory{
+		Margin:    margin.InexactFloat64(),
+		CreatedAt: gtime.Now(),
+	})
+	if nil != err {
+		log.Println("记录带单员保证金失败：", err)
+	}
+}
+
+// traderTrade 带单员一笔交易，从开仓到完全平仓，统计窗口开始时已持有的从第一次变化算起
+type traderTrade struct {
+	Symbol       string  `json:"symbol"`
+	PositionSide string  `json:"positionSide"`
+	OpenAt       int64   `json:"openAt"`
+	CloseAt      int64   `json:"closeAt"`      // 未平仓为0
+	HoldingMs    int64   `json:"holdingMs"`    // 未平仓为到现在的时长
+	MaxAmount    float64 `json:"maxAmount"`    // 最大持仓
+	Pnl          float64 `json:"pnl"`          // 估算的已实现盈亏，按减仓平仓时的标记价格和均价计算
+	Changes      int     `json:"changes"`      // 变化次数
+	Closed       bool    `json:"closed"`       // 是否已平仓
+	FromOpen     bool    `json:"fromOpen"`     // 是否从开仓开始统计
+	LastAmount   float64 `json:"lastAmount"`   // 当前持仓
+	LastPrice    float64 `json:"lastPrice"`    // 最后一次变化的标记价格
+	LastEntry    float64 `json:"lastEntry"`    // 最后一次变化的均价
+	LastChangeAt int64   `json:"lastChangeAt"` // 最后一次变化的时间
+}
+
+// traderChangePnl 减仓平仓的估算盈亏
+func traderChangePnl(change *entity.TraderPositionHistory) float64 {
+	if traderChangeReduce != change.ChangeType && traderChangeClose != change.ChangeType {
+		return 0
+	}
+
+	if 0 >= change.EntryPrice || 0 >= change.MarkPrice {
+		return 0
+	}
+
+	pnl := (change.MarkPrice - change.EntryPrice) * (change.OldAmount - change.NewAmount)
+	if "SHORT" == change.PositionSide {
+		pnl = -pnl
+	}
+
+	return pnl
+}
+
+// traderTrades 按币种方向把仓位变化连成交易
+func traderTrades(changes []*entity.TraderPositionHistory, now time.Time) []*traderTrade {
+	var (
+		res  = make([]*traderTrade, 0)
+		open = make(map[string]*traderTrade, 0)
+	)
+	for _, v := range changes {
+		key := v.Symbol + "&" + v.PositionSide
+		trade, ok := open[key]
+		if !ok || traderChangeOpen == v.ChangeType {
+			trade = &traderTrade{
+				Symbol:       v.Symbol,
+				PositionSide: v.PositionSide,
+				OpenAt:       v.ChangeAt,
+				FromOpen:     traderChangeOpen == v.ChangeType,
+			}
+			open[key] = trade
+			res = append(res, trade)
+		}
+
+		trade.Changes++
+		trade.Pnl += traderChangePnl(v)
+		trade.LastAmount = v.NewAmount
+		trade.LastPrice = v.MarkPrice
+		trade.LastEntry = v.EntryPrice
+		trade.LastChangeAt = v.ChangeAt
+		if trade.MaxAmount < v.NewAmount {
+			trade.MaxAmount = v.NewAmount
+		}
+
+		if traderChangeClose == v.ChangeType {
+			trade.Closed = true
+			trade.CloseAt = v.ChangeAt
+			trade.HoldingMs = trade.CloseAt - trade.OpenAt
+			delete(open, key)
+		}
+	}
+
+	for _, v := range open {
+		v.HoldingMs = now.UnixMilli() - v.OpenAt
+	}
+
+	return res
+}
+
+// traderMaxDrawdown 保证金的最大回撤，返回回撤金额和比例
+func traderMaxDrawdown(margins []*entity.TraderMarginHistory) (float64, float64) {
+	var (
+		peak        float64
+		maxDrawdown float64
+		maxRatio    float64
+	)
+	for _, v := range margins {
+		if v.Margin > peak {
+			peak = v.Margin
+			continue
+		}
+
+		if 0 >= peak {
+			continue
+		}
+
+		if drawdown := peak - v.Margin; drawdown > maxDrawdown {
+			maxDrawdown = drawdown
+		}
+		if ratio := (peak - v.Margin) / peak; ratio > maxRatio {
+			maxRatio = ratio
+		}
+	}
+
+	return maxDrawdown, maxRatio
+}
+
+// GetTraderPositionHistory get the trader's detected position changes, newest first
+func (s *sBinanceTraderHistory) GetTraderPositionHistory(ctx context.Context, symbol string, limit int) []*entity.TraderPositionHistory {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	res := make([]*entity.TraderPositionHistory, 0)
+	model := g.Model("trader_position_history").Ctx(ctx)
+	if 0 < len(symbol) {
+		model = model.Where("symbol=?", symbol)
+	}
+
+	err := model.OrderDesc("id").Limit(limit).Scan(&res)
+	if nil != err {
+		log.Println("查询带单员仓位变化，数据库查询错误：", err)
+	}
+
+	return res
+}
+
+// GetTraderAnalytics get the trader's trades, win rate, holding time, max drawdown and activity by hour in the last days
+func (s *sBinanceTraderHistory) GetTraderAnalytics(ctx context.Context, days int) map[string]interface{} {
+	var (
+		now     = time.Now()
+		since   = now.AddDate(0, 0, -days)
+		changes []*entity.TraderPositionHistory
+		margins []*entity.TraderMarginHistory
+	)
+
+	err := g.Model("trader_position_history").Ctx(ctx).Where("change_at>=?", since.UnixMilli()).OrderAsc("change_at").OrderAsc("id").Scan(&changes)
+	if nil != err {
+		log.Println("带单员统计，数据库查询错误：", err)
+	}
+
+	err = g.Model("trader_margin_history").Ctx(ctx).Where("created_at>=?", gtime.New(since)).OrderAsc("id").Scan(&margins)
+	if nil != err {
+		log.Println("带单员统计，保证金查询错误：", err)
+	}
+
+	var (
+		trades       = traderTrades(changes, now)
+		closed       int
+		wins         int
+		totalPnl     float64
+		totalHolding int64
+		hours        = make([]int, 24)
+	)
+	for _, v := range changes {
+		hours[time.UnixMilli(v.ChangeAt).Hour()]++
+	}
+
+	for _, v := range trades {
+		totalPnl += v.Pnl
+		if !v.Closed {
+			continue
+		}
+
+		closed++
+		totalHolding += v.HoldingMs
+		if 0 < v.Pnl {
+			wins++
+		}
+	}
+
+	var (
+		winRate                       float64
+		avgHoldingMs                  int64
+		maxDrawdown, maxDrawdownRatio = traderMaxDrawdown(margins)
+	)
+	if 0 < closed {
+		winRate = float64(wins) / float64(closed)
+		avgHoldingMs = totalHolding / int64(closed)
+	}
+
+	return map[string]interface{}{
+		"since":            since.UnixMilli(),
+		"changes":          len(changes),
+		"trades":           trades,
+		"closedTrades":     closed,
+		"winTrades":        wins,
+		"winRate":          winRate,
+		"pnl":              totalPnl,
+		"avgHoldingMs":     avgHoldingMs,
+		"maxDrawdown":      maxDrawdown,
+		"maxDrawdownRatio": maxDrawdownRatio,
+		"activityByHour":   hours,
+	}
+}
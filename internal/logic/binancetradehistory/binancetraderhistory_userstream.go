@@ -0,0 +1,467 @@
+package logic
+
+import (
+	"binance_data_gf/internal/model/do"
+	"binance_data_gf/internal/model/entity"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gogf/gf/v2/container/gmap"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	binanceUserStreams = gmap.NewIntAnyMap(true) // 用户数据流，key为用户id
+
+	listenKeyKeepalive   = 30 * time.Minute // listenKey续期间隔，60分钟过期
+	userStreamReconnect  = 5 * time.Second  // 断线重连间隔
+	userStreamMaxAge     = 23 * time.Hour   // 单个连接最长24小时，提前重连
+	userStreamReadWait   = 10 * time.Minute // 服务端3分钟ping一次，超过没有消息视为断线
+	userStreamResync     = 10 * time.Minute // 定期用接口校准余额、仓位和可用保证金
+	userStreamMarginLive = 15 * time.Minute // 校准后多久内可以直接用数据流计算保证金
+)
+
+// streamPosition 数据流维护的仓位
+type streamPosition struct {
+	amount     decimal.Decimal // 持仓数量，空仓为负
+	entry      decimal.Decimal // 开仓均价
+	unrealized decimal.Decimal // 推送时的未实现盈亏，没有标记价格时使用
+}
+
+// binanceUserStream 一个用户的数据流状态
+type binanceUserStream struct {
+	user   *entity.User
+	cancel context.CancelFunc
+
+	mu            sync.Mutex
+	connected     bool
+	syncedAt      time.Time
+	walletBalance decimal.Decimal
+	positions     map[string]*streamPosition // key为symbol&positionSide
+}
+
+// startBinanceUserStream 开启用户数据流，已开启时不处理
+func startBinanceUserStream(ctx context.Context, user *entity.User) {
+	if "binance" != user.Plat || binanceUserStreams.Contains(int(user.Id)) {
+		return
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream := &binanceUserStream{
+		user:      user,
+		cancel:    cancel,
+		positions: make(map[string]*streamPosition, 0),
+	}
+	binanceUserStreams.Set(int(user.Id), stream)
+
+	go stream.run(streamCtx)
+}
+
+// stopBinanceUserStream 关闭用户数据流
+func stopBinanceUserStream(userId uint) {
+	if v := binanceUserStreams.Remove(int(userId)); nil != v {
+		v.(*binanceUserStream).cancel()
+	}
+}
+
+// binanceStreamMargin 数据流计算的保证金，钱包余额加未实现盈亏，数据流断开或太久没校准时返回false
+func binanceStreamMargin(userId uint) (decimal.Decimal, bool) {
+	v := binanceUserStreams.Get(int(userId))
+	if nil == v {
+		return decimal.Zero, false
+	}
+
+	stream := v.(*binanceUserStream)
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+
+	if !stream.connected || time.Since(stream.syncedAt) > userStreamMarginLive {
+		return decimal.Zero, false
+	}
+
+	return stream.walletBalance.Add(streamUnrealized(stream.positions)), true
+}
+
+// streamUnrealized 按标记价格计算未实现盈亏，没有标记价格时用推送的值
+func streamUnrealized(positions map[string]*streamPosition) decimal.Decimal {
+	res := decimal.Zero
+	for k, v := range positions {
+		markPrice := getMarkPrice(strings.Split(k, "&")[0])
+		if 0 >= markPrice {
+			res = res.Add(v.unrealized)
+			continue
+		}
+
+		res = res.Add(v.amount.Mul(decimal.NewFromFloat(markPrice).Sub(v.entry)))
+	}
+
+	return res
+}
+
+// run 维护连接，断线后重新获取listenKey重连，退出时删除listenKey
+func (s *binanceUserStream) run(ctx context.Context) {
+	for {
+		listenKey, err := requestBinanceListenKey(http.MethodPost, s.user.ApiKey)
+		if nil != err {
+			log.Println("用户数据流，获取listenKey失败：", s.user.Id, err)
+		} else {
+			s.serve(ctx, listenKey)
+
+			// 连接结束后删除，重连时重新获取
+			if _, err = requestBinanceListenKey(http.MethodDelete, s.user.ApiKey); nil != err {
+				log.Println("用户数据流，删除listenKey失败：", s.user.Id, err)
+			}
+		}
+
+		if !sleepWithCtx(ctx, userStreamReconnect) {
+			log.Println("用户数据流，退出：", s.user.Id)
+			return
+		}
+	}
+}
+
+// serve 单个连接的生命周期
+func (s *binanceUserStream) serve(ctx context.Context, listenKey string) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, "wss://fstream.binance.com/ws/"+listenKey, nil)
+	if nil != err {
+		log.Println("用户数据流，连接失败：", s.user.Id, err)
+		return
+	}
+
+	// 连上后校准一次，之前推送的变化不会丢
+	s.resync()
+	s.setConnected(true)
+	log.Println("用户数据流，已连接：", s.user.Id)
+
+	connCtx, cancel := context.WithTimeout(ctx, userStreamMaxAge)
+	defer func() {
+		cancel()
+		s.setConnected(false)
+		_ = conn.Close()
+	}()
+
+	// 续期和定期校准，连接结束时关闭连接让读取返回
+	go func() {
+		keepalive := time.NewTicker(listenKeyKeepalive)
+		resync := time.NewTicker(userStreamResync)
+		defer keepalive.Stop()
+		defer resync.Stop()
+
+		for {
+			select {
+			case <-connCtx.Done():
+				_ = conn.Close()
+				return
+			case <-keepalive.C:
+				if _, err := requestBinanceListenKey(http.MethodPut, s.user.ApiKey); nil != err {
+					log.Println("用户数据流，续期失败：", s.user.Id, err)
+				}
+			case <-resync.C:
+				s.resync()
+			}
+		}
+	}()
+
+	_ = conn.SetReadDeadline(time.Now().Add(userStreamReadWait))
+	conn.SetPingHandler(func(appData string) error {
+		_ = conn.SetReadDeadline(time.Now().Add(userStreamReadWait))
+		return conn.WriteControl(websocket.PongMessage, []byte(appData), time.Now().Add(5*time.Second))
+	})
+
+	for {
+		var message []byte
+		_, message, err = conn.ReadMessage()
+		if nil != err {
+			if nil == connCtx.Err() {
+				log.Println("用户数据流，读取错误，重连：", s.user.Id, err)
+			}
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(userStreamReadWait))
+
+		if expired := s.handle(ctx, message); expired {
+			log.Println("用户数据流，listenKey过期，重连：", s.user.Id)
+			return
+		}
+	}
+}
+
+func (s *binanceUserStream) setConnected(connected bool) {
+	s.mu.Lock()
+	s.connected = connected
+	s.mu.Unlock()
+}
+
+// resync 用账户接口校准钱包余额、仓位和可用保证金
+func (s *binanceUserStream) resync() {
+	account := getBinanceAccount(s.user.ApiKey, s.user.ApiSecret)
+	if nil == account {
+		return
+	}
+
+	walletBalance, err := decimal.NewFromString(account.TotalWalletBalance)
+	if nil != err {
+		log.Println("用户数据流，校准钱包余额解析错误：", s.user.Id, account.TotalWalletBalance)
+		return
+	}
+	setAvailableMoney(s.user.Id, account.AvailableBalance)
+
+	positions := make(map[string]*streamPosition, 0)
+	for _, v := range account.Positions {
+		amount, _ := decimal.NewFromString(v.PositionAmt)
+		if amount.IsZero() {
+			continue
+		}
+
+		entry, _ := decimal.NewFromString(v.EntryPrice)
+		unrealized, _ := decimal.NewFromString(v.UnrealizedProfit)
+		positions[v.Symbol+"&"+v.PositionSide] = &streamPosition{amount: amount, entry: entry, unrealized: unrealized}
+	}
+
+	s.mu.Lock()
+	s.walletBalance = walletBalance
+	s.positions = positions
+	s.syncedAt = time.Now()
+	s.mu.Unlock()
+}
+
+// binanceUserStreamEvent 用户数据流推送
+type binanceUserStreamEvent struct {
+	Event     string                     `json:"e"`
+	EventTime int64                      `json:"E"` // 需要声明，否则大小写不敏感匹配到e
+	Order     *binanceOrderTradeUpdate   `json:"o"`
+	Account   *binanceAccountUpdateEvent `json:"a"`
+}
+
+// binanceOrderTradeUpdate 订单推送
+type binanceOrderTradeUpdate struct {
+	Symbol          string `json:"s"`
+	ClientOrderId   string `json:"c"`
+	Side            string `json:"S"`
+	OrderType       string `json:"o"`
+	ExecType        string `json:"x"`
+	OrderStatus     string `json:"X"`
+	OrderId         int64  `json:"i"`
+	LastQty         string `json:"l"`
+	CumQty          string `json:"z"`
+	LastPrice       string `json:"L"`
+	AvgPrice        string `json:"ap"`
+	Commission      string `json:"n"`
+	CommissionAsset string `json:"N"`
+	TradeTime       int64  `json:"T"`
+	TradeId         int64  `json:"t"` // 需要声明，否则大小写不敏感匹配到T
+	PositionSide    string `json:"ps"`
+	ReduceOnly      bool   `json:"R"`
+	RealizedPnl     string `json:"rp"`
+}
+
+// binanceAccountUpdateEvent 余额和仓位推送
+type binanceAccountUpdateEvent struct {
+	Reason   string `json:"m"`
+	Balances []struct {
+		Asset         string `json:"a"`
+		WalletBalance string `json:"wb"`
+	} `json:"B"`
+	Positions []struct {
+		Symbol       string `json:"s"`
+		Amount       string `json:"pa"`
+		EntryPrice   string `json:"ep"`
+		Unrealized   string `json:"up"`
+		PositionSide string `json:"ps"`
+	} `json:"P"`
+}
+
+// handle 处理推送，返回listenKey是否过期
+func (s *binanceUserStream) handle(ctx context.Context, message []byte) bool {
+	var event *binanceUserStreamEvent
+	if err := json.Unmarshal(message, &event); nil != err || nil == event {
+		log.Println("用户数据流，解析错误：", s.user.Id, err, string(message))
+		return false
+	}
+
+	switch event.Event {
+	case "listenKeyExpired":
+		return true
+	case "ORDER_TRADE_UPDATE":
+		if nil != event.Order {
+			s.handleOrder(ctx, event.Order)
+		}
+	case "ACCOUNT_UPDATE":
+		if nil != event.Account {
+			s.handleAccount(event.Account)
+		}
+	}
+
+	return false
+}
+
+// orderSourceOf 成交来源，系统下的单按clientOrderId识别
+func orderSourceOf(clientOrderId string, userId uint) string {
+	if strings.HasPrefix(clientOrderId, "autoclose-") || strings.HasPrefix(clientOrderId, "settlement_autoclose-") {
+		return "liquidation"
+	}
+
+	if strings.HasPrefix(clientOrderId, "adl_autoclose") {
+		return "adl"
+	}
+
+	if parts := strings.Split(clientOrderId, "_"); 4 == len(parts) && strconv.FormatUint(uint64(userId), 36) == parts[1] {
+		return "system"
+	}
+
+	return "manual"
+}
+
+// handleOrder 成交记入流水，非系统下单的平仓成交（强平，自动减仓，手动平仓）同步减少系统仓位。
+// 系统下单的仓位已经在下单返回时更新，手动开仓不计入系统仓位
+func (s *binanceUserStream) handleOrder(ctx context.Context, order *binanceOrderTradeUpdate) {
+	// 撤单失败仍在挂单的限价单，成交和终结按累计数量计入系统仓位
+	if cumQty, err := decimal.NewFromString(order.CumQty); nil == err {
+		applyOpenLimitFill(order.ClientOrderId, cumQty, binanceOrderFinal(order.OrderStatus))
+	}
+
+	if "TRADE" != order.ExecType {
+		return
+	}
+
+	source := orderSourceOf(order.ClientOrderId, s.user.Id)
+	lastQty, err := decimal.NewFromString(order.LastQty)
+	if nil != err {
+		log.Println("用户数据流，成交数量解析错误：", s.user.Id, order.LastQty)
+		return
+	}
+
+	// 单向持仓只减仓的单按买卖方向折算成平空或平多
+	positionSide := order.PositionSide
+	if "BOTH" == positionSide && order.ReduceOnly {
+		positionSide = "LONG"
+		if "BUY" == order.Side {
+			positionSide = "SHORT"
+		}
+	}
+
+	if "system" != source && isCloseOrder(order.Side, positionSide) {
+		key := order.Symbol + "&" + positionSide + "&" + strconv.FormatUint(uint64(s.user.Id), 10)
+		if orderMap.Contains(key) {
+			log.Println("用户数据流，非系统平仓，减少系统仓位：", key, source, lastQty, subOrderQty(key, lastQty))
+		}
+	}
+
+	_, err = g.Model("user_order_ledger").Ctx(ctx).Data(do.UserOrderLedger{
+		UserId:          s.user.Id,
+		Plat:            "binance",
+		Symbol:          order.Symbol,
+		PositionSide:    order.PositionSide,
+		Side:            order.Side,
+		OrderId:         strconv.FormatInt(order.OrderId, 10),
+		ClientOrderId:   order.ClientOrderId,
+		Source:          source,
+		OrderType:       order.OrderType,
+		OrderStatus:     order.OrderStatus,
+		ExecType:        order.ExecType,
+		LastQty:         order.LastQty,
+		CumQty:          order.CumQty,
+		LastPrice:       order.LastPrice,
+		AvgPrice:        order.AvgPrice,
+		RealizedPnl:     order.RealizedPnl,
+		Commission:      order.Commission,
+		CommissionAsset: order.CommissionAsset,
+		TradeTime:       order.TradeTime,
+		CreatedAt:       gtime.Now(),
+	}).Insert()
+	if nil != err {
+		log.Println("用户数据流，成交流水记录失败：", s.user.Id, err)
+	}
+}
+
+// handleAccount 更新钱包余额和仓位
+func (s *binanceUserStream) handleAccount(account *binanceAccountUpdateEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, v := range account.Balances {
+		if "USDT" != v.Asset {
+			continue
+		}
+
+		if tmp, err := decimal.NewFromString(v.WalletBalance); nil == err {
+			s.walletBalance = tmp
+		}
+	}
+
+	for _, v := range account.Positions {
+		key := v.Symbol + "&" + v.PositionSide
+		amount, err := decimal.NewFromString(v.Amount)
+		if nil != err {
+			continue
+		}
+
+		if amount.IsZero() {
+			delete(s.positions, key)
+			continue
+		}
+
+		entry, _ := decimal.NewFromString(v.EntryPrice)
+		unrealized, _ := decimal.NewFromString(v.Unrealized)
+		s.positions[key] = &streamPosition{amount: amount, entry: entry, unrealized: unrealized}
+	}
+}
+
+// requestBinanceListenKey 创建（POST），续期（PUT），删除（DELETE）listenKey
+func requestBinanceListenKey(method string, apiKey string) (string, error) {
+	if err := acquireBinance(apiKey, 1, false, false); nil != err {
+		return "", err
+	}
+
+	req, err := http.NewRequest(method, "https://fapi.binance.com/fapi/v1/listenKey", nil)
+	if nil != err {
+		return "", err
+	}
+	req.Header.Set("X-MBX-APIKEY", apiKey)
+
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Do(req)
+	if nil != err {
+		return "", err
+	}
+	updateBinanceRate(apiKey, resp)
+
+	defer func() {
+		if resp != nil && resp.Body != nil {
+			err := resp.Body.Close()
+			if err != nil {
+				log.Println("关闭响应体错误：", err)
+			}
+		}
+	}()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if nil != err {
+		return "", err
+	}
+
+	var res struct {
+		ListenKey string `json:"listenKey"`
+		Code      int64  `json:"code"`
+		Msg       string `json:"msg"`
+	}
+	if err = json.Unmarshal(b, &res); nil != err {
+		return "", err
+	}
+
+	if 0 != res.Code {
+		return "", errors.New(res.Msg)
+	}
+
+	return res.ListenKey, nil
+}
@@ -6,7 +6,9 @@
 package service
 
 import (
+	"binance_data_gf/internal/model/entity"
 	"context"
+	"time"
 )
 
 type (
@@ -17,7 +19,7 @@ type (
 		PullAndSetBaseMoneyNewGuiTuAndUser(ctx context.Context)
 		// InsertGlobalUsers  新增用户
 		InsertGlobalUsers(ctx context.Context)
-		// PullAndOrderNewGuiTu 拉取binance数据，仓位，根据cookie
+		// PullAndOrderNewGuiTu 拉取binance数据，仓位，根据cookie。先登记任务再开协程同步，收到退出信号后结束
 		PullAndOrderNewGuiTu(ctx context.Context)
 		// CookieErrEmail email
 		CookieErrEmail(ctx context.Context)
@@ -25,7 +27,7 @@ type (
 		GetSystemUserNum(ctx context.Context) map[string]float64
 		// CreateUser set user num
 		CreateUser(ctx context.Context, address, apiKey, apiSecret, plat string, needInit uint64, num float64) error
-		// SetPositionSide set position side
+		// SetPositionSide set hedge position mode, returns 1 when hedge, 2 when the account stays in one-way mode, 0 on failure
 		SetPositionSide(ctx context.Context, plat, apiKey, apiSecret string) (uint64, string)
 		// SetSystemUserNum set user num
 		SetSystemUserNum(ctx context.Context, apiKey string, num float64) error
@@ -45,6 +47,113 @@ type (
 		SetSystemUserPosition(ctx context.Context, system uint64, systemOrder uint64, apiKey string, symbol string, side string, positionSide string, num float64) uint64
 		// SetCookie set cookie
 		SetCookie(ctx context.Context, cookie, token string) int64
+		// Shutdown 等待进行中的下单任务，超时后不再等待，系统仓位落库
+		Shutdown(ctx context.Context, timeout time.Duration)
+		// FlushSystemPositions 系统仓位落库
+		FlushSystemPositions(ctx context.Context) error
+		// LoadSystemPositions 启动时恢复落库的系统仓位，只恢复api可用的用户
+		LoadSystemPositions(ctx context.Context) error
+		// UpdateMarkPrice 更新标记价格
+		UpdateMarkPrice(ctx context.Context)
+		// GetUserRiskLimit get user risk limit
+		GetUserRiskLimit(ctx context.Context, apiKey string) *entity.UserRiskLimit
+		// SetUserRiskLimit set user risk limit
+		SetUserRiskLimit(ctx context.Context, apiKey string, maxSymbolNotional, maxTotalNotional float64, maxPositions int, maxLeverage, minFreeMargin float64, leverageCap int) error
+		// GetUserRiskLogs get user risk logs
+		GetUserRiskLogs(ctx context.Context, apiKey string, limit int) []*entity.UserRiskLog
+		// GetSymbolFilters get symbol filters, global when apiKey is empty
+		GetSymbolFilters(ctx context.Context, apiKey string) map[string][]string
+		// SetSymbolFilter add or remove symbol filter, global when apiKey is empty
+		SetSymbolFilter(ctx context.Context, apiKey, symbol, filterType string, remove bool) error
+		// GetBybitOrderByLinkId get bybit order by order link id
+		GetBybitOrderByLinkId(ctx context.Context, apiKey string, orderLinkId string) (map[string]interface{}, error)
+		// GetSymbolQuarantines get quarantine config, active quarantines and recent events
+		GetSymbolQuarantines(ctx context.Context, limit int) map[string]interface{}
+		// SetSymbolQuarantineConfig set flicker window, count window, threshold and cooldown in seconds
+		SetSymbolQuarantineConfig(ctx context.Context, flickerWindow, countWindow, threshold, cooldown int) error
+		// ResumeSymbolQuarantine resume a quarantined symbol and position side manually
+		ResumeSymbolQuarantine(ctx context.Context, symbol string, positionSide string) error
+		// GetSnapshotConfirm get snapshot confirmation configs, pending changes and latency stats
+		GetSnapshotConfirm(ctx context.Context) map[string]interface{}
+		// SetSnapshotConfirmConfig set confirmation for a change type, polls <= 1 and durationMs <= 0 disable it
+		SetSnapshotConfirmConfig(ctx context.Context, changeType string, polls int, durationMs int, ratio float64) error
+		// SweepDust close leftover position fragments of followers after the trader fully closed the symbol and side
+		SweepDust(ctx context.Context)
+		// GetDustSweepConfig get dust sweep config
+		GetDustSweepConfig(ctx context.Context) *entity.DustSweepConfig
+		// SetDustSweepConfig enable or disable dust sweep and set the max notional of a fragment
+		SetDustSweepConfig(ctx context.Context, enabled int, maxNotional float64) error
+		// GetExecutionPolicies get execution policies of users and symbols
+		GetExecutionPolicies(ctx context.Context) []*entity.ExecutionPolicy
+		// SetExecutionPolicy set execution policy, userId 0 and empty symbol apply to all, limit mode posts opens and adds at best bid or ask with offset and markets the remainder after waitMs, closes are always market
+		SetExecutionPolicy(ctx context.Context, userId uint, symbol string, mode string, offsetBps float64, waitMs int) error
+		// DeleteExecutionPolicy delete execution policy of the user and symbol
+		DeleteExecutionPolicy(ctx context.Context, userId uint, symbol string) error
+		// GetSlippageGuard get slippage guard config and the trader's latest fill and drift of each position
+		GetSlippageGuard(ctx context.Context) map[string]interface{}
+		// SetSlippageGuardConfig enable or disable the slippage guard and set the threshold and max drift in basis points
+		SetSlippageGuardConfig(ctx context.Context, enabled int, thresholdBps float64, maxBps float64) error
+		// GetTraderPositionHistory get the trader's detected position changes, newest first
+		GetTraderPositionHistory(ctx context.Context, symbol string, limit int) []*entity.TraderPositionHistory
+		// GetTraderAnalytics get the trader's trades, win rate, holding time, max drawdown and activity by hour in the last days
+		GetTraderAnalytics(ctx context.Context, days int) map[string]interface{}
+		// SyncUserIncome pull realized pnl, commissions and funding fees of every follower into user_income
+		SyncUserIncome(ctx context.Context)
+		// GetIncomeReport get daily, weekly or monthly income report of the user, or of all followers when apiKey is empty
+		GetIncomeReport(ctx context.Context, apiKey string, period string, days int) (map[string]interface{}, error)
+		// ExportIncomeReportCsv export the income report as csv
+		ExportIncomeReportCsv(ctx context.Context, apiKey string, period string, days int) ([]byte, error)
+		// SettleUserFees settle ended periods of every user with a fee config and pause users with overdue statements
+		SettleUserFees(ctx context.Context)
+		// SetUserFeeConfig set the user's profit share rate, settlement period, basis, payment days and whether to pause on overdue.
+		// A new config starts its first period now with the current equity or realized pnl as the high-water mark, equity basis is binance only.
+		SetUserFeeConfig(ctx context.Context, apiKey string, feeRate float64, period string, basis string, dueDays int, pauseOverdue int) error
+		// DeleteUserFeeConfig delete the user's fee config, existing statements are kept
+		DeleteUserFeeConfig(ctx context.Context, apiKey string) error
+		// GetUserFeeConfigs get fee configs of all users
+		GetUserFeeConfigs(ctx context.Context) []*entity.UserFeeConfig
+		// GetFeeSettlements get fee statements, newest first, filtered by user and status when given
+		GetFeeSettlements(ctx context.Context, apiKey string, status string, limit int) ([]*entity.FeeSettlement, error)
+		// SetFeeSettlementStatus mark a pending statement as paid or waived, and reopen the user if it was paused for overdue
+		// and has no other overdue statement left
+		SetFeeSettlementStatus(ctx context.Context, id uint, status string) error
+		// GetAgents get all agents, token hashes are not returned
+		GetAgents(ctx context.Context) []*entity.Agent
+		// SetAgent create the agent when id is 0 and return its token, otherwise update it.
+		// The commission rate includes the sub-agents' part, so it can not be lower than any sub-agent's or higher than the parent's.
+		SetAgent(ctx context.Context, id uint, parentId uint, name string, commissionRate float64, status int) (string, error)
+		// ResetAgentToken generate a new token for the agent, the old one stops working
+		ResetAgentToken(ctx context.Context, id uint) (string, error)
+		// SetUserAgent set the user's direct agent, 0 for none
+		SetUserAgent(ctx context.Context, apiKey string, agentId uint) error
+		// GetAgentUsers get status, margin and system positions of the followers under the token's agent and its sub-agents.
+		// Api keys are never returned.
+		GetAgentUsers(ctx context.Context, token string) ([]map[string]interface{}, error)
+		// GetAgentCommissions get the token's agent commissions, newest first
+		GetAgentCommissions(ctx context.Context, token string, limit int) ([]*entity.AgentCommission, error)
+		// GetNotifications get notifications, newest first, filtered by user when userId is not 0
+		GetNotifications(ctx context.Context, userId uint, limit int) []*entity.Notification
+		// SetNotifyConfig enable or disable pushing notifications to the webhook
+		SetNotifyConfig(ctx context.Context, enabled int, webhookUrl string) error
+		// CheckSubscriptions warn users whose subscription is about to expire, notify on expiry and deactivate users after the grace period
+		CheckSubscriptions(ctx context.Context)
+		// SetUserSubscription set the user's paid-until time, warning days, grace days and whether to deactivate the user after the grace period
+		SetUserSubscription(ctx context.Context, apiKey string, expireAt string, warnDays int, graceDays int, deactivate int) error
+		// ExtendUserSubscription renew the user's subscription by days from the current expiry, or from now when already expired.
+		// Users without a subscription get one with default warning and grace days.
+		ExtendUserSubscription(ctx context.Context, apiKey string, days int) error
+		// DeleteUserSubscription remove the user's subscription, the user is no longer limited by expiry
+		DeleteUserSubscription(ctx context.Context, apiKey string) error
+		// GetUserSubscriptions get subscriptions of all users with their current status
+		GetUserSubscriptions(ctx context.Context) []*entity.UserSubscription
+		// GetUserSizings get sizing mode of every configured user, userId 0 applies to users without their own
+		GetUserSizings(ctx context.Context) []*entity.UserSizing
+		// SetUserSizing set the user's sizing mode with its value and per order min and max notional, userId 0 applies to all users
+		SetUserSizing(ctx context.Context, userId uint, mode string, value float64, minNotional float64, maxNotional float64) error
+		// DeleteUserSizing delete the user's sizing mode, the user falls back to the global one or margin ratio
+		DeleteUserSizing(ctx context.Context, userId uint) error
+		// GetMoneyFreshness get the last successful margin refresh time of the trader and every following user
+		GetMoneyFreshness(ctx context.Context) map[string]interface{}
 	}
 )
 
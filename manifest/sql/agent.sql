@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS `agent` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `parent_id` int unsigned NOT NULL DEFAULT '0' COMMENT '上级代理id，0为顶级代理',
+  `name` varchar(64) NOT NULL DEFAULT '' COMMENT '代理名称',
+  `token_hash` char(64) NOT NULL DEFAULT '' COMMENT '代理查询token的sha256',
+  `commission_rate` decimal(10,4) NOT NULL DEFAULT '0' COMMENT '佣金比例，按分成计算，包含下级代理的部分，0.3为30%',
+  `status` tinyint NOT NULL DEFAULT '1' COMMENT '状态：1可用，2停用',
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_token_hash` (`token_hash`),
+  KEY `idx_parent` (`parent_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `agent_commission` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `agent_id` int unsigned NOT NULL DEFAULT '0' COMMENT '代理id',
+  `settlement_id` int unsigned NOT NULL DEFAULT '0' COMMENT '分成账单id',
+  `user_id` int unsigned NOT NULL DEFAULT '0' COMMENT '用户id',
+  `level` int NOT NULL DEFAULT '0' COMMENT '层级，0为用户的直属代理',
+  `rate` decimal(10,4) NOT NULL DEFAULT '0' COMMENT '本代理实得比例，自身比例减去下级代理比例',
+  `fee` decimal(20,8) NOT NULL DEFAULT '0' COMMENT '账单分成',
+  `commission` decimal(20,8) NOT NULL DEFAULT '0' COMMENT '佣金',
+  `created_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_agent_settlement` (`agent_id`,`settlement_id`),
+  KEY `idx_settlement` (`settlement_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- user.dai 为用户的直属代理id，0为没有代理
+ALTER TABLE `user` ADD INDEX `idx_dai` (`dai`);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS `dust_sweep_config` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `enabled` tinyint NOT NULL DEFAULT '1' COMMENT '是否开启：1开启',
+  `max_notional` decimal(36,8) NOT NULL DEFAULT '5' COMMENT '名义价值低于该值的剩余仓位视为碎仓',
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `execution_policy` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` int unsigned NOT NULL DEFAULT '0' COMMENT '用户id，0为所有用户',
+  `symbol` varchar(32) NOT NULL DEFAULT '' COMMENT '币种，空为所有币种',
+  `mode` varchar(16) NOT NULL DEFAULT 'market' COMMENT '下单方式：market市价，limit限价',
+  `offset_bps` decimal(10,2) NOT NULL DEFAULT '0' COMMENT '限价相对买一卖一的偏移，万分之一，正数更容易成交',
+  `wait_ms` int NOT NULL DEFAULT '0' COMMENT '限价单等待成交的毫秒数，超时撤单后剩余市价',
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_user_symbol` (`user_id`,`symbol`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS `user_fee_config` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` int unsigned NOT NULL DEFAULT '0' COMMENT '用户id',
+  `fee_rate` decimal(10,4) NOT NULL DEFAULT '0' COMMENT '分成比例，0.2为20%',
+  `period` varchar(16) NOT NULL DEFAULT 'month' COMMENT '结算周期：day，week，month',
+  `basis` varchar(16) NOT NULL DEFAULT 'equity' COMMENT '盈利计算方式：equity账户权益，realized已实现盈亏',
+  `high_water_mark` decimal(20,8) NOT NULL DEFAULT '0' COMMENT '高水位，equity为权益，realized为累计已实现盈亏',
+  `period_start` datetime DEFAULT NULL COMMENT '当前未结算周期的开始时间',
+  `due_days` int NOT NULL DEFAULT '7' COMMENT '账单生成后多少天内需支付',
+  `pause_overdue` tinyint NOT NULL DEFAULT '0' COMMENT '账单逾期时是否暂停开新仓：1暂停',
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_user` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `fee_settlement` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` int unsigned NOT NULL DEFAULT '0' COMMENT '用户id',
+  `basis` varchar(16) NOT NULL DEFAULT '' COMMENT '盈利计算方式：equity账户权益，realized已实现盈亏',
+  `period_start` datetime DEFAULT NULL COMMENT '结算周期开始时间',
+  `period_end` datetime DEFAULT NULL COMMENT '结算周期结束时间',
+  `start_high_water_mark` decimal(20,8) NOT NULL DEFAULT '0' COMMENT '结算前的高水位',
+  `transfers` decimal(20,8) NOT NULL DEFAULT '0' COMMENT '周期内的净转入，equity方式下计入高水位',
+  `end_value` decimal(20,8) NOT NULL DEFAULT '0' COMMENT '结算时的权益或累计已实现盈亏',
+  `profit` decimal(20,8) NOT NULL DEFAULT '0' COMMENT '超过高水位的盈利',
+  `fee_rate` decimal(10,4) NOT NULL DEFAULT '0' COMMENT '分成比例',
+  `fee` decimal(20,8) NOT NULL DEFAULT '0' COMMENT '应付分成',
+  `end_high_water_mark` decimal(20,8) NOT NULL DEFAULT '0' COMMENT '结算后的高水位',
+  `status` varchar(16) NOT NULL DEFAULT 'pending' COMMENT '状态：pending待支付，paid已支付，waived已免除',
+  `due_at` datetime DEFAULT NULL COMMENT '支付截止时间',
+  `paid_at` datetime DEFAULT NULL COMMENT '支付时间',
+  `paused` tinyint NOT NULL DEFAULT '0' COMMENT '是否因逾期暂停了开新仓：1是，2已恢复',
+  `created_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_user_period` (`user_id`,`period_start`),
+  KEY `idx_status_due` (`status`,`due_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
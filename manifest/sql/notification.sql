@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS `notification` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` int unsigned NOT NULL DEFAULT '0' COMMENT '用户id，0为系统',
+  `category` varchar(32) NOT NULL DEFAULT '' COMMENT '类别',
+  `title` varchar(255) NOT NULL DEFAULT '' COMMENT '标题',
+  `content` text COMMENT '内容',
+  `sent` tinyint NOT NULL DEFAULT '0' COMMENT 'webhook推送状态：0未推送，1成功，2失败',
+  `created_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_user` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `notify_config` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `enabled` tinyint NOT NULL DEFAULT '0' COMMENT '是否推送webhook：1推送',
+  `webhook_url` varchar(512) NOT NULL DEFAULT '' COMMENT 'webhook地址，POST json：title，content，category，userId',
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS `slippage_guard_config` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `enabled` tinyint NOT NULL DEFAULT '0' COMMENT '是否开启：1开启',
+  `threshold_bps` decimal(10,2) NOT NULL DEFAULT '0' COMMENT '价格偏离带单员成交价超过该值（万分之一）后减少或跳过开仓加仓',
+  `max_bps` decimal(10,2) NOT NULL DEFAULT '0' COMMENT '偏离达到该值时跳过，阈值到该值之间按比例减少，不大于阈值时超过阈值直接跳过',
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `snapshot_confirm_config` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `change_type` varchar(16) NOT NULL DEFAULT '' COMMENT '变化类型：close完全平仓，reduce大幅减仓，jump仓位突增',
+  `polls` int NOT NULL DEFAULT '0' COMMENT '连续多少次拉取一致后确认，0不按次数',
+  `duration_ms` int NOT NULL DEFAULT '0' COMMENT '持续多少毫秒后确认，0不按时间',
+  `ratio` decimal(20,8) NOT NULL DEFAULT '0' COMMENT '减仓或突增的比例阈值，完全平仓不用',
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_change_type` (`change_type`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS `symbol_filter` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` int unsigned NOT NULL DEFAULT '0' COMMENT '用户id，0为全局',
+  `symbol` varchar(64) NOT NULL DEFAULT '' COMMENT '币种，如BTCUSDT',
+  `filter_type` varchar(16) NOT NULL DEFAULT '' COMMENT 'allow：只允许名单内币种，deny：禁止',
+  `created_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_user_symbol_type` (`user_id`,`symbol`,`filter_type`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
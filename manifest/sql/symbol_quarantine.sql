@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS `symbol_quarantine_config` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `flicker_window` int NOT NULL DEFAULT '30' COMMENT '开仓或加仓后多少秒内完全平仓算闪烁',
+  `count_window` int NOT NULL DEFAULT '600' COMMENT '闪烁计数的统计窗口，秒',
+  `threshold` int NOT NULL DEFAULT '1' COMMENT '统计窗口内闪烁次数达到多少时隔离',
+  `cooldown` int NOT NULL DEFAULT '1800' COMMENT '隔离多少秒后自动恢复',
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `symbol_quarantine` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `symbol` varchar(64) NOT NULL DEFAULT '' COMMENT '币种',
+  `position_side` varchar(16) NOT NULL DEFAULT '' COMMENT '仓位方向',
+  `flicker_count` int NOT NULL DEFAULT '0' COMMENT '触发时统计窗口内的闪烁次数',
+  `open_at` bigint NOT NULL DEFAULT '0' COMMENT '最近开仓或加仓时间，秒',
+  `close_at` bigint NOT NULL DEFAULT '0' COMMENT '触发的完全平仓时间，秒',
+  `resume_at` bigint NOT NULL DEFAULT '0' COMMENT '自动恢复时间，秒',
+  `resumed_at` datetime DEFAULT NULL COMMENT '实际恢复时间，未恢复为空',
+  `resume_type` varchar(16) NOT NULL DEFAULT '' COMMENT '恢复方式：auto自动，manual手动',
+  `created_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_symbol_side` (`symbol`,`position_side`),
+  KEY `idx_resumed_at` (`resumed_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
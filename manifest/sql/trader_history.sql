@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS `trader_position_history` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `symbol` varchar(32) NOT NULL DEFAULT '',
+  `position_side` varchar(8) NOT NULL DEFAULT '' COMMENT 'LONG，SHORT，单向持仓按正负拆成多空',
+  `change_type` varchar(8) NOT NULL DEFAULT '' COMMENT 'open开仓，add加仓，reduce减仓，close平仓',
+  `old_amount` decimal(36,8) NOT NULL DEFAULT '0' COMMENT '变化前数量',
+  `new_amount` decimal(36,8) NOT NULL DEFAULT '0' COMMENT '变化后数量',
+  `entry_price` decimal(36,8) NOT NULL DEFAULT '0' COMMENT '变化后的开仓均价，平仓为平仓前的均价',
+  `mark_price` decimal(36,8) NOT NULL DEFAULT '0' COMMENT '拉到变化时的标记价格',
+  `change_at` bigint NOT NULL DEFAULT '0' COMMENT '拉到变化的时间，毫秒',
+  `created_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_symbol_side` (`symbol`,`position_side`),
+  KEY `idx_change_at` (`change_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `trader_margin_history` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `margin` decimal(36,8) NOT NULL DEFAULT '0' COMMENT '带单员保证金',
+  `created_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS `user_income` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` int unsigned NOT NULL DEFAULT '0',
+  `plat` varchar(16) NOT NULL DEFAULT '' COMMENT 'binance，bybit',
+  `income_type` varchar(32) NOT NULL DEFAULT '' COMMENT 'REALIZED_PNL已实现盈亏，COMMISSION手续费，FUNDING_FEE资金费，其他按平台原样',
+  `symbol` varchar(32) NOT NULL DEFAULT '',
+  `asset` varchar(16) NOT NULL DEFAULT '',
+  `income` decimal(36,8) NOT NULL DEFAULT '0' COMMENT '收入，支出为负数',
+  `tran_id` varchar(64) NOT NULL DEFAULT '' COMMENT '平台流水号',
+  `info` varchar(64) NOT NULL DEFAULT '',
+  `income_time` bigint NOT NULL DEFAULT '0' COMMENT '平台时间，毫秒',
+  `created_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_user_tran` (`user_id`,`plat`,`tran_id`,`income_type`),
+  KEY `idx_user_time` (`user_id`,`income_time`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS `user_income_cursor` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` int unsigned NOT NULL DEFAULT '0',
+  `plat` varchar(16) NOT NULL DEFAULT '' COMMENT 'binance，bybit',
+  `synced_to` bigint NOT NULL DEFAULT '0' COMMENT '已完整同步到的平台时间，毫秒',
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_user_plat` (`user_id`,`plat`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
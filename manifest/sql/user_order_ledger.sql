@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS `user_order_ledger` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` int unsigned NOT NULL DEFAULT '0' COMMENT '用户id',
+  `plat` varchar(16) NOT NULL DEFAULT '' COMMENT '平台',
+  `symbol` varchar(64) NOT NULL DEFAULT '' COMMENT '币种',
+  `position_side` varchar(16) NOT NULL DEFAULT '' COMMENT '仓位方向',
+  `side` varchar(16) NOT NULL DEFAULT '' COMMENT '买卖方向',
+  `order_id` varchar(64) NOT NULL DEFAULT '' COMMENT '交易所订单号',
+  `client_order_id` varchar(64) NOT NULL DEFAULT '' COMMENT '自定义订单号',
+  `source` varchar(16) NOT NULL DEFAULT '' COMMENT '来源：system系统，manual手动，liquidation强平，adl自动减仓',
+  `order_type` varchar(32) NOT NULL DEFAULT '' COMMENT '订单类型',
+  `order_status` varchar(32) NOT NULL DEFAULT '' COMMENT '订单状态',
+  `exec_type` varchar(32) NOT NULL DEFAULT '' COMMENT '执行类型',
+  `last_qty` decimal(36,18) NOT NULL DEFAULT '0' COMMENT '本次成交数量',
+  `cum_qty` decimal(36,18) NOT NULL DEFAULT '0' COMMENT '累计成交数量',
+  `last_price` decimal(36,18) NOT NULL DEFAULT '0' COMMENT '本次成交价格',
+  `avg_price` decimal(36,18) NOT NULL DEFAULT '0' COMMENT '成交均价',
+  `realized_pnl` decimal(36,18) NOT NULL DEFAULT '0' COMMENT '本次成交实现盈亏',
+  `commission` decimal(36,18) NOT NULL DEFAULT '0' COMMENT '手续费',
+  `commission_asset` varchar(16) NOT NULL DEFAULT '' COMMENT '手续费资产',
+  `trade_time` bigint NOT NULL DEFAULT '0' COMMENT '成交时间，毫秒',
+  `created_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_user_symbol` (`user_id`,`symbol`),
+  KEY `idx_order_id` (`order_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
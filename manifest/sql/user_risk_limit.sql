@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS `user_risk_limit` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` int unsigned NOT NULL DEFAULT '0' COMMENT '用户id',
+  `max_symbol_notional` decimal(36,8) NOT NULL DEFAULT '0' COMMENT '单币种最大名义价值，0不限制',
+  `max_total_notional` decimal(36,8) NOT NULL DEFAULT '0' COMMENT '总持仓最大名义价值，0不限制',
+  `max_positions` int NOT NULL DEFAULT '0' COMMENT '最大同时持仓数，0不限制',
+  `max_leverage` decimal(20,8) NOT NULL DEFAULT '0' COMMENT '最大有效杠杆，0不限制',
+  `min_free_margin` decimal(36,8) NOT NULL DEFAULT '0' COMMENT '最低可用保证金，0不限制',
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `user_risk_log` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` int unsigned NOT NULL DEFAULT '0' COMMENT '用户id',
+  `symbol` varchar(64) NOT NULL DEFAULT '' COMMENT '币种',
+  `position_side` varchar(16) NOT NULL DEFAULT '' COMMENT '仓位方向',
+  `qty` decimal(36,18) NOT NULL DEFAULT '0' COMMENT '原始开仓数量',
+  `allow_qty` decimal(36,18) NOT NULL DEFAULT '0' COMMENT '风控后数量，0为跳过',
+  `reason` varchar(255) NOT NULL DEFAULT '' COMMENT '风控原因',
+  `created_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
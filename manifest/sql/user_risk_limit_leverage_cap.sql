@@ -0,0 +1,2 @@
+ALTER TABLE `user_risk_limit`
+  ADD COLUMN `leverage_cap` int NOT NULL DEFAULT '0' COMMENT '跟随交易员设置杠杆的上限，0不限制' AFTER `min_free_margin`;
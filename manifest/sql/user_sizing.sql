@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `user_sizing` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` int unsigned NOT NULL DEFAULT '0' COMMENT '用户id，0为所有用户',
+  `mode` varchar(32) NOT NULL DEFAULT 'ratio' COMMENT '下单数量方式：ratio按保证金比例，fixed_notional固定名义价值，equity_fraction固定保证金比例，trader_multiple带单员数量倍数，capped_ratio限制比例',
+  `value` decimal(20,8) NOT NULL DEFAULT '0' COMMENT 'ratio不使用，fixed_notional为每笔名义价值，equity_fraction为每笔使用的保证金比例，trader_multiple为倍数，capped_ratio为最大比例',
+  `min_notional` decimal(20,8) NOT NULL DEFAULT '0' COMMENT '每笔最小名义价值，0不限制',
+  `max_notional` decimal(20,8) NOT NULL DEFAULT '0' COMMENT '每笔最大名义价值，0不限制',
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_user` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `user_subscription` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` int unsigned NOT NULL DEFAULT '0' COMMENT '用户id',
+  `expire_at` datetime DEFAULT NULL COMMENT '订阅到期时间',
+  `warn_days` int NOT NULL DEFAULT '3' COMMENT '到期前多少天开始提醒',
+  `grace_days` int NOT NULL DEFAULT '3' COMMENT '到期后的宽限天数，宽限期内只跟平仓',
+  `deactivate` tinyint NOT NULL DEFAULT '0' COMMENT '宽限期后是否停用用户：1停用',
+  `status` varchar(16) NOT NULL DEFAULT 'active' COMMENT '状态：active正常，expiring即将到期，expired已到期，deactivated已停用',
+  `warned_at` datetime DEFAULT NULL COMMENT '最近一次到期提醒时间',
+  `created_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_user` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
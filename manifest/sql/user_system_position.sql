@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `user_system_position` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` int unsigned NOT NULL DEFAULT '0' COMMENT '用户id',
+  `symbol` varchar(64) NOT NULL DEFAULT '' COMMENT '币种',
+  `position_side` varchar(16) NOT NULL DEFAULT '' COMMENT '仓位方向：LONG，SHORT',
+  `amount` decimal(36,18) NOT NULL DEFAULT '0' COMMENT '系统仓位',
+  `tmp_amount` decimal(36,18) NOT NULL DEFAULT '0' COMMENT '暂存的预备仓位',
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_user_symbol_side` (`user_id`,`symbol`,`position_side`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;